@@ -0,0 +1,49 @@
+IPMITOOL_VERSION="1.8.19"
+IPMITOOL_LINK="https://github.com/ipmitool/ipmitool"
+
+download_ipmitool() {
+    download_git $IPMITOOL_LINK "IPMITOOL_${IPMITOOL_VERSION//./_}"
+}
+
+prepare_ipmitool() {
+    echo "[+] prepare ipmitool"
+    github_name "ipmitool-${IPMITOOL_VERSION}"
+}
+
+compile_ipmitool() {
+    echo "[+] compiling ipmitool"
+    ./bootstrap
+    ./configure --prefix=/usr --enable-intf-lanplus --disable-intf-usb
+    make ${MAKEOPTS}
+}
+
+install_ipmitool() {
+    echo "[+] installing ipmitool"
+    mkdir -p "${ROOTDIR}/usr/bin"
+    cp src/ipmitool "${ROOTDIR}/usr/bin/ipmitool"
+    chmod +x "${ROOTDIR}/usr/bin/ipmitool"
+}
+
+build_ipmitool() {
+    apt-get install -y \
+        build-essential \
+        git \
+        autoconf \
+        automake \
+        libtool \
+        pkg-config \
+        libssl-dev \
+        libreadline-dev
+
+    pushd "${WORKDIR}"
+
+    download_ipmitool
+    prepare_ipmitool
+
+    pushd "ipmitool"
+    compile_ipmitool
+    install_ipmitool
+    popd
+
+    popd
+}
@@ -0,0 +1,49 @@
+RADVD_VERSION="2.19"
+RADVD_LINK="https://github.com/radvd-project/radvd"
+
+download_radvd() {
+    download_git $RADVD_LINK "v${RADVD_VERSION}"
+}
+
+prepare_radvd() {
+    echo "[+] prepare radvd"
+    github_name "radvd-${RADVD_VERSION}"
+}
+
+compile_radvd() {
+    echo "[+] compiling radvd"
+    ./autogen.sh
+    ./configure --prefix=/usr --sysconfdir=/etc
+    make ${MAKEOPTS}
+}
+
+install_radvd() {
+    echo "[+] installing radvd"
+    mkdir -p "${ROOTDIR}/usr/sbin"
+    cp radvd "${ROOTDIR}/usr/sbin/radvd"
+    chmod +x "${ROOTDIR}/usr/sbin/radvd"
+}
+
+build_radvd() {
+    apt-get install -y \
+        build-essential \
+        git \
+        autoconf \
+        automake \
+        libtool \
+        pkg-config \
+        bison \
+        flex
+
+    pushd "${WORKDIR}"
+
+    download_radvd
+    prepare_radvd
+
+    pushd "radvd"
+    compile_radvd
+    install_radvd
+    popd
+
+    popd
+}
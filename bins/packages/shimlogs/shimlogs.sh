@@ -1,17 +1,23 @@
-SHIMLOGS_VERSION="0.3"
-SHIMLOGS_CHECKSUM="f2b3ceaca8abe09fe6b96b694569d0a3"
-SHIMLOGS_LINK="https://github.com/threefoldtech/shim-logs/archive/v${SHIMLOGS_VERSION}.tar.gz"
+SHIMLOGS_VERSION="0.4"
+SHIMLOGS_SOURCE="${ROOTPKG}/../../cmds/shim-logs"
+HIREDIS_VERSION="1.2.0"
+HIREDIS_LINK="https://github.com/redis/hiredis"
 
 dependencies_shimlogs() {
-    apt-get install -y libjansson-dev libhiredis-dev build-essential
+    apt-get install -y \
+        build-essential \
+        git \
+        libjansson-dev \
+        libssl-dev
 }
 
 download_shimlogs() {
-    download_file ${SHIMLOGS_LINK} ${SHIMLOGS_CHECKSUM} shim-logs-${SHIMLOGS_VERSION}.tar.gz
+    download_git ${HIREDIS_LINK} "v${HIREDIS_VERSION}"
 }
 
 extract_shimlogs() {
-    tar -xf ${DISTDIR}/shim-logs-${SHIMLOGS_VERSION}.tar.gz -C ${WORKDIR}
+    rm -rf ${WORKDIR}/shim-logs-${SHIMLOGS_VERSION}
+    cp -a ${SHIMLOGS_SOURCE} ${WORKDIR}/shim-logs-${SHIMLOGS_VERSION}
 }
 
 prepare_shimlogs() {
@@ -19,8 +25,18 @@ prepare_shimlogs() {
     github_name "shim-logs-${SHIMLOGS_VERSION}"
 }
 
+compile_hiredis() {
+    echo "[+] compile hiredis (with ssl)"
+
+    # the distribution hiredis is not always built with ssl support
+    make ${MAKEOPTS} USE_SSL=1
+    make USE_SSL=1 PREFIX=/usr/local install
+    ldconfig
+}
+
 compile_shimlogs() {
     echo "[+] compile shim-logs"
+    make clean
     make
 }
 
@@ -38,6 +54,11 @@ build_shimlogs() {
 
     dependencies_shimlogs
     download_shimlogs
+
+    pushd hiredis
+    compile_hiredis
+    popd
+
     extract_shimlogs
 
     popd
@@ -49,4 +70,3 @@ build_shimlogs() {
 
     popd
 }
-
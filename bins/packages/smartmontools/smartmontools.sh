@@ -0,0 +1,54 @@
+SMARTMONTOOLS_VERSION="7.4"
+SMARTMONTOOLS_LINK="https://github.com/smartmontools/smartmontools"
+
+dependencies_smartmontools() {
+    apt-get install -y \
+        build-essential \
+        git \
+        autoconf \
+        automake
+}
+
+download_smartmontools() {
+    download_git $SMARTMONTOOLS_LINK "RELEASE_${SMARTMONTOOLS_VERSION//./_}"
+}
+
+prepare_smartmontools() {
+    echo "[+] prepare smartmontools"
+    github_name "smartmontools-${SMARTMONTOOLS_VERSION}"
+}
+
+compile_smartmontools() {
+    echo "[+] compiling smartmontools"
+    ./autogen.sh
+    ./configure --prefix=/usr \
+        --without-libsystemd \
+        --without-systemdsystemunitdir \
+        --without-update-smart-drivedb \
+        LDFLAGS="-static"
+
+    make ${MAKEOPTS} smartctl
+}
+
+install_smartmontools() {
+    echo "[+] installing smartmontools"
+    mkdir -p "${ROOTDIR}/usr/sbin"
+    cp smartctl "${ROOTDIR}/usr/sbin/smartctl"
+    chmod +x "${ROOTDIR}/usr/sbin/smartctl"
+}
+
+build_smartmontools() {
+    dependencies_smartmontools
+
+    pushd "${WORKDIR}"
+
+    download_smartmontools
+    prepare_smartmontools
+
+    pushd "smartmontools/smartmontools"
+    compile_smartmontools
+    install_smartmontools
+    popd
+
+    popd
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg/app"
+	"github.com/threefoldtech/test/pkg/stubs"
+	"github.com/threefoldtech/test/pkg/version"
+
+	"github.com/rs/zerolog/log"
+)
+
+// parseTime parses a time given as a unix timestamp, RFC3339 date or a
+// duration before now (for example 48h)
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time '%s', expecting unix timestamp, RFC3339 date or duration", value)
+}
+
+func main() {
+	app.Initialize()
+
+	var (
+		msgBrokerCon string
+		from         string
+		to           string
+		twin         uint
+		format       string
+		output       string
+		ver          bool
+	)
+
+	flag.StringVar(&msgBrokerCon, "broker", "unix:///var/run/redis.sock", "connection string to the message broker")
+	flag.StringVar(&from, "from", "24h", "start of the time range (unix timestamp, RFC3339 date or duration before now)")
+	flag.StringVar(&to, "to", "0s", "end of the time range (unix timestamp, RFC3339 date or duration before now)")
+	flag.UintVar(&twin, "twin", 0, "only show the contracts of this twin (default all)")
+	flag.StringVar(&format, "format", "json", "export format (json or csv)")
+	flag.StringVar(&output, "o", "", "write report to file instead of stdout")
+	flag.BoolVar(&ver, "v", false, "show version and exit")
+
+	flag.Parse()
+	if ver {
+		version.ShowAndExit(false)
+	}
+
+	now := time.Now()
+	start, err := parseTime(from, now)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid start time")
+	}
+
+	end, err := parseTime(to, now)
+	if err != nil {
+		log.Fatal().Err(err).Msg("invalid end time")
+	}
+
+	cl, err := zbus.NewRedisClient(msgBrokerCon)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize zbus client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	report, err := stubs.NewConsumptionStub(cl).Consumption(ctx, uint32(twin), start.Unix(), end.Unix())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to get consumption report")
+	}
+
+	var out io.Writer = os.Stdout
+	if len(output) != 0 {
+		file, err := os.Create(output)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to create output file")
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "csv":
+		err = report.WriteCSV(out)
+	default:
+		log.Fatal().Str("format", format).Msg("unknown format, expecting json or csv")
+	}
+
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to write report")
+	}
+}
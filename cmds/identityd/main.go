@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/threefoldtech/test/pkg/app"
+	"github.com/threefoldtech/test/pkg/diagnostics"
 	"github.com/threefoldtech/test/pkg/stubs"
 	"github.com/threefoldtech/test/pkg/upgrade"
 
@@ -44,6 +45,7 @@ func main() {
 		broker   string
 		root     string
 		interval int
+		window   int
 		ver      bool
 		debug    bool
 
@@ -56,6 +58,7 @@ func main() {
 	flag.StringVar(&root, "root", "/var/cache/modules/identityd", "root working directory of the module")
 	flag.StringVar(&broker, "broker", redisSocket, "connection string to broker")
 	flag.IntVar(&interval, "interval", 600, "interval in seconds between update checks, default to 600")
+	flag.IntVar(&window, "health-window", 600, "time in seconds the node health is checked after an upgrade before it's rolled back on failure")
 	flag.BoolVar(&ver, "v", false, "show version and exit")
 	flag.BoolVar(&debug, "d", false, "when set, no self update is done before upgrading")
 	flag.BoolVar(&id, "id", false, "[deprecated] prints the node ID and exits")
@@ -108,12 +111,26 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to create identity manager")
 	}
 
-	upgrader, err := upgrade.NewUpgrader(root, upgrade.NoZosUpgrade(debug), upgrade.ZbusClient(client))
+	diagnosticsManager, err := diagnostics.NewDiagnosticsManager(broker, client)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create diagnostics manager")
+	}
+
+	upgrader, err := upgrade.NewUpgrader(
+		root,
+		upgrade.NoZosUpgrade(debug),
+		upgrade.ZbusClient(client),
+		upgrade.HealthWindow(time.Duration(window)*time.Second),
+		upgrade.HealthGates(
+			upgrade.NewDiagnosticsGate(diagnosticsManager),
+			upgrade.NewHealthcheckGate(client),
+		),
+	)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize upgrader")
 	}
 
-	monitor := newVersionMonitor(10*time.Second, upgrader.Version())
+	monitor := newVersionMonitor(10*time.Second, upgrader)
 	// 3. start zbus server to serve identity interface
 	log.Info().Stringer("version", monitor.GetVersion()).Msg("current")
 
@@ -124,6 +141,7 @@ func main() {
 
 	server.Register(zbus.ObjectID{Name: "manager", Version: "0.0.1"}, idMgr)
 	server.Register(zbus.ObjectID{Name: "monitor", Version: "0.0.1"}, monitor)
+	server.Register(zbus.ObjectID{Name: "upgrader", Version: "0.0.1"}, newUpgraderAPI(upgrader))
 
 	ctx, cancel := utils.WithSignal(context.Background())
 	// register the cancel function with defer if the process stops because of a update
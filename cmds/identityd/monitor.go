@@ -6,20 +6,23 @@ import (
 
 	"github.com/blang/semver"
 	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/upgrade"
 )
 
 type monitorStream struct {
 	duration time.Duration
 	version  semver.Version
+	upgrader *upgrade.Upgrader
 }
 
 var _ pkg.VersionMonitor = (*monitorStream)(nil)
 
 // newVersionMonitor creates a new instance of version monitor
-func newVersionMonitor(d time.Duration, version semver.Version) *monitorStream {
+func newVersionMonitor(d time.Duration, upgrader *upgrade.Upgrader) *monitorStream {
 	return &monitorStream{
 		duration: d,
-		version:  version,
+		version:  upgrader.Version(),
+		upgrader: upgrader,
 	}
 }
 
@@ -27,6 +30,10 @@ func (m *monitorStream) GetVersion() semver.Version {
 	return m.version
 }
 
+func (m *monitorStream) LastUpgrade() (pkg.UpgradeReport, error) {
+	return m.upgrader.LastUpgrade()
+}
+
 func (m *monitorStream) Version(ctx context.Context) <-chan semver.Version {
 	ch := make(chan semver.Version)
 	go func() {
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/upgrade"
+)
+
+// upgraderAPI is the upgrader object exposed over zbus. It only exposes
+// the upgrade report and control methods, the upgrader itself (Run, ...)
+// is never reachable from other modules.
+type upgraderAPI struct {
+	upgrader *upgrade.Upgrader
+}
+
+var _ pkg.Upgrader = (*upgraderAPI)(nil)
+
+func newUpgraderAPI(upgrader *upgrade.Upgrader) *upgraderAPI {
+	return &upgraderAPI{upgrader: upgrader}
+}
+
+func (u *upgraderAPI) ApplyNow() error {
+	return u.upgrader.ApplyNow()
+}
+
+func (u *upgraderAPI) LastUpgrade() (pkg.UpgradeReport, error) {
+	return u.upgrader.LastUpgrade()
+}
+
+func (u *upgraderAPI) MaintenanceGet() (pkg.MaintenanceConfig, error) {
+	return u.upgrader.MaintenanceGet()
+}
+
+func (u *upgraderAPI) MaintenanceSet(cfg pkg.MaintenanceConfig) error {
+	return u.upgrader.MaintenanceSet(cfg)
+}
+
+func (u *upgraderAPI) Pending() (pkg.PendingUpgrade, error) {
+	return u.upgrader.Pending()
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/rs/zerolog/log"
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/tfgrid-sdk-go/rmb-sdk-go/peer"
+	"github.com/threefoldtech/tfgrid-sdk-go/rmb-sdk-go/peer/types"
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg/environment"
+	"github.com/threefoldtech/test/pkg/identity/signer"
 	"github.com/threefoldtech/test/pkg/stubs"
 	substrategw "github.com/threefoldtech/test/pkg/substrate_gateway"
 	"github.com/threefoldtech/test/pkg/utils"
@@ -19,7 +21,11 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-const module = "api-gateway"
+const (
+	module = "api-gateway"
+	// rpcSession is the rmb session of the rpc client
+	rpcSession = "test-rpc"
+)
 
 // Module entry point
 var Module cli.Command = cli.Command{
@@ -56,19 +62,17 @@ func action(cli *cli.Context) error {
 	}
 	idStub := stubs.NewIdentityManagerStub(redis)
 
-	sk := ed25519.PrivateKey(idStub.PrivateKey(cli.Context))
-	id, err := substrate.NewIdentityFromEd25519Key(sk)
-	log.Info().Str("address", id.Address()).Msg("node address")
+	id, err := nodeIdentity(idStub)
 	if err != nil {
 		return err
 	}
+	log.Info().Str("address", id.Address()).Msg("node address")
 
 	manager, err := environment.GetSubstrate()
 	if err != nil {
 		return fmt.Errorf("failed to create substrate manager: %w", err)
 	}
 
-	router := peer.NewRouter()
 	gw, err := substrategw.NewSubstrateGateway(manager, id)
 	if err != nil {
 		return fmt.Errorf("failed to create api gateway: %w", err)
@@ -92,25 +96,54 @@ func action(cli *cli.Context) error {
 		}
 	}()
 
-	api, err := testapi.NewZosAPI(manager, redis, msgBrokerCon)
-	if err != nil {
-		return fmt.Errorf("failed to create test api: %w", err)
+	// the rmb peer needs the node seed, it can't run if the node key
+	// is held by an external signer
+	sk := ed25519.PrivateKey(idStub.PrivateKey(cli.Context))
+	if len(sk) != ed25519.PrivateKeySize {
+		log.Error().Msg("node key is held by an external signer, rmb is not supported")
+		<-ctx.Done()
+		return nil
 	}
-	api.SetupRoutes(router)
+	seedHex := hex.EncodeToString(sk.Seed())
 
-	pair, err := id.KeyPair()
+	bo := backoff.NewExponentialBackOff()
+	bo.MaxElapsedTime = 0
+
+	// the rpc client calls the other nodes of the farm, it uses its own
+	// session so the responses are not received by the router
+	var rpc *peer.RpcClient
+	backoff.Retry(func() error {
+		rpc, err = peer.NewRpcClient(
+			ctx,
+			seedHex,
+			manager,
+			peer.WithKeyType(peer.KeyTypeEd25519),
+			peer.WithRelay(environment.MustGet().RelayURL...),
+			peer.WithSession(rpcSession),
+			peer.WithInMemoryExpiration(6*60*60), // 6 hours
+		)
+		if err != nil {
+			return fmt.Errorf("failed to start rmb rpc client: %w", err)
+		}
+
+		return nil
+	}, bo)
+
+	api, err := testapi.NewZosAPI(manager, redis, msgBrokerCon, rpc)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create test api: %w", err)
 	}
 
-	bo := backoff.NewExponentialBackOff()
-	bo.MaxElapsedTime = 0
+	router := peer.NewRouter()
+	api.SetupRoutes(router)
+
+	bo.Reset()
 	backoff.Retry(func() error {
 		_, err = peer.NewPeer(
 			ctx,
-			hex.EncodeToString(pair.Seed()),
+			seedHex,
 			manager,
-			router.Serve,
+			serve(router),
 			peer.WithKeyType(peer.KeyTypeEd25519),
 			peer.WithRelay(environment.MustGet().RelayURL...),
 			peer.WithInMemoryExpiration(6*60*60), // 6 hours
@@ -122,6 +155,13 @@ func action(cli *cli.Context) error {
 		return nil
 	}, bo)
 
+	go func() {
+		// the farm power config may have changed while the node was off
+		if err := api.PowerConfigPull(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to get farm power config from farm nodes")
+		}
+	}()
+
 	log.Info().
 		Str("broker", msgBrokerCon).
 		Uint("worker nr", workerNr).
@@ -131,3 +171,43 @@ func action(cli *cli.Context) error {
 	<-ctx.Done()
 	return nil
 }
+
+// nodeIdentity returns the chain identity of the node. All signing goes
+// through identityd, so the node key never leaves the node signer.
+func nodeIdentity(idStub *stubs.IdentityManagerStub) (substrate.Identity, error) {
+	id, err := signer.NewIdentity(signer.NewZbusSigner(idStub))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signer identity: %w", err)
+	}
+
+	return id, nil
+}
+
+// serve returns the peer handler of the router. The peer drops envelopes that
+// fail validation or decryption, they are logged here with their source and the
+// caller gets the error back instead of waiting for a response until it times out.
+func serve(router *peer.Router) peer.Handler {
+	return func(ctx context.Context, p *peer.Peer, env *types.Envelope, err error) {
+		if err == nil {
+			router.Serve(ctx, p, env, nil)
+			return
+		}
+
+		logger := log.Error().Err(err).Str("uid", env.Uid)
+		if env.Source != nil {
+			logger = logger.Uint32("twin", env.Source.Twin)
+		}
+		if request := env.GetRequest(); request != nil {
+			logger = logger.Str("command", request.Command)
+		}
+		logger.Msg("rejected rmb envelope")
+
+		if env.Source == nil || env.GetRequest() == nil {
+			return
+		}
+
+		if err := p.SendResponse(ctx, env.Uid, env.Source.Twin, env.Source.Connection, err, nil); err != nil {
+			log.Error().Err(err).Uint32("twin", env.Source.Twin).Msg("failed to send rejection response")
+		}
+	}
+}
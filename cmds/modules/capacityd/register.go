@@ -204,7 +204,10 @@ func registerNode(
 	log.Info().Str("id", mgr.NodeID(ctx).Identity()).Msg("start registration of the node")
 	log.Info().Msg("registering node on blockchain")
 
-	sk := ed25519.PrivateKey(mgr.PrivateKey(ctx))
+	sk, err := nodeKey(ctx, mgr)
+	if err != nil {
+		return 0, 0, err
+	}
 	id, err := substrate.IdentityFromSecureKey(sk)
 	if err != nil {
 		return 0, 0, err
@@ -271,6 +274,18 @@ func registerNode(
 	return nodeID, twinID, nil
 }
 
+// nodeKey returns the node private key. The substrate client used here can
+// only sign with the key itself, so it fails if the key is held by an
+// external signer.
+func nodeKey(ctx context.Context, mgr *stubs.IdentityManagerStub) (ed25519.PrivateKey, error) {
+	sk := ed25519.PrivateKey(mgr.PrivateKey(ctx))
+	if len(sk) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("node key is held by an external signer, capacityd can't sign chain calls without it")
+	}
+
+	return sk, nil
+}
+
 func ensureTwin(sub *substrate.Substrate, sk ed25519.PrivateKey, ip net.IP) (uint32, error) {
 	identity, err := substrate.IdentityFromSecureKey(sk)
 	if err != nil {
@@ -310,7 +325,10 @@ func uptime(ctx context.Context, cl zbus.Client) error {
 		return errors.Wrap(err, "failed to create substrate client")
 	}
 
-	sk := ed25519.PrivateKey(mgr.PrivateKey(ctx))
+	sk, err := nodeKey(ctx, mgr)
+	if err != nil {
+		return err
+	}
 	id, err := substrate.IdentityFromSecureKey(sk)
 	if err != nil {
 		return err
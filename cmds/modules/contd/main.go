@@ -97,6 +97,7 @@ func action(cli *cli.Context) error {
 
 	// start watching for events
 	go containerd.Watch(ctx)
+	go containerd.RotateLogs(ctx)
 
 	if err := server.Run(ctx); err != nil && err != context.Canceled {
 		return errors.Wrap(err, "unexpected error")
@@ -0,0 +1,186 @@
+package exporterd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg/exporter"
+	"github.com/threefoldtech/test/pkg/kernel"
+	"github.com/threefoldtech/test/pkg/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// Module is entry point for module
+var Module cli.Command = cli.Command{
+	Name:  "exporterd",
+	Usage: "serves the node metrics in prometheus format",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "broker",
+			Usage: "connection string to the message `BROKER`",
+			Value: "unix:///var/run/redis.sock",
+		},
+		&cli.StringFlag{
+			Name:  "interface",
+			Usage: "serve the metrics on the private address of `INTERFACE`, overrides the interface set with the kernel parameter",
+			Value: "zos",
+		},
+		&cli.UintFlag{
+			Name:  "port",
+			Usage: "serve the metrics on `PORT`, overrides the port set with the kernel parameter",
+			Value: 9100,
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "start even if the exporter is not enabled with the kernel parameters",
+		},
+	},
+	Action: action,
+}
+
+// parseExporterParam parses the value of the exporter kernel parameter
+// which is `<interface>[:<port>]`, for example `test-exporter=zos:9100`
+// or `test-exporter=:9200`. The interface and port that are not set in
+// value are returned unchanged.
+func parseExporterParam(value, iface string, port uint) (string, uint, error) {
+	name, portValue, hasPort := strings.Cut(value, ":")
+	if len(name) != 0 {
+		iface = name
+	}
+
+	if hasPort {
+		parsed, err := strconv.ParseUint(portValue, 10, 16)
+		if err != nil || parsed == 0 {
+			return "", 0, fmt.Errorf("invalid exporter port '%s'", portValue)
+		}
+		port = uint(parsed)
+	}
+
+	return iface, port, nil
+}
+
+// interfaceAddress returns the first private (RFC1918 or ULA) ip of the
+// interface, ipv4 addresses are preferred. Public addresses are never
+// used so the metrics can't be exposed to the internet
+func interfaceAddress(name string) (net.IP, error) {
+	inf, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get interface '%s'", name)
+	}
+
+	addrs, err := inf.Addrs()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get interface '%s' addresses", name)
+	}
+
+	var ip net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsPrivate() {
+			continue
+		}
+
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		} else if ip == nil {
+			ip = ipNet.IP
+		}
+	}
+
+	if ip == nil {
+		return nil, fmt.Errorf("interface '%s' has no private address", name)
+	}
+
+	return ip, nil
+}
+
+func action(cli *cli.Context) error {
+	var (
+		msgBrokerCon string = cli.String("broker")
+		iface        string = cli.String("interface")
+		port         uint   = cli.Uint("port")
+		force        bool   = cli.Bool("force")
+	)
+
+	ctx, _ := utils.WithSignal(cli.Context)
+	utils.OnDone(ctx, func(_ error) {
+		log.Info().Msg("shutting down")
+	})
+
+	params := kernel.GetParams()
+	if !force && !params.IsExporterEnabled() {
+		log.Info().Msgf("metrics exporter is not enabled, set '%s' kernel parameter to enable", kernel.Exporter)
+		<-ctx.Done()
+		return nil
+	}
+
+	if value, ok := params.GetOne(kernel.Exporter); ok {
+		paramIface, paramPort, err := parseExporterParam(value, iface, port)
+		if err != nil {
+			return errors.Wrapf(err, "invalid '%s' kernel parameter", kernel.Exporter)
+		}
+
+		// flags set on the command line take precedence
+		if !cli.IsSet("interface") {
+			iface = paramIface
+		}
+		if !cli.IsSet("port") {
+			port = paramPort
+		}
+	}
+
+	cl, err := zbus.NewRedisClient(msgBrokerCon)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to message broker server")
+	}
+
+	// the interface can get its address after we start
+	// so we keep trying until it has one
+	var ip net.IP
+	for {
+		ip, err = interfaceAddress(iface)
+		if err == nil {
+			break
+		}
+
+		log.Error().Err(err).Msg("failed to get exporter address, retrying")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(10 * time.Second):
+		}
+	}
+
+	exp := exporter.NewExporter(cl)
+	go exp.Start(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp)
+
+	server := http.Server{
+		Addr:    net.JoinHostPort(ip.String(), fmt.Sprint(port)),
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	log.Info().Str("address", server.Addr).Msg("serving metrics")
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.Wrap(err, "metrics server exited unexpectedly")
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package exporterd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExporterParam(t *testing.T) {
+	require := require.New(t)
+
+	iface, port, err := parseExporterParam("", "zos", 9100)
+	require.NoError(err)
+	require.Equal("zos", iface)
+	require.EqualValues(9100, port)
+
+	iface, port, err = parseExporterParam("eth1", "zos", 9100)
+	require.NoError(err)
+	require.Equal("eth1", iface)
+	require.EqualValues(9100, port)
+
+	iface, port, err = parseExporterParam(":9200", "zos", 9100)
+	require.NoError(err)
+	require.Equal("zos", iface)
+	require.EqualValues(9200, port)
+
+	iface, port, err = parseExporterParam("eth1:9200", "zos", 9100)
+	require.NoError(err)
+	require.Equal("eth1", iface)
+	require.EqualValues(9200, port)
+
+	_, _, err = parseExporterParam("eth1:port", "zos", 9100)
+	require.Error(err)
+
+	_, _, err = parseExporterParam("eth1:70000", "zos", 9100)
+	require.Error(err)
+}
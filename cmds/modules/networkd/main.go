@@ -9,6 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/threefoldtech/test/pkg/environment"
+	nodeidentity "github.com/threefoldtech/test/pkg/identity"
 	"github.com/threefoldtech/test/pkg/network/dhcp"
 	"github.com/threefoldtech/test/pkg/network/mycelium"
 	"github.com/threefoldtech/test/pkg/network/public"
@@ -117,14 +118,19 @@ func action(cli *cli.Context) error {
 		namespace = public.PublicNamespace
 	}
 
+	networkKey, err := nodeidentity.NetworkKey(cli.Context, identity)
+	if err != nil {
+		return errors.Wrap(err, "failed to get network key")
+	}
+
 	log.Debug().Msg("starting yggdrasil")
-	ygg, err := setupYgg(ctx, namespace, dmz.Namespace(), identity.PrivateKey(cli.Context))
+	ygg, err := setupYgg(ctx, namespace, dmz.Namespace(), networkKey)
 	if err != nil {
 		return err
 	}
 
 	log.Debug().Msg("starting mycelium")
-	mycelium, err := setupMycelium(ctx, namespace, dmz.Namespace(), identity.PrivateKey(cli.Context))
+	mycelium, err := setupMycelium(ctx, namespace, dmz.Namespace(), networkKey)
 	if err != nil {
 		return err
 	}
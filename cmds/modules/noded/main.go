@@ -2,15 +2,15 @@ package noded
 
 import (
 	"context"
-	"crypto/ed25519"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/cenkalti/backoff"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
-	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/test/pkg/app"
 	"github.com/threefoldtech/test/pkg/capacity"
 	"github.com/threefoldtech/test/pkg/environment"
@@ -18,10 +18,13 @@ import (
 	"github.com/threefoldtech/test/pkg/monitord"
 	"github.com/threefoldtech/test/pkg/perf"
 	"github.com/threefoldtech/test/pkg/perf/cpubench"
+	"github.com/threefoldtech/test/pkg/perf/diskbench"
 	"github.com/threefoldtech/test/pkg/perf/healthcheck"
 	"github.com/threefoldtech/test/pkg/perf/iperf"
+	"github.com/threefoldtech/test/pkg/perf/membench"
 	"github.com/threefoldtech/test/pkg/perf/publicip"
 	"github.com/threefoldtech/test/pkg/registrar"
+	"github.com/threefoldtech/test/pkg/rotate"
 	"github.com/threefoldtech/test/pkg/stubs"
 	"github.com/threefoldtech/test/pkg/utils"
 
@@ -34,6 +37,15 @@ const (
 	module          = "node"
 	registrarModule = "registrar"
 	eventsBlock     = "/tmp/events.chain"
+	// perfHistory is the database of the performance tasks runs
+	perfHistory = "/var/cache/modules/noded/perf.db"
+	// eventsLog is the database of the received chain events
+	eventsLog = "/var/cache/modules/noded/events.db"
+	// hardwareDB is the database of the hardware inventory and changes
+	hardwareDB = "/var/cache/modules/noded/hardware.db"
+	// systemLogs is where zinit services logs are written (see etc/zinit/logger.yaml)
+	systemLogs    = "/var/cache/log"
+	systemLogFile = "system.log"
 )
 
 // Module is entry point for module
@@ -163,7 +175,30 @@ func action(cli *cli.Context) error {
 	go registerationServer(ctx, msgBrokerCon, env, info)
 	log.Info().Msg("start perf scheduler")
 
-	perfMon, err := perf.NewPerformanceMonitor(msgBrokerCon)
+	if err := os.MkdirAll(filepath.Dir(perfHistory), 0755); err != nil {
+		return errors.Wrap(err, "failed to create perf history directory")
+	}
+
+	hardware, err := capacity.NewHardwareManager(hardwareDB, oracle, stubs.NewNetworkerStub(redis))
+	if err != nil {
+		return errors.Wrap(err, "failed to open hardware inventory")
+	}
+	defer hardware.Close()
+
+	changes, err := hardware.Scan(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to collect hardware inventory")
+	}
+	for _, change := range changes {
+		log.Warn().
+			Str("kind", string(change.Kind)).
+			Str("component", string(change.Component)).
+			Str("id", change.ID).
+			Str("details", change.Details).
+			Msg("hardware change detected")
+	}
+
+	perfMon, err := perf.NewPerformanceMonitor(msgBrokerCon, perfHistory)
 	if err != nil {
 		return errors.Wrap(err, "failed to create a new perfMon")
 	}
@@ -177,6 +212,8 @@ func action(cli *cli.Context) error {
 	perfMon.AddTask(cpubench.NewTask())
 	perfMon.AddTask(publicip.NewTask())
 	perfMon.AddTask(healthcheck.NewTask())
+	perfMon.AddTask(diskbench.NewTask())
+	perfMon.AddTask(membench.NewTask())
 
 	if err = perfMon.Run(ctx); err != nil {
 		return errors.Wrap(err, "failed to run the scheduler")
@@ -215,12 +252,23 @@ func action(cli *cli.Context) error {
 		return err
 	}
 
-	events, err := events.NewRedisStream(sub, msgBrokerCon, env.FarmID, node, eventsBlock)
+	eventLog, err := events.NewLog(eventsLog)
+	if err != nil {
+		return errors.Wrap(err, "failed to open events log")
+	}
+	defer eventLog.Close()
+
+	events, err := events.NewRedisStream(sub, msgBrokerCon, env.FarmID, node, eventsBlock, eventLog)
 	if err != nil {
 		return err
 	}
 	go events.Start(ctx)
 
+	go rotate.NewLogsService(rotate.Job{
+		Name:  "system",
+		Files: rotate.Files(systemLogs, systemLogFile),
+	}).Run(ctx)
+
 	system, err := monitord.NewSystemMonitor(node, 2*time.Second)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to initialize system monitor")
@@ -234,6 +282,8 @@ func action(cli *cli.Context) error {
 	server.Register(zbus.ObjectID{Name: "host", Version: "0.0.1"}, host)
 	server.Register(zbus.ObjectID{Name: "system", Version: "0.0.1"}, system)
 	server.Register(zbus.ObjectID{Name: "performance-monitor", Version: "0.0.1"}, perfMon)
+	server.Register(zbus.ObjectID{Name: "events", Version: "0.0.1"}, events)
+	server.Register(zbus.ObjectID{Name: "hardware", Version: "0.0.1"}, hardware)
 
 	log.Info().Uint32("node", node).Uint32("twin", twin).Msg("node registered")
 
@@ -250,12 +300,11 @@ func action(cli *cli.Context) error {
 	idStub := stubs.NewIdentityManagerStub(redis)
 	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
-	sk := ed25519.PrivateKey(idStub.PrivateKey(fetchCtx))
-	id, err := substrate.NewIdentityFromEd25519Key(sk)
-	log.Info().Str("address", id.Address()).Msg("node address")
+	address, err := idStub.Address(fetchCtx)
 	if err != nil {
 		return err
 	}
+	log.Info().Str("address", address.String()).Msg("node address")
 	if err := server.Run(ctx); err != nil && err != context.Canceled {
 		log.Fatal().Err(err).Msg("unexpected error")
 	}
@@ -2,11 +2,10 @@ package powerd
 
 import (
 	"context"
-	"crypto/ed25519"
+	"os"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg/environment"
 	"github.com/threefoldtech/test/pkg/events"
@@ -30,6 +29,11 @@ var Module cli.Command = cli.Command{
 			Usage: "connection string to the message `BROKER`",
 			Value: "unix:///var/run/redis.sock",
 		},
+		&cli.StringFlag{
+			Name:  "root",
+			Usage: "`ROOT` working directory of the module",
+			Value: "/var/cache/modules/powerd",
+		},
 	},
 	Action: action,
 }
@@ -37,6 +41,7 @@ var Module cli.Command = cli.Command{
 func action(cli *cli.Context) error {
 	var (
 		msgBrokerCon string = cli.String("broker")
+		moduleRoot   string = cli.String("root")
 		powerdLabel  string = "powerd"
 	)
 
@@ -47,6 +52,10 @@ func action(cli *cli.Context) error {
 
 	env := environment.MustGet()
 
+	if err := os.MkdirAll(moduleRoot, 0750); err != nil {
+		return errors.Wrap(err, "fail to create module root")
+	}
+
 	cl, err := zbus.NewRedisClient(msgBrokerCon)
 	if err != nil {
 		return errors.Wrap(err, "failed to connect to message broker server")
@@ -77,16 +86,15 @@ func action(cli *cli.Context) error {
 		return errors.Wrap(err, "failed to get twin id")
 	}
 
-	sk := ed25519.PrivateKey(identity.PrivateKey(ctx))
-	id, err := substrate.NewIdentityFromEd25519Key(sk)
-	log.Info().Str("address", id.Address()).Msg("node address")
+	address, err := identity.Address(ctx)
 	if err != nil {
 		return err
 	}
+	log.Info().Str("address", address.String()).Msg("node address")
 
 	substrateGateway := stubs.NewSubstrateGatewayStub(cl)
 
-	uptime, err := power.NewUptime(substrateGateway, id)
+	uptime, err := power.NewUptime(substrateGateway)
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize uptime reported")
 	}
@@ -114,11 +122,30 @@ func action(cli *cli.Context) error {
 	}
 
 	// start power manager
-	power, err := power.NewPowerServer(substrateGateway, consumer, enabled, env.FarmID, nodeID, twinID, uptime)
+	power, err := power.NewPowerServer(cl, moduleRoot, substrateGateway, consumer, enabled, env.FarmID, nodeID, twinID, uptime)
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize power manager")
 	}
 
+	server, err := zbus.NewRedisServer(module, msgBrokerCon, 1)
+	if err != nil {
+		return errors.Wrap(err, "fail to connect to message broker server")
+	}
+
+	server.Register(zbus.ObjectID{Name: module, Version: "0.0.1"}, power)
+
+	go func() {
+		if err := server.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			log.Error().Err(err).Msg("unexpected error from zbus server")
+		}
+	}()
+
+	if report, err := power.LastShutdown(); err != nil {
+		log.Error().Err(err).Msg("failed to load last shutdown report")
+	} else if len(report.State) != 0 {
+		log.Info().Str("state", string(report.State)).Str("reason", report.Reason).Strs("stopped", report.Stopped).Msg("last shutdown report")
+	}
+
 	if err := power.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
 		return err
 	}
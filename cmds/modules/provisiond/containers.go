@@ -0,0 +1,185 @@
+package provisiond
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/primitives/zdb"
+	"github.com/threefoldtech/test/pkg/provision"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	containerLimitsInterval = 5 * time.Minute
+
+	// namespaces of the containers created by the zdb and qsfs modules
+	zdbContainerNS  = "zdb"
+	qsfsContainerNS = "qsfs"
+)
+
+// ContainerWatcher reports the resource limits hit by the containers that
+// run the zdb and qsfs workloads in the workloads results, so the owner
+// can tell when the workload is under provisioned
+type ContainerWatcher struct {
+	cl     zbus.Client
+	engine provision.Engine
+}
+
+// NewContainerWatcher creates a new container watcher
+func NewContainerWatcher(cl zbus.Client, engine provision.Engine) *ContainerWatcher {
+	return &ContainerWatcher{cl: cl, engine: engine}
+}
+
+// Run checks the containers every containerLimitsInterval until the context
+// is cancelled
+func (w *ContainerWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(containerLimitsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.check(ctx); err != nil {
+				log.Error().Err(err).Msg("failed to check workloads containers limits")
+			}
+		}
+	}
+}
+
+// container key of the container stats cache
+type container struct {
+	ns string
+	id pkg.ContainerID
+}
+
+func (w *ContainerWatcher) check(ctx context.Context) error {
+	storage := w.engine.Storage()
+	twins, err := storage.Twins()
+	if err != nil {
+		return errors.Wrap(err, "failed to list twins")
+	}
+
+	// zdb containers are shared by many namespaces, so stats are only
+	// collected once per check
+	cache := make(map[container]pkg.ContainerStats)
+	for _, twin := range twins {
+		ids, err := storage.ByTwin(twin)
+		if err != nil {
+			log.Error().Err(err).Uint32("twin", twin).Msg("failed to list twin deployments")
+			continue
+		}
+
+		for _, id := range ids {
+			dl, err := storage.Get(twin, id)
+			if err != nil {
+				log.Error().Err(err).Uint32("twin", twin).Uint64("contract", id).Msg("failed to get deployment")
+				continue
+			}
+
+			for _, wl := range dl.ByType(test.ZDBType, test.QuantumSafeFSType) {
+				if !wl.Result.State.IsOkay() {
+					continue
+				}
+
+				if err := w.update(ctx, cache, wl); err != nil {
+					log.Error().Err(err).Stringer("id", wl.ID).Msg("failed to update workload container limits")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (w *ContainerWatcher) container(ctx context.Context, wl *gridtypes.WorkloadWithID) (container, error) {
+	if wl.Type == test.QuantumSafeFSType {
+		return container{ns: qsfsContainerNS, id: pkg.ContainerID(wl.ID.String())}, nil
+	}
+
+	id, err := zdb.NewManager(w.cl).ContainerOf(ctx, wl.ID.String())
+	return container{ns: zdbContainerNS, id: id}, err
+}
+
+// update sets the limits hit by the workload container on the workload
+// result. A new transaction is only added if the limits have changed
+func (w *ContainerWatcher) update(ctx context.Context, cache map[container]pkg.ContainerStats, wl *gridtypes.WorkloadWithID) error {
+	cont, err := w.container(ctx, wl)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stats, ok := cache[cont]
+	if !ok {
+		stats, err = stubs.NewContainerModuleStub(w.cl).Stats(ctx, cont.ns, cont.id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get container '%s' stats", cont.id)
+		}
+		cache[cont] = stats
+	}
+
+	limits := stats.Limits()
+	twin, deployment, name, _ := wl.ID.Parts()
+	// the result is read again from the storage since the workload could
+	// have changed since the deployment was listed
+	return w.engine.Storage().UpdateResult(twin, deployment, name, func(current *gridtypes.Workload) error {
+		if !current.Result.State.IsOkay() {
+			return provision.ErrNoActionNeeded
+		}
+
+		// results are decoded generically so the other fields of the result
+		// (zdb or qsfs) are kept as is
+		result := make(map[string]json.RawMessage)
+		if err := current.Result.Unmarshal(&result); err != nil {
+			return errors.Wrap(err, "failed to load workload result")
+		}
+
+		var previous *pkg.ContainerLimits
+		if data, ok := result["resources"]; ok {
+			if err := json.Unmarshal(data, &previous); err != nil {
+				return errors.Wrap(err, "failed to load workload resources")
+			}
+		}
+
+		if previous == nil && !limits.Hit() {
+			return provision.ErrNoActionNeeded
+		}
+
+		if previous != nil &&
+			previous.OOMKills == limits.OOMKills &&
+			previous.MemoryLimitHit == limits.MemoryLimitHit &&
+			previous.PidsLimitHit == limits.PidsLimitHit {
+			return provision.ErrNoActionNeeded
+		}
+
+		log.Warn().Stringer("id", wl.ID).
+			Uint64("oom-kills", limits.OOMKills).
+			Bool("memory-limit-hit", limits.MemoryLimitHit).
+			Bool("pids-limit-hit", limits.PidsLimitHit).
+			Msg("workload container hit its limits")
+
+		data, err := json.Marshal(limits)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode workload resources")
+		}
+		result["resources"] = data
+
+		if current.Result.Data, err = json.Marshal(result); err != nil {
+			return errors.Wrap(err, "failed to encode workload result")
+		}
+		current.Result.Created = gridtypes.Now()
+
+		return nil
+	})
+}
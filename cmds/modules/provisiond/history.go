@@ -0,0 +1,189 @@
+package provisiond
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+const (
+	// historyRetention is how long sent consumption reports are kept
+	historyRetention = 90 * 24 * time.Hour
+)
+
+var (
+	historyBucket  = []byte("reports")
+	capacityBucket = []byte("capacity")
+)
+
+// reportedContract is the consumption of a contract in a sent report
+type reportedContract struct {
+	Contract uint64 `json:"contract"`
+	Twin     uint32 `json:"twin"`
+	NU       uint64 `json:"nu"`
+}
+
+// reportRecord is a consumption report as it was sent to the chain
+type reportRecord struct {
+	Timestamp int64              `json:"timestamp"`
+	Window    uint64             `json:"window"`
+	Contracts []reportedContract `json:"contracts"`
+}
+
+// reservedContract is the capacity reserved by a contract
+type reservedContract struct {
+	Contract uint64             `json:"contract"`
+	Twin     uint32             `json:"twin"`
+	Capacity gridtypes.Capacity `json:"capacity"`
+}
+
+// capacityRecord is the capacity reserved by all the node contracts at a time
+type capacityRecord struct {
+	Timestamp int64              `json:"timestamp"`
+	Contracts []reservedContract `json:"contracts"`
+}
+
+// peak sets c to the largest of c and o for each resource
+func peak(c *gridtypes.Capacity, o *gridtypes.Capacity) {
+	c.CRU = max(c.CRU, o.CRU)
+	c.SRU = max(c.SRU, o.SRU)
+	c.HRU = max(c.HRU, o.HRU)
+	c.MRU = max(c.MRU, o.MRU)
+	c.IPV4U = max(c.IPV4U, o.IPV4U)
+}
+
+// reportHistory keeps the consumption reports sent to the chain so they can
+// be compared later with what was billed, and the capacity reserved by the
+// contracts each time a report is created
+type reportHistory struct {
+	db *bolt.DB
+}
+
+func newReportHistory(path string) (*reportHistory, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open report history")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{historyBucket, capacityBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create report history bucket")
+	}
+
+	return &reportHistory{db: db}, nil
+}
+
+// Add records a sent report and drops the reports older than the retention
+func (h *reportHistory) Add(record reportRecord) error {
+	return h.put(historyBucket, record.Timestamp, record)
+}
+
+// AddCapacity records the reserved capacity and drops the records older
+// than the retention
+func (h *reportHistory) AddCapacity(record capacityRecord) error {
+	return h.put(capacityBucket, record.Timestamp, record)
+}
+
+func (h *reportHistory) put(name []byte, ts int64, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode record")
+	}
+
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(name)
+		if err := bucket.Put(tsKey(ts), data); err != nil {
+			return err
+		}
+
+		retain := tsKey(time.Unix(ts, 0).Add(-historyRetention).Unix())
+		var drop [][]byte
+		cur := bucket.Cursor()
+		for k, _ := cur.First(); k != nil && bytes.Compare(k, retain) < 0; k, _ = cur.Next() {
+			drop = append(drop, append([]byte{}, k...))
+		}
+
+		for _, k := range drop {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Between returns the reports sent between from (inclusive) and to (exclusive)
+func (h *reportHistory) Between(from, to int64) ([]reportRecord, error) {
+	var records []reportRecord
+	err := h.between(historyBucket, from, to, func(data []byte) error {
+		var record reportRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return errors.Wrap(err, "failed to decode report")
+		}
+
+		records = append(records, record)
+		return nil
+	})
+
+	return records, err
+}
+
+// CapacityBetween returns the capacity records between from (inclusive)
+// and to (exclusive)
+func (h *reportHistory) CapacityBetween(from, to int64) ([]capacityRecord, error) {
+	var records []capacityRecord
+	err := h.between(capacityBucket, from, to, func(data []byte) error {
+		var record capacityRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return errors.Wrap(err, "failed to decode capacity record")
+		}
+
+		records = append(records, record)
+		return nil
+	})
+
+	return records, err
+}
+
+func (h *reportHistory) between(name []byte, from, to int64, fn func(data []byte) error) error {
+	return h.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(name).Cursor()
+		end := tsKey(to)
+		for k, v := cur.Seek(tsKey(from)); k != nil && bytes.Compare(k, end) < 0; k, v = cur.Next() {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (h *reportHistory) Close() error {
+	return h.db.Close()
+}
+
+func tsKey(ts int64) []byte {
+	if ts < 0 {
+		ts = 0
+	}
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(ts))
+	return b[:]
+}
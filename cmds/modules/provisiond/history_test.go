@@ -0,0 +1,77 @@
+package provisiond
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+func TestReportHistory(t *testing.T) {
+	require := require.New(t)
+
+	history, err := newReportHistory(filepath.Join(t.TempDir(), "history.bolt"))
+	require.NoError(err)
+	defer history.Close()
+
+	now := time.Now().Unix()
+	old := time.Now().Add(-historyRetention - time.Hour).Unix()
+
+	require.NoError(history.Add(reportRecord{Timestamp: old, Contracts: []reportedContract{{Contract: 1, NU: 10}}}))
+	for i := int64(0); i < 3; i++ {
+		require.NoError(history.Add(reportRecord{
+			Timestamp: now + i*3600,
+			Window:    3600,
+			Contracts: []reportedContract{{Contract: 1, Twin: 2, NU: 10}},
+		}))
+	}
+
+	// the old report is dropped by the retention
+	records, err := history.Between(0, now+10*3600)
+	require.NoError(err)
+	require.Len(records, 3)
+
+	records, err = history.Between(now, now+2*3600)
+	require.NoError(err)
+	require.Len(records, 2)
+	require.EqualValues(now, records[0].Timestamp)
+	require.EqualValues(2, records[0].Contracts[0].Twin)
+}
+
+func TestCapacityHistory(t *testing.T) {
+	require := require.New(t)
+
+	history, err := newReportHistory(filepath.Join(t.TempDir(), "history.bolt"))
+	require.NoError(err)
+	defer history.Close()
+
+	now := time.Now().Unix()
+	require.NoError(history.AddCapacity(capacityRecord{
+		Timestamp: now,
+		Contracts: []reservedContract{{Contract: 1, Twin: 2, Capacity: gridtypes.Capacity{CRU: 2}}},
+	}))
+	// contract 1 is deleted in the next record
+	require.NoError(history.AddCapacity(capacityRecord{
+		Timestamp: now + 3600,
+		Contracts: []reservedContract{{Contract: 3, Twin: 2, Capacity: gridtypes.Capacity{CRU: 1}}},
+	}))
+
+	records, err := history.CapacityBetween(now, now+2*3600)
+	require.NoError(err)
+	require.Len(records, 2)
+	require.EqualValues(1, records[0].Contracts[0].Contract)
+
+	// capacity records are separate from the sent reports
+	reports, err := history.Between(now, now+2*3600)
+	require.NoError(err)
+	require.Empty(reports)
+}
+
+func TestPeak(t *testing.T) {
+	c := gridtypes.Capacity{CRU: 2, MRU: 4 * gridtypes.Gigabyte}
+	peak(&c, &gridtypes.Capacity{CRU: 1, MRU: 8 * gridtypes.Gigabyte, IPV4U: 1})
+
+	require.Equal(t, gridtypes.Capacity{CRU: 2, MRU: 8 * gridtypes.Gigabyte, IPV4U: 1}, c)
+}
@@ -3,7 +3,6 @@ package provisiond
 import (
 	"bytes"
 	"context"
-	"crypto/ed25519"
 	"fmt"
 	"os"
 	"os/exec"
@@ -12,10 +11,10 @@ import (
 
 	"github.com/cenkalti/backoff/v3"
 	"github.com/pkg/errors"
-	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/app"
 	"github.com/threefoldtech/test/pkg/capacity"
+	"github.com/threefoldtech/test/pkg/crypto"
 	"github.com/threefoldtech/test/pkg/environment"
 	"github.com/threefoldtech/test/pkg/events"
 	"github.com/threefoldtech/test/pkg/gridtypes"
@@ -35,16 +34,20 @@ import (
 )
 
 const (
-	serverName       = "provision"
-	provisionModule  = "provision"
-	statisticsModule = "statistics"
-	gib              = 1024 * 1024 * 1024
+	serverName        = "provision"
+	provisionModule   = "provision"
+	statisticsModule  = "statistics"
+	reconcilerModule  = "reconciler"
+	consumptionModule = "consumption"
+	gib               = 1024 * 1024 * 1024
 
 	boltStorageDB = "workloads.bolt"
 	// old style rrd, make sure we clean it up
 	metricsStorageDBOld = "metrics.bolt"
 	// new style db after rrd implementation change
 	metricsStorageDB = "metrics-diff.bolt"
+	// history of the consumption reports sent to the chain
+	consumptionHistoryDB = "consumption-history.bolt"
 
 	// deprecated, kept for migration
 	fsStorageDB = "workloads"
@@ -178,7 +181,10 @@ func action(cli *cli.Context) error {
 	}
 
 	identity := stubs.NewIdentityManagerStub(cl)
-	sk := ed25519.PrivateKey(identity.PrivateKey(ctx))
+	pk, err := crypto.KeyFromID(identity.NodeID(ctx))
+	if err != nil {
+		return errors.Wrap(err, "failed to get node public key")
+	}
 
 	// block until networkd is ready to serve request from zbus
 	// this is used to prevent uptime and online status to the explorer if the node is not in a fully ready
@@ -277,12 +283,7 @@ func action(cli *cli.Context) error {
 		return errors.Wrap(err, "failed to create substrate admins database")
 	}
 
-	kp, err := substrate.NewIdentityFromEd25519Key(sk)
-	if err != nil {
-		return errors.Wrap(err, "failed to get substrate keypair from secure key")
-	}
-
-	twin, subErr := substrateGateway.GetTwinByPubKey(ctx, kp.PublicKey())
+	twin, subErr := substrateGateway.GetTwinByPubKey(ctx, pk)
 	if subErr.IsError() {
 		return errors.Wrap(subErr.Err, "failed to get node twin id")
 	}
@@ -355,6 +356,12 @@ func action(cli *cli.Context) error {
 		pkg.Statistics(primitives.NewStatisticsStream(statistics)),
 	)
 
+	reconciler := NewReconciler(node, substrateGateway, engine)
+	server.Register(
+		zbus.ObjectID{Name: reconcilerModule, Version: "0.0.1"},
+		pkg.Reconciler(reconciler),
+	)
+
 	log.Info().
 		Str("broker", msgBrokerCon).
 		Msg("starting provision module")
@@ -380,7 +387,7 @@ func action(cli *cli.Context) error {
 		return errors.Wrap(err, "failed to create event consumer")
 	}
 
-	handler := NewContractEventHandler(node, substrateGateway, engine, consumer)
+	handler := NewContractEventHandler(reconciler, engine, consumer)
 
 	go func() {
 		if err := handler.Run(ctx); err != nil && err != context.Canceled {
@@ -388,14 +395,39 @@ func action(cli *cli.Context) error {
 		}
 	}()
 
+	watcher := NewQSFSWatcher(cl, engine)
+	go func() {
+		if err := watcher.Run(ctx); err != nil && err != context.Canceled {
+			log.Error().Err(err).Msg("qsfs health watcher exited unexpectedly")
+		}
+	}()
+
+	containers := NewContainerWatcher(cl, engine)
+	go func() {
+		if err := containers.Run(ctx); err != nil && err != context.Canceled {
+			log.Error().Err(err).Msg("container limits watcher exited unexpectedly")
+		}
+	}()
+
 	// clean up old rrd db that uses previous style reporting
 	_ = os.Remove(filepath.Join(rootDir, metricsStorageDBOld))
 
-	reporter, err := NewReporter(filepath.Join(rootDir, metricsStorageDB), cl, queues)
+	reporter, err := NewReporter(
+		filepath.Join(rootDir, metricsStorageDB),
+		filepath.Join(rootDir, consumptionHistoryDB),
+		cl,
+		queues,
+		store,
+	)
 	if err != nil {
 		return errors.Wrap(err, "failed to setup capacity reporter")
 	}
 
+	server.Register(
+		zbus.ObjectID{Name: consumptionModule, Version: "0.0.1"},
+		pkg.Consumption(reporter),
+	)
+
 	// also spawn the capacity reporter
 	go func() {
 		defer reporter.Close()
@@ -0,0 +1,135 @@
+package provisiond
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/primitives/qsfs"
+	"github.com/threefoldtech/test/pkg/provision"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	qsfsHealthInterval = 10 * time.Minute
+)
+
+// QSFSWatcher keeps the health in the results of the qsfs workloads up
+// to date, so the owner can see when a qsfs is degraded
+type QSFSWatcher struct {
+	cl     zbus.Client
+	engine provision.Engine
+}
+
+// NewQSFSWatcher creates a new qsfs watcher
+func NewQSFSWatcher(cl zbus.Client, engine provision.Engine) *QSFSWatcher {
+	return &QSFSWatcher{cl: cl, engine: engine}
+}
+
+// Run checks the qsfs workloads every qsfsHealthInterval until the context
+// is cancelled
+func (w *QSFSWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(qsfsHealthInterval)
+	defer ticker.Stop()
+
+	// the health is not checked while the qsfs is provisioned so it's set
+	// on the first check
+	if err := w.check(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to check qsfs workloads health")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := w.check(ctx); err != nil {
+				log.Error().Err(err).Msg("failed to check qsfs workloads health")
+			}
+		}
+	}
+}
+
+func (w *QSFSWatcher) check(ctx context.Context) error {
+	storage := w.engine.Storage()
+	twins, err := storage.Twins()
+	if err != nil {
+		return errors.Wrap(err, "failed to list twins")
+	}
+
+	for _, twin := range twins {
+		ids, err := storage.ByTwin(twin)
+		if err != nil {
+			log.Error().Err(err).Uint32("twin", twin).Msg("failed to list twin deployments")
+			continue
+		}
+
+		for _, id := range ids {
+			dl, err := storage.Get(twin, id)
+			if err != nil {
+				log.Error().Err(err).Uint32("twin", twin).Uint64("contract", id).Msg("failed to get deployment")
+				continue
+			}
+
+			for _, wl := range dl.ByType(test.QuantumSafeFSType) {
+				if !wl.Result.State.IsOkay() {
+					continue
+				}
+
+				if err := w.update(ctx, wl); err != nil {
+					log.Error().Err(err).Stringer("id", wl.ID).Msg("failed to update qsfs health")
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// update sets the current health on the workload result, a new transaction
+// is only added if the degraded state or the key rotation progress of the
+// qsfs has changed. The result is changed on the latest state of the workload
+// so changes made by the engine while the health was checked are kept
+func (w *QSFSWatcher) update(ctx context.Context, wl *gridtypes.WorkloadWithID) error {
+	health, err := stubs.NewQSFSDStub(w.cl).Health(ctx, wl.ID.String())
+	if err != nil {
+		return err
+	}
+
+	twin, deployment, name, _ := wl.ID.Parts()
+	return w.engine.Storage().UpdateResult(twin, deployment, name, func(current *gridtypes.Workload) error {
+		if !current.Result.State.IsOkay() {
+			return provision.ErrNoActionNeeded
+		}
+
+		var result qsfs.Result
+		if err := current.Result.Unmarshal(&result); err != nil {
+			return errors.Wrap(err, "failed to load qsfs result")
+		}
+
+		if result.Health != nil &&
+			result.Health.Degraded == health.Degraded &&
+			result.Health.Rotation == health.Rotation {
+			return provision.ErrNoActionNeeded
+		}
+
+		if health.Degraded {
+			log.Warn().Stringer("id", wl.ID).Msg("qsfs is degraded")
+		}
+
+		result.Health = &health
+		data, err := json.Marshal(result)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode qsfs result")
+		}
+
+		current.Result.Created = gridtypes.Now()
+		current.Result.Data = data
+		return nil
+	})
+}
@@ -0,0 +1,296 @@
+package provisiond
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/provision"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	reconcileTimeout = 10 * time.Minute
+)
+
+var _ pkg.Reconciler = (*Reconciler)(nil)
+
+// Reconciler compares the local deployments with their contracts on chain
+// and fixes the differences that are safe to fix (orphaned deployments and
+// lock state). Other differences are only reported.
+type Reconciler struct {
+	node             uint32
+	substrateGateway *stubs.SubstrateGatewayStub
+	engine           provision.Engine
+
+	// m makes sure only one reconciliation runs at a time
+	m    sync.Mutex
+	last pkg.DriftReport
+}
+
+// NewReconciler creates a new reconciler
+func NewReconciler(node uint32, substrateGateway *stubs.SubstrateGatewayStub, engine provision.Engine) *Reconciler {
+	return &Reconciler{
+		node:             node,
+		substrateGateway: substrateGateway,
+		engine:           engine,
+	}
+}
+
+// Reconcile implements pkg.Reconciler
+func (r *Reconciler) Reconcile(dryRun bool) (pkg.DriftReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer cancel()
+
+	return r.reconcile(ctx, dryRun)
+}
+
+// LastReport implements pkg.Reconciler
+func (r *Reconciler) LastReport() (pkg.DriftReport, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.last, nil
+}
+
+func (r *Reconciler) current() (map[uint64]gridtypes.Deployment, error) {
+	// we need to build a list of all supposedly active contracts on this node
+	storage := r.engine.Storage()
+	storageCap, err := storage.Capacity()
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[uint64]gridtypes.Deployment)
+	for _, active := range storageCap.Deployments {
+		running[active.ContractID] = active
+	}
+
+	return running, nil
+}
+
+// renter returns the twin that rents the node, 0 if the node is not rented
+func (r *Reconciler) renter(ctx context.Context) (uint32, error) {
+	rent, subErr := r.substrateGateway.GetNodeRentContract(ctx, r.node)
+	if subErr.IsCode(pkg.CodeNotFound) {
+		return 0, nil
+	} else if subErr.IsError() {
+		return 0, errors.Wrap(subErr.Err, "failed to get node rent contract")
+	}
+
+	if rent == 0 {
+		return 0, nil
+	}
+
+	contract, subErr := r.substrateGateway.GetContract(ctx, rent)
+	if subErr.IsError() {
+		return 0, errors.Wrap(subErr.Err, "failed to get rent contract")
+	}
+
+	return uint32(contract.TwinID), nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, dryRun bool) (pkg.DriftReport, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	log.Debug().Bool("dry-run", dryRun).Msg("reconciling deployments with the chain")
+
+	report := pkg.DriftReport{
+		DryRun:  dryRun,
+		Started: time.Now().Unix(),
+		Drifts:  []pkg.Drift{},
+	}
+
+	active, err := r.current()
+	if err != nil {
+		return report, errors.Wrap(err, "failed to get current active contracts")
+	}
+
+	onchain, err := r.substrateGateway.GetNodeContracts(ctx, r.node)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to get active node contracts")
+	}
+
+	renter, err := r.renter(ctx)
+	if err != nil {
+		// the other drifts can still be checked, a renter of 0
+		// skips the rent contract check
+		log.Error().Err(err).Msg("failed to get node renter, skipping rent contract check")
+		renter = 0
+	}
+
+	exists := make(map[uint64]struct{})
+	for _, contract := range onchain {
+		exists[uint64(contract)] = struct{}{}
+	}
+
+	report.Deployments = len(active)
+	for id, dl := range active {
+		var drifts []pkg.Drift
+		if _, ok := exists[id]; !ok {
+			// those contracts exits on node but not on chain.
+			drifts = []pkg.Drift{orphaned(&dl, "not a node contract")}
+		} else {
+			contract, subErr := r.substrateGateway.GetContract(ctx, id)
+			if subErr.IsCode(pkg.CodeNotFound) {
+				drifts = []pkg.Drift{orphaned(&dl, "not found")}
+			} else if subErr.IsError() {
+				log.Error().Err(subErr.Err).Uint64("contract", id).Msg("failed to get contract from chain")
+				continue
+			} else {
+				drifts = compare(&dl, &contract, renter)
+			}
+		}
+
+		for _, drift := range drifts {
+			if drift.Fixable && !dryRun {
+				if err := r.fix(ctx, &dl, drift); err != nil {
+					drift.Error = err.Error()
+				} else {
+					drift.Fixed = true
+				}
+			}
+
+			log.Info().
+				Uint32("twin", drift.Twin).
+				Uint64("contract", drift.Contract).
+				Str("kind", string(drift.Kind)).
+				Str("local", drift.Local).
+				Str("chain", drift.Chain).
+				Bool("fixed", drift.Fixed).
+				Msg("deployment drift")
+
+			report.Drifts = append(report.Drifts, drift)
+		}
+	}
+
+	sort.Slice(report.Drifts, func(i, j int) bool {
+		return report.Drifts[i].Contract < report.Drifts[j].Contract
+	})
+
+	report.Finished = time.Now().Unix()
+	r.last = report
+
+	log.Debug().Int("drifts", len(report.Drifts)).Msg("reconciliation complete")
+	return report, nil
+}
+
+// fix fixes a fixable drift
+func (r *Reconciler) fix(ctx context.Context, dl *gridtypes.Deployment, drift pkg.Drift) error {
+	switch drift.Kind {
+	case pkg.DriftOrphaned:
+		return r.engine.Deprovision(ctx, dl.TwinID, dl.ContractID, "contract not active on chain")
+	case pkg.DriftLockState:
+		if isLocked(dl) {
+			return r.engine.Resume(ctx, dl.TwinID, dl.ContractID)
+		}
+		return r.engine.Pause(ctx, dl.TwinID, dl.ContractID)
+	}
+
+	return fmt.Errorf("drift '%s' can't be fixed", drift.Kind)
+}
+
+func orphaned(dl *gridtypes.Deployment, chain string) pkg.Drift {
+	return pkg.Drift{
+		Twin:     dl.TwinID,
+		Contract: dl.ContractID,
+		Kind:     pkg.DriftOrphaned,
+		Local:    "active",
+		Chain:    chain,
+		Fixable:  true,
+	}
+}
+
+// compare returns the differences between a local deployment and its contract.
+// renter is the twin renting the node or 0 if the node is not rented.
+func compare(dl *gridtypes.Deployment, contract *substrate.Contract, renter uint32) []pkg.Drift {
+	drift := func(kind pkg.DriftKind, local, chain string, fixable bool) pkg.Drift {
+		return pkg.Drift{
+			Twin:     dl.TwinID,
+			Contract: dl.ContractID,
+			Kind:     kind,
+			Local:    local,
+			Chain:    chain,
+			Fixable:  fixable,
+		}
+	}
+
+	if contract.State.IsDeleted {
+		return []pkg.Drift{orphaned(dl, "deleted")}
+	}
+
+	if !contract.ContractType.IsNodeContract {
+		return []pkg.Drift{orphaned(dl, "not a node contract")}
+	}
+
+	var drifts []pkg.Drift
+	// locked is chain state for that contract
+	locked := contract.State.IsGracePeriod
+	if locked != isLocked(dl) {
+		drifts = append(drifts, drift(pkg.DriftLockState, lockState(isLocked(dl)), lockState(locked), true))
+	}
+
+	nodeContract := contract.ContractType.NodeContract
+	if hash, err := dl.ChallengeHash(); err != nil {
+		drifts = append(drifts, drift(pkg.DriftHash, err.Error(), nodeContract.DeploymentHash.String(), false))
+	} else if local := hex.EncodeToString(hash); local != nodeContract.DeploymentHash.String() {
+		drifts = append(drifts, drift(pkg.DriftHash, local, nodeContract.DeploymentHash.String(), false))
+	}
+
+	if ips := publicIPs(dl); ips != uint64(nodeContract.PublicIPsCount) {
+		drifts = append(drifts, drift(pkg.DriftPublicIPs, fmt.Sprint(ips), fmt.Sprint(nodeContract.PublicIPsCount), false))
+	}
+
+	if renter != 0 && renter != dl.TwinID {
+		drifts = append(drifts, drift(pkg.DriftRentContract, fmt.Sprint(dl.TwinID), fmt.Sprint(renter), false))
+	}
+
+	return drifts
+}
+
+// publicIPs counts the public ipv4 addresses used by the active workloads
+// of the deployment
+func publicIPs(dl *gridtypes.Deployment) uint64 {
+	var count uint64
+	for _, wl := range dl.Workloads {
+		if !wl.Result.State.IsOkay() {
+			continue
+		}
+
+		cap, err := wl.Capacity()
+		if err != nil {
+			continue
+		}
+
+		count += cap.IPV4U
+	}
+
+	return count
+}
+
+func isLocked(dl *gridtypes.Deployment) bool {
+	for _, wl := range dl.Workloads {
+		if wl.Result.State.IsAny(gridtypes.StatePaused) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lockState(locked bool) string {
+	if locked {
+		return "locked"
+	}
+
+	return "unlocked"
+}
@@ -0,0 +1,76 @@
+package provisiond
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+func TestCompare(t *testing.T) {
+	require := require.New(t)
+
+	dl := gridtypes.Deployment{
+		TwinID:     1,
+		ContractID: 10,
+	}
+
+	hash, err := dl.ChallengeHash()
+	require.NoError(err)
+
+	contract := func() substrate.Contract {
+		return substrate.Contract{
+			State: substrate.ContractState{IsCreated: true},
+			ContractType: substrate.ContractType{
+				IsNodeContract: true,
+				NodeContract: substrate.NodeContract{
+					DeploymentHash: substrate.NewHexHash(hex.EncodeToString(hash)),
+				},
+			},
+		}
+	}
+
+	t.Run("in sync", func(t *testing.T) {
+		c := contract()
+		require.Empty(compare(&dl, &c, 0))
+		require.Empty(compare(&dl, &c, 1))
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		c := contract()
+		c.State = substrate.ContractState{IsDeleted: true}
+		drifts := compare(&dl, &c, 0)
+		require.Len(drifts, 1)
+		require.Equal(pkg.DriftOrphaned, drifts[0].Kind)
+		require.True(drifts[0].Fixable)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		c := contract()
+		c.State = substrate.ContractState{IsGracePeriod: true}
+		drifts := compare(&dl, &c, 0)
+		require.Len(drifts, 1)
+		require.Equal(pkg.DriftLockState, drifts[0].Kind)
+		require.Equal("unlocked", drifts[0].Local)
+		require.Equal("locked", drifts[0].Chain)
+		require.True(drifts[0].Fixable)
+	})
+
+	t.Run("report only", func(t *testing.T) {
+		c := contract()
+		c.ContractType.NodeContract.DeploymentHash = substrate.NewHexHash("00000000000000000000000000000000")
+		c.ContractType.NodeContract.PublicIPsCount = 1
+		drifts := compare(&dl, &c, 2)
+		require.Len(drifts, 3)
+
+		kinds := []pkg.DriftKind{pkg.DriftHash, pkg.DriftPublicIPs, pkg.DriftRentContract}
+		for i, drift := range drifts {
+			require.Equal(kinds[i], drift.Kind)
+			require.False(drift.Fixable)
+			require.EqualValues(10, drift.Contract)
+		}
+	})
+}
@@ -2,9 +2,10 @@ package provisiond
 
 import (
 	"context"
-	"crypto/ed25519"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
@@ -15,6 +16,7 @@ import (
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/provision"
 	"github.com/threefoldtech/test/pkg/rrd"
 	"github.com/threefoldtech/test/pkg/stubs"
 )
@@ -22,18 +24,26 @@ import (
 const (
 	every           = 60 * 60 // 1 hour
 	lastReportedKey = ".last-reported-ts"
+
+	metricsWindow    = 5 * time.Minute
+	metricsRetention = 24 * time.Hour
 )
 
+var _ pkg.Consumption = (*Reporter)(nil)
+
 type Report struct {
 	Consumption []substrate.NruConsumption
+	// Record is added to the report history once the report is sent
+	Record reportRecord
 }
 
 // Reporter structure
 type Reporter struct {
-	cl  zbus.Client
-	rrd rrd.RRD
+	cl      zbus.Client
+	rrd     rrd.RRD
+	history *reportHistory
+	storage provision.Storage
 
-	identity         substrate.Identity
 	queue            *dque.DQue
 	substrateGateway *stubs.SubstrateGatewayStub
 }
@@ -43,7 +53,7 @@ func reportBuilder() interface{} {
 }
 
 func ReportChecks(metricsPath string) error {
-	rrd, err := rrd.NewRRDBolt(metricsPath, 5*time.Minute, 24*time.Hour)
+	rrd, err := rrd.NewRRDBolt(metricsPath, metricsWindow, metricsRetention)
 	if err != nil {
 		return errors.Wrap(err, "failed to create metrics database")
 	}
@@ -55,17 +65,14 @@ func ReportChecks(metricsPath string) error {
 	return rrd.Close()
 }
 
-// NewReporter creates a new capacity reporter
-func NewReporter(metricsPath string, cl zbus.Client, root string) (*Reporter, error) {
-	idMgr := stubs.NewIdentityManagerStub(cl)
-	sk := ed25519.PrivateKey(idMgr.PrivateKey(context.TODO()))
-	id, err := substrate.NewIdentityFromEd25519Key(sk)
-	if err != nil {
-		return nil, err
-	}
-
+// NewReporter creates a new capacity reporter. Sent reports are kept in
+// the report history at historyPath
+func NewReporter(metricsPath, historyPath string, cl zbus.Client, root string, storage provision.Storage) (*Reporter, error) {
 	const queueName = "consumption"
-	var queue *dque.DQue
+	var (
+		queue *dque.DQue
+		err   error
+	)
 	for i := 0; i < 3; i++ {
 		queue, err = dque.NewOrOpen(queueName, root, 1024, reportBuilder)
 		if err != nil {
@@ -81,15 +88,22 @@ func NewReporter(metricsPath string, cl zbus.Client, root string) (*Reporter, er
 
 	substrateGateway := stubs.NewSubstrateGatewayStub(cl)
 
-	rrd, err := rrd.NewRRDBolt(metricsPath, 5*time.Minute, 24*time.Hour)
+	rrd, err := rrd.NewRRDBolt(metricsPath, metricsWindow, metricsRetention)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create metrics database")
 	}
 
+	history, err := newReportHistory(historyPath)
+	if err != nil {
+		rrd.Close()
+		return nil, err
+	}
+
 	return &Reporter{
 		cl:               cl,
 		rrd:              rrd,
-		identity:         id,
+		history:          history,
+		storage:          storage,
 		queue:            queue,
 		substrateGateway: substrateGateway,
 	}, nil
@@ -112,6 +126,12 @@ func (r *Reporter) pushOne() error {
 
 	log.Info().Str("hash", hash.Hex()).Msg("report block hash")
 
+	if len(report.Record.Contracts) != 0 {
+		if err := r.history.Add(report.Record); err != nil {
+			log.Error().Err(err).Msg("failed to record consumption report in history")
+		}
+	}
+
 	// only removed if report is reported to substrate
 	// remove item from queue
 	_, err = r.queue.Dequeue()
@@ -288,6 +308,7 @@ func (r *Reporter) getLastReportTime() (int64, bool, error) {
 
 func (r *Reporter) Close() {
 	_ = r.rrd.Close()
+	_ = r.history.Close()
 	_ = r.queue.Close()
 }
 
@@ -361,12 +382,13 @@ func (r *Reporter) report(ctx context.Context, since time.Time) (time.Time, erro
 	}
 
 	reports := make(map[uint64]substrate.NruConsumption)
+	twins := make(map[uint64]uint32)
 	for key, value := range values {
 		if key == lastReportedKey {
 			continue
 		}
 
-		_, deployment, _, err := gridtypes.WorkloadID(key).Parts()
+		twin, deployment, _, err := gridtypes.WorkloadID(key).Parts()
 		if err != nil {
 			log.Error().Err(err).Msgf("failed to parse metric key '%s'", key)
 			continue
@@ -383,20 +405,168 @@ func (r *Reporter) report(ctx context.Context, since time.Time) (time.Time, erro
 
 		rep.NRU += types.U64(value)
 		reports[deployment] = rep
+		twins[deployment] = twin
 	}
 
-	var report Report
+	report := Report{
+		Record: reportRecord{
+			Timestamp: now.Unix(),
+			Window:    uint64(window / time.Second),
+		},
+	}
 
 	for _, v := range reports {
 		if v.NRU == 0 {
 			continue
 		}
 		report.Consumption = append(report.Consumption, v)
+		report.Record.Contracts = append(report.Record.Contracts, reportedContract{
+			Contract: uint64(v.ContractID),
+			Twin:     twins[uint64(v.ContractID)],
+			NU:       uint64(v.NRU),
+		})
+	}
+
+	// keep the reserved capacity so consumption can be computed
+	// later even for deleted contracts
+	reserved, err := r.reserved()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get reserved capacity")
+	} else if err := r.history.AddCapacity(capacityRecord{Timestamp: now.Unix(), Contracts: reserved}); err != nil {
+		log.Error().Err(err).Msg("failed to record reserved capacity in history")
 	}
 
 	return now, r.push(report)
 }
 
+// reserved returns the capacity reserved by each active contract
+func (r *Reporter) reserved() ([]reservedContract, error) {
+	active, err := r.storage.Capacity()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get active deployments")
+	}
+
+	reserved := make([]reservedContract, 0, len(active.Deployments))
+	for _, dl := range active.Deployments {
+		contract := reservedContract{Contract: dl.ContractID, Twin: dl.TwinID}
+		for _, wl := range dl.Workloads {
+			if !wl.Result.State.IsOkay() {
+				continue
+			}
+
+			cap, err := wl.Capacity()
+			if err != nil {
+				log.Error().Err(err).Str("workload", wl.Name.String()).Msg("failed to get workload capacity")
+				continue
+			}
+			contract.Capacity.Add(&cap)
+		}
+
+		reserved = append(reserved, contract)
+	}
+
+	return reserved, nil
+}
+
+// Consumption implements pkg.Consumption. NU is computed from the local
+// metrics (only kept for metricsRetention), reported NU from the history
+// of sent reports, and capacity from the history of reserved capacity.
+func (r *Reporter) Consumption(twin uint32, from, to int64) (pkg.ConsumptionReport, error) {
+	if to <= from {
+		return pkg.ConsumptionReport{}, fmt.Errorf("invalid time range, 'to' must be after 'from'")
+	}
+
+	report := pkg.ConsumptionReport{
+		From:          from,
+		To:            to,
+		MeasuredSince: time.Now().Add(-metricsRetention).Unix(),
+	}
+
+	contracts := make(map[uint64]*pkg.ContractConsumption)
+	get := func(contract uint64, owner uint32) *pkg.ContractConsumption {
+		c, ok := contracts[contract]
+		if !ok {
+			c = &pkg.ContractConsumption{Contract: contract, Twin: owner}
+			contracts[contract] = c
+		}
+		return c
+	}
+
+	snapshots, err := r.history.CapacityBetween(from, to)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to get reserved capacity history")
+	}
+
+	if to >= time.Now().Unix() {
+		// the last snapshot can be up to a report period old
+		reserved, err := r.reserved()
+		if err != nil {
+			return report, err
+		}
+		snapshots = append(snapshots, capacityRecord{Timestamp: time.Now().Unix(), Contracts: reserved})
+	}
+
+	for _, snapshot := range snapshots {
+		for _, reserved := range snapshot.Contracts {
+			if twin != 0 && reserved.Twin != twin {
+				continue
+			}
+
+			peak(&get(reserved.Contract, reserved.Twin).Capacity, &reserved.Capacity)
+		}
+	}
+
+	values, err := r.rrd.CountersRange(time.Unix(from, 0), time.Unix(to, 0))
+	if err != nil {
+		return report, errors.Wrap(err, "failed to get stored metrics from rrd")
+	}
+
+	for key, value := range values {
+		if key == lastReportedKey {
+			continue
+		}
+
+		owner, deployment, _, err := gridtypes.WorkloadID(key).Parts()
+		if err != nil {
+			continue
+		}
+
+		if twin != 0 && owner != twin {
+			continue
+		}
+
+		get(deployment, owner).NU += uint64(value)
+	}
+
+	records, err := r.history.Between(from, to)
+	if err != nil {
+		return report, errors.Wrap(err, "failed to get sent reports")
+	}
+
+	for _, record := range records {
+		for _, reported := range record.Contracts {
+			if twin != 0 && reported.Twin != twin {
+				continue
+			}
+
+			c := get(reported.Contract, reported.Twin)
+			c.ReportedNU += reported.NU
+			c.Reports++
+		}
+	}
+
+	report.Contracts = make([]pkg.ContractConsumption, 0, len(contracts))
+	for _, c := range contracts {
+		report.Contracts = append(report.Contracts, *c)
+	}
+
+	sort.Slice(report.Contracts, func(i, j int) bool {
+		return report.Contracts[i].Contract < report.Contracts[j].Contract
+	})
+
+	return report, nil
+}
+
 func (r *Reporter) push(report Report) error {
 	if len(report.Consumption) == 0 {
 		return nil
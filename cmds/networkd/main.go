@@ -22,6 +22,7 @@ import (
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/app"
+	nodeidentity "github.com/threefoldtech/test/pkg/identity"
 	"github.com/threefoldtech/test/pkg/network"
 	"github.com/threefoldtech/test/pkg/network/bootstrap"
 	"github.com/threefoldtech/test/pkg/network/ndmz"
@@ -118,7 +119,12 @@ func main() {
 		}
 	}
 
-	ygg, err := startYggdrasil(ctx, identity.PrivateKey(), ndmzNs)
+	networkKey, err := nodeidentity.NetworkKey(ctx, identity)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to get network key")
+	}
+
+	ygg, err := startYggdrasil(ctx, networkKey, ndmzNs)
 	if err != nil {
 		log.Fatal().Err(err).Msgf("fail to start yggdrasil")
 	}
@@ -18,7 +18,7 @@ file_t *file_new(char *path) {
     if(!(backend = calloc(sizeof(file_t), 1)))
         diep("calloc");
 
-    if(!(backend->fp = fopen(path, "w")))
+    if(!(backend->fp = fopen(path, "a")))
         diep("fopen");
 
     backend->write = file_write;
@@ -3,35 +3,110 @@
 #include <unistd.h>
 #include <string.h>
 #include <fcntl.h>
+#include <time.h>
 #include <sys/epoll.h>
 #include <sys/types.h>
 #include <sys/stat.h>
 #include "shim-logs.h"
 #include "url_parser.h"
 
+static json_int_t now_ms() {
+    struct timespec ts;
+
+    clock_gettime(CLOCK_REALTIME, &ts);
+    return (json_int_t) ts.tv_sec * 1000 + ts.tv_nsec / 1000000;
+}
+
+static int redis_publish(redis_t *self, const char *data, size_t len) {
+    redisReply *reply;
+
+    if(!(reply = redisCommand(self->conn, "PUBLISH %s %b", self->channel, data, len)))
+        diep("redis");
+
+    freeReplyObject(reply);
+
+    return 0;
+}
+
 int redis_write(void *_self, char *line, int len) {
-    (void) len;
     redis_t *self = (redis_t *) _self;
+    json_t *record;
+    char *data;
+
+    if(!self->json)
+        return redis_publish(self, line, len);
+
+    // line contains the trailing new line
+    if(len > 0 && line[len - 1] == '\n')
+        len -= 1;
+
+    record = json_pack("{s:I, s:s, s:s#}",
+        "timestamp", now_ms(),
+        "stream", self->stream,
+        "message", line, (size_t) len
+    );
+
+    if(!record)
+        return 1;
+
+    if(self->workload)
+        json_object_set_new(record, "workload", json_string(self->workload));
+
+    if(self->twin)
+        json_object_set_new(record, "twin", json_integer(self->twin));
+
+    if(!(data = json_dumps(record, JSON_COMPACT))) {
+        json_decref(record);
+        return 1;
+    }
+
+    redis_publish(self, data, strlen(data));
+
+    free(data);
+    json_decref(record);
+
+    return 0;
+}
+
+static int redis_auth(redis_t *backend, redis_options_t *options) {
     redisReply *reply;
 
-    if(!(reply = redisCommand(self->conn, "PUBLISH %s %s", self->channel, line)))
-        diep("redis");
+    if(!options->password)
+        return 0;
+
+    // username is only supported by redis 6 acl
+    if(options->username && strlen(options->username))
+        reply = redisCommand(backend->conn, "AUTH %s %s", options->username, options->password);
+    else
+        reply = redisCommand(backend->conn, "AUTH %s", options->password);
+
+    if(!reply) {
+        printf("redis: auth: %s\n", backend->conn->errstr);
+        return 1;
+    }
+
+    if(reply->type == REDIS_REPLY_ERROR) {
+        printf("redis: auth: %s\n", reply->str);
+        freeReplyObject(reply);
+        return 1;
+    }
 
     freeReplyObject(reply);
 
     return 0;
 }
 
-redis_t *redis_new(char *host, int port, char *channel) {
+redis_t *redis_new(redis_options_t *options) {
     redis_t *backend;
     struct timeval timeout = { 2, 0 };
+    redisSSLContextError sslerr;
 
-    printf("[+] redis backend: [%s:%d / %s]\n", host, port, channel);
+    printf("[+] redis backend: [%s:%d / %s] (tls: %d)\n", options->host, options->port, options->channel, options->tls);
 
     if(!(backend = calloc(sizeof(redis_t), 1)))
         diep("calloc");
 
-    if(!(backend->conn = redisConnectWithTimeout(host, port, timeout)))
+    if(!(backend->conn = redisConnectWithTimeout(options->host, options->port, timeout)))
         diep("redis");
 
     if(backend->conn->err) {
@@ -39,25 +114,98 @@ redis_t *redis_new(char *host, int port, char *channel) {
         return NULL;
     }
 
-    backend->channel = strdup(channel);
+    if(options->tls) {
+        // without a ca file, the system certificates are used
+        if(!(backend->ssl = redisCreateSSLContext(options->cafile, NULL, NULL, NULL, options->host, &sslerr))) {
+            printf("redis: tls: %s\n", redisSSLContextGetError(sslerr));
+            return NULL;
+        }
+
+        if(redisInitiateSSLWithContext(backend->conn, backend->ssl) != REDIS_OK) {
+            printf("redis: tls: %s\n", backend->conn->errstr);
+            return NULL;
+        }
+    }
+
+    if(redis_auth(backend, options))
+        return NULL;
+
+    backend->channel = strdup(options->channel);
     backend->write = redis_write;
 
     return backend;
 }
 
-static int redis_attach(const char *url, log_t *target) {
-    int port = 6379;
+// redis_cafile writes the ca certificate of the backend
+// next to the container configuration, tls library
+// only loads certificates from files
+static char *redis_cafile(container_t *c, const char *ca) {
+    static int index = 0;
+    char path[512];
+    int fd, length;
+
+    // namespace and id are set by the workload, the path
+    // is rejected if it doesn't fit in the buffer
+    length = snprintf(path, sizeof(path), "%s/%s/%s-ca-%d.pem", CONFDIR, c->namespace, c->id, index++);
+    if(length < 0 || (size_t) length >= sizeof(path)) {
+        fprintf(stderr, "[-] redis: ca file path too long\n");
+        return NULL;
+    }
+
+    if((fd = open(path, O_WRONLY | O_CREAT | O_TRUNC, 0600)) < 0) {
+        perror("open");
+        return NULL;
+    }
+
+    if(write(fd, ca, strlen(ca)) != (ssize_t) strlen(ca)) {
+        perror("write");
+        close(fd);
+        return NULL;
+    }
+
+    close(fd);
+
+    return strdup(path);
+}
+
+// settings shared by the stdout and stderr backends
+typedef struct redis_settings_t {
+    int json;
+    char *workload;
+    json_int_t twin;
+    char *cafile;
+
+} redis_settings_t;
+
+static int redis_attach(const char *url, log_t *target, const char *stream, redis_settings_t *settings) {
     struct parsed_url *purl;
+    redis_options_t options;
     redis_t *redis;
 
     if(!(purl = parse_url(url)))
         return 1;
 
+    memset(&options, 0, sizeof(redis_options_t));
+    options.host = purl->host;
+    options.port = 6379;
+    options.channel = purl->path;
+    options.tls = (strcmp(purl->scheme, "rediss") == 0);
+    options.cafile = settings->cafile;
+    options.username = purl->username;
+    options.password = purl->password;
+
     if(purl->port)
-        port = atoi(purl->port);
+        options.port = atoi(purl->port);
 
-    if(!(redis = redis_new(purl->host, port, purl->path)))
+    if(!(redis = redis_new(&options))) {
+        parsed_url_free(purl);
         return 1;
+    }
+
+    redis->json = settings->json;
+    redis->stream = stream;
+    redis->workload = settings->workload;
+    redis->twin = settings->twin;
 
     parsed_url_free(purl);
     log_attach(target, redis, redis->write);
@@ -68,12 +216,36 @@ static int redis_attach(const char *url, log_t *target) {
 int redis_extract(container_t *c, json_t *root) {
     json_t *sout = json_object_get(root, "stdout");
     json_t *serr = json_object_get(root, "stderr");
+    json_t *format = json_object_get(root, "format");
+    json_t *ca = json_object_get(root, "ca");
+    json_t *workload = json_object_get(root, "workload");
+    json_t *twin = json_object_get(root, "twin");
+    redis_settings_t settings;
 
     if(!json_is_string(sout) || !json_is_string(serr))
         return 1;
 
-    redis_attach(json_string_value(sout), c->logout);
-    redis_attach(json_string_value(serr), c->logerr);
+    memset(&settings, 0, sizeof(redis_settings_t));
+
+    if(json_is_string(format) && strcmp(json_string_value(format), "json") == 0)
+        settings.json = 1;
+
+    if(json_is_string(workload))
+        settings.workload = strdup(json_string_value(workload));
+
+    if(json_is_integer(twin))
+        settings.twin = json_integer_value(twin);
+
+    if(json_is_string(ca) && strlen(json_string_value(ca))) {
+        // never fall back to the system certificates if a ca is set
+        if(!(settings.cafile = redis_cafile(c, json_string_value(ca)))) {
+            free(settings.workload);
+            return 1;
+        }
+    }
+
+    redis_attach(json_string_value(sout), c->logout, "stdout", &settings);
+    redis_attach(json_string_value(serr), c->logerr, "stderr", &settings);
 
     return 0;
 }
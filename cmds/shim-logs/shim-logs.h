@@ -2,6 +2,7 @@
     #define SHIM_LOGS_H
 
     #include <hiredis/hiredis.h>
+    #include <hiredis/hiredis_ssl.h>
     #include <jansson.h>
 
     #define MAXEVENTS   64
@@ -76,13 +77,36 @@
     //
     typedef struct redis_t {
         redisContext *conn;
+        redisSSLContext *ssl;
         char *channel;
         int (*write)(void *self, char *line, int len);
 
+        // json records settings, lines are published
+        // as is if json is not set
+        int json;
+        const char *stream;
+        char *workload;
+        json_int_t twin;
+
     } redis_t;
 
+    //
+    // redis connection settings, extracted from
+    // the backend configuration and url
+    //
+    typedef struct redis_options_t {
+        char *host;
+        int port;
+        char *channel;
+        int tls;
+        char *cafile;
+        char *username;
+        char *password;
+
+    } redis_options_t;
+
     int redis_write(void *_self, char *line, int len);
-    redis_t *redis_new(char *host, int port, char *channel);
+    redis_t *redis_new(redis_options_t *options);
     int redis_extract(container_t *c, json_t *root);
 
     //
@@ -8,6 +8,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/test/cmds/modules/capacityd"
 	"github.com/threefoldtech/test/cmds/modules/contd"
+	"github.com/threefoldtech/test/cmds/modules/exporterd"
 	"github.com/threefoldtech/test/cmds/modules/flistd"
 	"github.com/threefoldtech/test/cmds/modules/networkd"
 	"github.com/threefoldtech/test/cmds/modules/provisiond"
@@ -43,6 +44,7 @@ func main() {
 			&networkd.Module,
 			&provisiond.Module,
 			&zbusdebug.Module,
+			&exporterd.Module,
 		},
 		Action: func(c *cli.Context) error {
 			if !c.Bool("list") {
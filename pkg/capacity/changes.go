@@ -0,0 +1,198 @@
+package capacity
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/threefoldtech/test/pkg"
+)
+
+// component is a hardware component identified by id, state is compared
+// to detect changes of the same component
+type component struct {
+	id      string
+	details string
+	state   string
+	failed  bool
+}
+
+func diffComponents(kind pkg.HardwareComponent, before, after []component) []pkg.HardwareEvent {
+	old := make(map[string]component)
+	for _, c := range before {
+		old[c.id] = c
+	}
+
+	current := make(map[string]component)
+	for _, c := range after {
+		current[c.id] = c
+	}
+
+	var events []pkg.HardwareEvent
+	for _, c := range after {
+		previous, ok := old[c.id]
+		switch {
+		case !ok:
+			events = append(events, pkg.HardwareEvent{Kind: pkg.HardwareAdded, Component: kind, ID: c.id, Details: c.details})
+		case c.failed && !previous.failed:
+			events = append(events, pkg.HardwareEvent{Kind: pkg.HardwareFailed, Component: kind, ID: c.id, Details: c.details})
+		case c.state != previous.state:
+			events = append(events, pkg.HardwareEvent{
+				Kind:      pkg.HardwareChanged,
+				Component: kind,
+				ID:        c.id,
+				Details:   fmt.Sprintf("%s -> %s", previous.state, c.state),
+			})
+		}
+
+		if !ok && c.failed {
+			events = append(events, pkg.HardwareEvent{Kind: pkg.HardwareFailed, Component: kind, ID: c.id, Details: c.details})
+		}
+	}
+
+	var removed []component
+	for _, c := range before {
+		if _, ok := current[c.id]; !ok {
+			removed = append(removed, c)
+		}
+	}
+
+	sort.Slice(removed, func(i, j int) bool { return removed[i].id < removed[j].id })
+	for _, c := range removed {
+		events = append(events, pkg.HardwareEvent{Kind: pkg.HardwareRemoved, Component: kind, ID: c.id, Details: c.details})
+	}
+
+	return events
+}
+
+func cpuComponents(inv *pkg.HardwareInventory) []component {
+	if len(inv.CPU.Model) == 0 {
+		return nil
+	}
+
+	return []component{{
+		id:      "cpu",
+		details: inv.CPU.Model,
+		state:   fmt.Sprintf("%s (%d sockets, %d cores, %d threads)", inv.CPU.Model, inv.CPU.Sockets, inv.CPU.Cores, inv.CPU.Threads),
+	}}
+}
+
+func memoryComponents(inv *pkg.HardwareInventory) []component {
+	var components []component
+	for _, dimm := range inv.Memory {
+		components = append(components, component{
+			id:      dimm.Locator,
+			details: fmt.Sprintf("%s %d bytes %s", dimm.Manufacturer, dimm.Size, dimm.PartNumber),
+			state:   fmt.Sprintf("%s/%d", dimm.Serial, dimm.Size),
+		})
+	}
+
+	return components
+}
+
+func nicComponents(inv *pkg.HardwareInventory) []component {
+	var components []component
+	for _, nic := range inv.NICs {
+		components = append(components, component{
+			id:      nic.Mac,
+			details: fmt.Sprintf("%s (%s)", nic.Name, nic.Driver),
+			state:   fmt.Sprintf("%s %dMbps", nic.Name, nic.Speed),
+		})
+	}
+
+	return components
+}
+
+func diskComponents(inv *pkg.HardwareInventory) []component {
+	var components []component
+	for _, disk := range inv.Disks {
+		// the device path of a disk can change between boots, so disks
+		// without a serial can't be tracked
+		if len(disk.Serial) == 0 {
+			continue
+		}
+
+		components = append(components, component{
+			id:      disk.Serial,
+			details: fmt.Sprintf("%s %s (%s) health: %s", disk.Path, disk.Model, disk.Capacity, disk.Health),
+			state:   disk.Health,
+			failed:  disk.Failed(),
+		})
+	}
+
+	return components
+}
+
+func pciComponents(inv *pkg.HardwareInventory) []component {
+	var components []component
+	for _, device := range inv.PCI {
+		components = append(components, component{
+			id:      fmt.Sprintf("%s/%s/%s", device.Slot, device.Vendor, device.Device),
+			details: device.Name,
+			state:   fmt.Sprintf("iommu group %d", device.IOMMUGroup),
+		})
+	}
+
+	return components
+}
+
+// isMissing checks if the component kind failed to be collected in inv
+func isMissing(inv *pkg.HardwareInventory, kind pkg.HardwareComponent) bool {
+	for _, missing := range inv.Missing {
+		if missing == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keepMissing sets the components that failed to be collected in current
+// to their value in previous, so they are not reported as removed and then
+// added again once they are collected
+func keepMissing(previous, current *pkg.HardwareInventory) {
+	for _, kind := range current.Missing {
+		switch kind {
+		case pkg.HardwareComponentCPU:
+			current.CPU = previous.CPU
+		case pkg.HardwareComponentMemory:
+			current.Memory = previous.Memory
+		case pkg.HardwareComponentNIC:
+			current.NICs = previous.NICs
+		case pkg.HardwareComponentDisk:
+			current.Disks = previous.Disks
+		case pkg.HardwareComponentPCI:
+			current.PCI = previous.PCI
+		}
+	}
+}
+
+// Changes returns the hardware changes between the old and new inventory.
+// A disk that smart reports as failing is reported once as failed. Components
+// that are missing from the new inventory are not compared, and so are the
+// components missing from the old inventory that were never collected.
+func Changes(before, after *pkg.HardwareInventory) []pkg.HardwareEvent {
+	var events []pkg.HardwareEvent
+	for _, c := range []struct {
+		kind       pkg.HardwareComponent
+		components func(*pkg.HardwareInventory) []component
+	}{
+		{pkg.HardwareComponentCPU, cpuComponents},
+		{pkg.HardwareComponentMemory, memoryComponents},
+		{pkg.HardwareComponentNIC, nicComponents},
+		{pkg.HardwareComponentDisk, diskComponents},
+		{pkg.HardwareComponentPCI, pciComponents},
+	} {
+		previous := c.components(before)
+		if isMissing(after, c.kind) || (isMissing(before, c.kind) && len(previous) == 0) {
+			continue
+		}
+
+		events = append(events, diffComponents(c.kind, previous, c.components(after))...)
+	}
+
+	for i := range events {
+		events[i].Timestamp = after.Timestamp
+	}
+
+	return events
+}
@@ -0,0 +1,182 @@
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/events"
+)
+
+const (
+	// hardwareMaxEvents is the max number of hardware events kept
+	hardwareMaxEvents = 1000
+)
+
+var (
+	inventoryBucket = []byte("inventory")
+	hardwareBucket  = []byte("events")
+
+	lastInventoryKey = []byte("last")
+)
+
+// NICLister lists the network interfaces of the host, it's implemented by
+// the networker stub
+type NICLister interface {
+	PhysicalNICs(ctx context.Context) ([]pkg.HardwareNIC, error)
+}
+
+// HardwareManager keeps the hardware inventory of the node and the
+// changes detected between boots in a bolt database
+type HardwareManager struct {
+	db     *bolt.DB
+	oracle *ResourceOracle
+	nics   NICLister
+}
+
+var _ pkg.HardwareManager = (*HardwareManager)(nil)
+
+// NewHardwareManager opens (or creates) the hardware database at path
+func NewHardwareManager(path string, oracle *ResourceOracle, nics NICLister) (*HardwareManager, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open hardware database")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{inventoryBucket, hardwareBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create hardware buckets")
+	}
+
+	return &HardwareManager{db: db, oracle: oracle, nics: nics}, nil
+}
+
+func (h *HardwareManager) Close() error {
+	return h.db.Close()
+}
+
+// Scan collects the current inventory and records the changes from the
+// previous inventory as events. No events are recorded on the first scan.
+func (h *HardwareManager) Scan(ctx context.Context) ([]pkg.HardwareEvent, error) {
+	current, err := h.oracle.Inventory()
+	if err != nil {
+		return nil, err
+	}
+
+	// fail instead of reporting all the interfaces as removed
+	current.NICs, err = h.nics.PhysicalNICs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list network interfaces")
+	}
+
+	return h.update(current)
+}
+
+func (h *HardwareManager) update(current pkg.HardwareInventory) ([]pkg.HardwareEvent, error) {
+	var changes []pkg.HardwareEvent
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		inventory := tx.Bucket(inventoryBucket)
+		if data := inventory.Get(lastInventoryKey); data != nil {
+			var previous pkg.HardwareInventory
+			if err := json.Unmarshal(data, &previous); err != nil {
+				// a broken inventory is overwritten
+				log.Error().Err(err).Msg("failed to decode previous hardware inventory")
+			} else {
+				keepMissing(&previous, &current)
+				changes = Changes(&previous, &current)
+			}
+		}
+
+		bucket := tx.Bucket(hardwareBucket)
+		for i := range changes {
+			offset, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			changes[i].Offset = offset
+			value, err := json.Marshal(changes[i])
+			if err != nil {
+				return err
+			}
+
+			if err := bucket.Put(events.OffsetKey(offset), value); err != nil {
+				return err
+			}
+		}
+
+		if err := events.Trim(bucket, hardwareMaxEvents); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+
+		return inventory.Put(lastInventoryKey, data)
+	})
+
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update hardware inventory")
+	}
+
+	return changes, nil
+}
+
+// Inventory returns the last collected inventory
+func (h *HardwareManager) Inventory() (inv pkg.HardwareInventory, err error) {
+	err = h.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(inventoryBucket).Get(lastInventoryKey)
+		if data == nil {
+			return fmt.Errorf("hardware inventory is not collected yet")
+		}
+
+		return json.Unmarshal(data, &inv)
+	})
+
+	return
+}
+
+// Events returns the last count hardware events in the order they were
+// recorded, 0 means all
+func (h *HardwareManager) Events(count uint32) ([]pkg.HardwareEvent, error) {
+	if count == 0 || count > hardwareMaxEvents {
+		count = hardwareMaxEvents
+	}
+
+	events := []pkg.HardwareEvent{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(hardwareBucket).Cursor()
+		for k, v := cur.Last(); k != nil && len(events) < int(count); k, v = cur.Prev() {
+			var event pkg.HardwareEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return errors.Wrap(err, "failed to decode hardware event")
+			}
+			events = append(events, event)
+		}
+
+		return nil
+	})
+
+	// return them in the order they were recorded
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+
+	return events, err
+}
@@ -0,0 +1,250 @@
+package capacity
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/capacity/dmi"
+	"github.com/threefoldtech/test/pkg/capacity/smartctl"
+)
+
+const (
+	cpuInfoFile = "/proc/cpuinfo"
+)
+
+// Inventory collects the hardware inventory of the node. Failing to collect
+// one of the components is logged and the component is set as missing, the
+// rest of the inventory is still returned. The network interfaces are not collected since they are not
+// visible from the namespace of the caller, they are listed by networkd.
+func (r *ResourceOracle) Inventory() (inv pkg.HardwareInventory, err error) {
+	inv.Timestamp = time.Now().Unix()
+
+	file, err := os.Open(cpuInfoFile)
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to open cpu info")
+	}
+	defer file.Close()
+
+	inv.CPU, err = parseCPUInfo(file)
+	if err != nil {
+		return inv, errors.Wrap(err, "failed to parse cpu info")
+	}
+
+	if decoded, err := r.DMI(); err != nil {
+		log.Error().Err(err).Msg("failed to get memory modules")
+		inv.Missing = append(inv.Missing, pkg.HardwareComponentMemory)
+	} else {
+		inv.Memory = memoryModules(decoded)
+	}
+
+	if inv.Disks, err = smartDisks(); err != nil {
+		log.Error().Err(err).Msg("failed to list disks")
+		inv.Missing = append(inv.Missing, pkg.HardwareComponentDisk)
+	}
+
+	if inv.PCI, err = pciDevices(); err != nil {
+		log.Error().Err(err).Msg("failed to list pci devices")
+		inv.Missing = append(inv.Missing, pkg.HardwareComponentPCI)
+	}
+
+	return inv, nil
+}
+
+func parseCPUInfo(reader io.Reader) (cpu pkg.HardwareCPU, err error) {
+	sockets := make(map[string]struct{})
+	cores := make(map[string]struct{})
+
+	var physical string
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "processor":
+			cpu.Threads++
+		case "model name":
+			cpu.Model = value
+		case "physical id":
+			physical = value
+			sockets[value] = struct{}{}
+		case "core id":
+			cores[physical+":"+value] = struct{}{}
+		case "flags":
+			if cpu.Flags == nil {
+				cpu.Flags = strings.Fields(value)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return cpu, err
+	}
+
+	cpu.Sockets = uint32(len(sockets))
+	cpu.Cores = uint32(len(cores))
+	// virtual machines do not always report the topology
+	if cpu.Sockets == 0 {
+		cpu.Sockets = 1
+	}
+	if cpu.Cores == 0 {
+		cpu.Cores = cpu.Threads
+	}
+
+	return cpu, nil
+}
+
+// parseMemorySize parses the dmi memory size (for example `16 GB`) in bytes
+func parseMemorySize(size string) (uint64, error) {
+	parts := strings.Fields(size)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid memory size '%s'", size)
+	}
+
+	value, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size '%s'", size)
+	}
+
+	switch strings.ToUpper(parts[1]) {
+	case "KB":
+		return value * 1024, nil
+	case "MB":
+		return value * 1024 * 1024, nil
+	case "GB":
+		return value * 1024 * 1024 * 1024, nil
+	case "TB":
+		return value * 1024 * 1024 * 1024 * 1024, nil
+	}
+
+	return 0, fmt.Errorf("invalid memory size unit '%s'", size)
+}
+
+// memoryModules returns the installed memory modules from the dmi memory
+// device sections. Empty slots are skipped
+func memoryModules(decoded *dmi.DMI) []pkg.HardwareDIMM {
+	var dimms []pkg.HardwareDIMM
+	for _, section := range decoded.Sections {
+		if section.Type != dmi.TypeMemoryDevice {
+			continue
+		}
+
+		for _, sub := range section.SubSections {
+			props := sub.Properties
+			size, err := parseMemorySize(props["Size"].Val)
+			if err != nil {
+				// No Module Installed
+				continue
+			}
+
+			dimms = append(dimms, pkg.HardwareDIMM{
+				Locator:      props["Locator"].Val,
+				Size:         size,
+				Type:         props["Type"].Val,
+				Speed:        props["Speed"].Val,
+				Manufacturer: props["Manufacturer"].Val,
+				Serial:       props["Serial Number"].Val,
+				PartNumber:   props["Part Number"].Val,
+			})
+		}
+	}
+
+	return dimms
+}
+
+// smartDisks lists the disks with smartctl, disks that can't be queried
+// are skipped
+func smartDisks() ([]pkg.HardwareDisk, error) {
+	devices, err := smartctl.ListDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []pkg.HardwareDisk
+	for _, device := range devices {
+		info, err := smartctl.DeviceInfo(device)
+		if err != nil {
+			log.Error().Err(err).Str("device", device.Path).Msg("failed to get disk info")
+			continue
+		}
+
+		disk := diskFromInfo(device.Path, info)
+		if disk.Health, err = smartctl.DeviceHealth(device); err != nil {
+			log.Error().Err(err).Str("device", device.Path).Msg("failed to get disk health")
+		}
+
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+// first returns the value of the first key found in values
+func first(values map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+func diskFromInfo(path string, info smartctl.Info) pkg.HardwareDisk {
+	// smartctl uses different keys for ata, nvme and scsi devices
+	return pkg.HardwareDisk{
+		Path:     path,
+		Model:    first(info.Information, "Device Model", "Model Number", "Product"),
+		Serial:   first(info.Information, "Serial Number", "Serial number"),
+		Capacity: first(info.Information, "User Capacity", "Total NVM Capacity", "Namespace 1 Size/Capacity"),
+	}
+}
+
+func pciDevices() ([]pkg.HardwarePCI, error) {
+	devices, err := ListPCI()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]pkg.HardwarePCI, 0, len(devices))
+	for _, device := range devices {
+		entry := pkg.HardwarePCI{
+			Slot:       device.Slot,
+			Vendor:     fmt.Sprintf("%04x", device.Vendor),
+			Device:     fmt.Sprintf("%04x", device.Device),
+			Class:      fmt.Sprintf("%06x", device.Class),
+			IOMMUGroup: -1,
+		}
+
+		if vendor, dev, ok := device.GetDevice(); ok {
+			entry.Name = fmt.Sprintf("%s %s", vendor.Name, dev.Name)
+		}
+
+		if group, err := os.Readlink(filepath.Join(pciDir, device.Slot, "iommu_group")); err == nil {
+			if id, err := strconv.Atoi(filepath.Base(group)); err == nil {
+				entry.IOMMUGroup = id
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Slot < result[j].Slot })
+	return result, nil
+}
@@ -0,0 +1,213 @@
+package capacity
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/capacity/dmi"
+)
+
+func TestParseCPUInfo(t *testing.T) {
+	const cpuinfo = `processor	: 0
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 0
+core id		: 0
+flags		: fpu vme sse sse2 vmx
+
+processor	: 1
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 0
+core id		: 0
+flags		: fpu vme sse sse2 vmx
+
+processor	: 2
+model name	: Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz
+physical id	: 1
+core id		: 0
+flags		: fpu vme sse sse2 vmx
+`
+
+	cpu, err := parseCPUInfo(strings.NewReader(cpuinfo))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Intel(R) Xeon(R) CPU E5-2620 v4 @ 2.10GHz", cpu.Model)
+	assert.Equal(t, uint32(2), cpu.Sockets)
+	assert.Equal(t, uint32(2), cpu.Cores)
+	assert.Equal(t, uint32(3), cpu.Threads)
+	assert.Equal(t, []string{"fpu", "vme", "sse", "sse2", "vmx"}, cpu.Flags)
+}
+
+func TestMemoryModules(t *testing.T) {
+	decoded := &dmi.DMI{
+		Sections: []dmi.Section{
+			{
+				Type: dmi.TypeMemoryDevice,
+				SubSections: []dmi.SubSection{
+					{
+						Title: "Memory Device",
+						Properties: map[string]dmi.PropertyData{
+							"Size":          {Val: "16 GB"},
+							"Locator":       {Val: "DIMM_A1"},
+							"Type":          {Val: "DDR4"},
+							"Serial Number": {Val: "1234"},
+						},
+					},
+					{
+						Title: "Memory Device",
+						Properties: map[string]dmi.PropertyData{
+							"Size":    {Val: "No Module Installed"},
+							"Locator": {Val: "DIMM_A2"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	dimms := memoryModules(decoded)
+	require.Len(t, dimms, 1)
+	assert.Equal(t, "DIMM_A1", dimms[0].Locator)
+	assert.Equal(t, uint64(16*1024*1024*1024), dimms[0].Size)
+	assert.Equal(t, "1234", dimms[0].Serial)
+}
+
+func TestChanges(t *testing.T) {
+	before := pkg.HardwareInventory{
+		CPU:    pkg.HardwareCPU{Model: "cpu", Sockets: 1, Cores: 4, Threads: 8},
+		Memory: []pkg.HardwareDIMM{{Locator: "DIMM_A1", Size: 1024, Serial: "1"}},
+		NICs:   []pkg.HardwareNIC{{Name: "eth0", Mac: "aa", Speed: 1000}},
+		Disks: []pkg.HardwareDisk{
+			{Path: "/dev/sda", Serial: "sda", Health: "PASSED"},
+			{Path: "/dev/sdb", Serial: "sdb", Health: "PASSED"},
+			{Path: "/dev/vda"},
+		},
+	}
+
+	after := pkg.HardwareInventory{
+		Timestamp: 10,
+		CPU:       pkg.HardwareCPU{Model: "cpu", Sockets: 1, Cores: 4, Threads: 8},
+		Memory: []pkg.HardwareDIMM{
+			{Locator: "DIMM_A1", Size: 1024, Serial: "1"},
+			{Locator: "DIMM_A2", Size: 1024, Serial: "2"},
+		},
+		NICs: []pkg.HardwareNIC{{Name: "eth0", Mac: "aa", Speed: 100}},
+		Disks: []pkg.HardwareDisk{
+			{Path: "/dev/sdb", Serial: "sda", Health: "FAILED"},
+			{Path: "/dev/vdb"},
+		},
+	}
+
+	events := Changes(&before, &after)
+
+	type change struct {
+		kind      pkg.HardwareEventKind
+		component pkg.HardwareComponent
+		id        string
+	}
+
+	var changes []change
+	for _, event := range events {
+		assert.Equal(t, int64(10), event.Timestamp)
+		changes = append(changes, change{event.Kind, event.Component, event.ID})
+	}
+
+	assert.Equal(t, []change{
+		{pkg.HardwareAdded, pkg.HardwareComponentMemory, "DIMM_A2"},
+		{pkg.HardwareChanged, pkg.HardwareComponentNIC, "aa"},
+		{pkg.HardwareFailed, pkg.HardwareComponentDisk, "sda"},
+		{pkg.HardwareRemoved, pkg.HardwareComponentDisk, "sdb"},
+	}, changes)
+
+	assert.Empty(t, Changes(&after, &after))
+}
+
+func TestHardwareManager(t *testing.T) {
+	mgr, err := NewHardwareManager(filepath.Join(t.TempDir(), "hardware.db"), nil, nil)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	_, err = mgr.Inventory()
+	require.Error(t, err)
+
+	first := pkg.HardwareInventory{
+		Timestamp: 1,
+		Disks:     []pkg.HardwareDisk{{Path: "/dev/sda", Serial: "sda"}},
+	}
+
+	events, err := mgr.update(first)
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	second := pkg.HardwareInventory{Timestamp: 2}
+	events, err = mgr.update(second)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	inv, err := mgr.Inventory()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), inv.Timestamp)
+
+	stored, err := mgr.Events(0)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, uint64(1), stored[0].Offset)
+	assert.Equal(t, pkg.HardwareRemoved, stored[0].Kind)
+	assert.Equal(t, "sda", stored[0].ID)
+
+	third := pkg.HardwareInventory{
+		Timestamp: 3,
+		Memory:    []pkg.HardwareDIMM{{Locator: "DIMM_A1", Size: 1024, Serial: "1"}},
+		Disks:     []pkg.HardwareDisk{{Path: "/dev/sda", Serial: "sda"}},
+	}
+	events, err = mgr.update(third)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	// disks failed to be collected, they are not reported as removed
+	events, err = mgr.update(pkg.HardwareInventory{
+		Timestamp: 4,
+		Memory:    third.Memory,
+		Missing:   []pkg.HardwareComponent{pkg.HardwareComponentDisk},
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// the disks are back and memory failed to be collected
+	events, err = mgr.update(pkg.HardwareInventory{
+		Timestamp: 5,
+		Disks:     third.Disks,
+		Missing:   []pkg.HardwareComponent{pkg.HardwareComponentMemory},
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	// the last collected memory is kept
+	inv, err = mgr.Inventory()
+	require.NoError(t, err)
+	assert.Equal(t, third.Memory, inv.Memory)
+	assert.Equal(t, []pkg.HardwareComponent{pkg.HardwareComponentMemory}, inv.Missing)
+}
+
+func TestHardwareManagerNeverCollected(t *testing.T) {
+	mgr, err := NewHardwareManager(filepath.Join(t.TempDir(), "hardware.db"), nil, nil)
+	require.NoError(t, err)
+	defer mgr.Close()
+
+	_, err = mgr.update(pkg.HardwareInventory{
+		Timestamp: 1,
+		Missing:   []pkg.HardwareComponent{pkg.HardwareComponentPCI},
+	})
+	require.NoError(t, err)
+
+	// pci devices are not reported as added once they are collected
+	events, err := mgr.update(pkg.HardwareInventory{
+		Timestamp: 2,
+		PCI:       []pkg.HardwarePCI{{Slot: "0000:00:01.0"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
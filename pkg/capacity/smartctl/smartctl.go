@@ -11,6 +11,7 @@ import (
 var reScan = regexp.MustCompile(`(?m)^([^\s]+)\s+-d\s+([^\s]+)\s+#`)
 var reHeader = regexp.MustCompile(`(?m)([^\[]+)\[([^\[]+)\]`)
 var reInfo = regexp.MustCompile(`(?m)([^:]+):\s+(.+)`)
+var reHealth = regexp.MustCompile(`(?m)^(?:SMART overall-health self-assessment test result|SMART Health Status):\s+(.+)$`)
 
 // ErrEmpty is return when smatctl doesn't find any device
 var ErrEmpty = errors.New("smartctl returned an empty response")
@@ -54,6 +55,30 @@ func DeviceInfo(d Device) (Info, error) {
 	return parseInfo(output)
 }
 
+// DeviceHealth returns the overall health of a device as returned by "smartctl -H {path} -d {type}"
+// (for example PASSED, FAILED or OK). An empty string is returned if the device
+// doesn't report its health
+func DeviceHealth(d Device) (string, error) {
+	cmd := exec.Command("smartctl", "-H", d.Path, "-d", d.Type)
+	output, err := cmd.Output()
+	// the exit status of smartctl is a bit mask that is also set when
+	// the disk is failing, so the output is parsed if there is one
+	if len(output) == 0 && err != nil {
+		return "", err
+	}
+
+	return parseHealth(output), nil
+}
+
+func parseHealth(b []byte) string {
+	match := reHealth.FindSubmatch(b)
+	if len(match) != 2 {
+		return ""
+	}
+
+	return strings.TrimSpace(string(match[1]))
+}
+
 func parseScan(b []byte) ([]Device, error) {
 	trimed := strings.TrimSpace(string(b))
 	lines := strings.Split(trimed, "\n")
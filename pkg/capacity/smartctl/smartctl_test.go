@@ -49,3 +49,21 @@ SMART support is:     Unavailable - device lacks SMART capability.`)
 	_, exists := info.Information["local Time is"]
 	assert.False(t, exists, "Local time should not be included in information")
 }
+
+func TestParseHealth(t *testing.T) {
+	b := []byte(`smartctl 7.0 2018-12-30 r4883 [x86_64-linux-4.14.82-Zero-OS] (local build)
+Copyright (C) 2002-18, Bruce Allen, Christian Franke, www.smartmontools.org
+
+=== START OF READ SMART DATA SECTION ===
+SMART overall-health self-assessment test result: PASSED
+`)
+	assert.Equal(t, "PASSED", parseHealth(b))
+
+	b = []byte(`=== START OF READ SMART DATA SECTION ===
+SMART Health Status: OK
+`)
+	assert.Equal(t, "OK", parseHealth(b))
+
+	b = []byte(`SMART support is:     Unavailable - device lacks SMART capability.`)
+	assert.Equal(t, "", parseHealth(b))
+}
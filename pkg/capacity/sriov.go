@@ -0,0 +1,161 @@
+package capacity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sriovTotalVFsFile = "sriov_totalvfs"
+	sriovNumVFsFile   = "sriov_numvfs"
+	virtfnPrefix      = "virtfn"
+)
+
+// VF is a virtual function of an SR-IOV capable device
+type VF struct {
+	PCI
+	// Index of the virtual function on its physical function
+	Index int
+}
+
+// SRIOV filter only physical functions that support SR-IOV and are
+// attached to a network interface
+func SRIOV(p *PCI) bool {
+	total, err := p.TotalVFs()
+	if err != nil || total == 0 {
+		return false
+	}
+
+	_, err = p.Interface()
+	return err == nil
+}
+
+// TotalVFs returns the max number of virtual functions the device supports
+func (p *PCI) TotalVFs() (uint32, error) {
+	return readDecimal(filepath.Join(pciDir, p.Slot, sriovTotalVFsFile))
+}
+
+// NumVFs returns the number of virtual functions currently enabled on the device
+func (p *PCI) NumVFs() (uint32, error) {
+	return readDecimal(filepath.Join(pciDir, p.Slot, sriovNumVFsFile))
+}
+
+// Interface returns the name of the network interface of the device
+func (p *PCI) Interface() (string, error) {
+	entries, err := os.ReadDir(filepath.Join(pciDir, p.Slot, "net"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list device '%s' interfaces: %w", p.Slot, err)
+	}
+
+	if len(entries) == 0 {
+		return "", fmt.Errorf("device '%s' has no network interface", p.Slot)
+	}
+
+	return entries[0].Name(), nil
+}
+
+// EnableVFs makes sure the virtual functions of the device are created. All
+// supported virtual functions are created at once since the number of
+// virtual functions can't be changed without removing the existing ones
+func (p *PCI) EnableVFs() error {
+	num, err := p.NumVFs()
+	if err != nil {
+		return err
+	}
+
+	if num > 0 {
+		return nil
+	}
+
+	total, err := p.TotalVFs()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(pciDir, p.Slot, sriovNumVFsFile)
+	if err := os.WriteFile(path, []byte(fmt.Sprint(total)), 0644); err != nil {
+		return fmt.Errorf("failed to enable virtual functions on '%s': %w", p.Slot, err)
+	}
+
+	return nil
+}
+
+// VFs lists the enabled virtual functions of the device ordered by index
+func (p *PCI) VFs() ([]VF, error) {
+	slots, err := virtualFunctions(filepath.Join(pciDir, p.Slot))
+	if err != nil {
+		return nil, err
+	}
+
+	vfs := make([]VF, 0, len(slots))
+	for _, slot := range slots {
+		pci, err := pciDeviceFromSlot(slot.Slot)
+		if err != nil {
+			return nil, err
+		}
+
+		vfs = append(vfs, VF{PCI: pci, Index: slot.Index})
+	}
+
+	return vfs, nil
+}
+
+// virtualFunctions reads the virtfn<index> links of the physical function
+// found at path
+func virtualFunctions(path string) ([]VF, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device '%s': %w", path, err)
+	}
+
+	var vfs []VF
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), virtfnPrefix) {
+			continue
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), virtfnPrefix))
+		if err != nil {
+			continue
+		}
+
+		target, err := os.Readlink(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read virtual function link '%s': %w", entry.Name(), err)
+		}
+
+		vfs = append(vfs, VF{PCI: PCI{Slot: filepath.Base(target)}, Index: index})
+	}
+
+	sort.Slice(vfs, func(i, j int) bool { return vfs[i].Index < vfs[j].Index })
+	return vfs, nil
+}
+
+// Driver returns the name of the driver bound to the device, or an empty
+// string if no driver is bound
+func (p *PCI) Driver() string {
+	link, err := os.Readlink(filepath.Join(pciDir, p.Slot, "driver"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(link)
+}
+
+func readDecimal(path string) (uint32, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read path '%s': %w", path, err)
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in '%s': %w", path, err)
+	}
+
+	return uint32(value), nil
+}
@@ -0,0 +1,41 @@
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualFunctions(t *testing.T) {
+	root := t.TempDir()
+	pf := filepath.Join(root, "0000:3b:00.0")
+	require.NoError(t, os.MkdirAll(pf, 0755))
+
+	links := map[string]string{
+		"virtfn0":  "../0000:3b:02.0",
+		"virtfn1":  "../0000:3b:02.1",
+		"virtfn10": "../0000:3b:03.2",
+		"virtfn2":  "../0000:3b:02.2",
+	}
+
+	for name, target := range links {
+		require.NoError(t, os.Symlink(target, filepath.Join(pf, name)))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(pf, sriovTotalVFsFile), []byte("16\n"), 0644))
+
+	vfs, err := virtualFunctions(pf)
+	require.NoError(t, err)
+
+	require.Equal(t, []VF{
+		{PCI: PCI{Slot: "0000:3b:02.0"}, Index: 0},
+		{PCI: PCI{Slot: "0000:3b:02.1"}, Index: 1},
+		{PCI: PCI{Slot: "0000:3b:02.2"}, Index: 2},
+		{PCI: PCI{Slot: "0000:3b:03.2"}, Index: 10},
+	}, vfs)
+
+	total, err := readDecimal(filepath.Join(pf, sriovTotalVFsFile))
+	require.NoError(t, err)
+	require.Equal(t, uint32(16), total)
+}
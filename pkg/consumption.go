@@ -0,0 +1,73 @@
+package pkg
+
+//go:generate zbusc -module provision -version 0.0.1 -name consumption -package stubs github.com/threefoldtech/test/pkg+Consumption stubs/consumption_stub.go
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+// ContractConsumption is the consumption of a single contract
+type ContractConsumption struct {
+	Contract uint64 `json:"contract"`
+	Twin     uint32 `json:"twin"`
+	// Capacity is the largest capacity reserved by the contract in the time range
+	Capacity gridtypes.Capacity `json:"capacity"`
+	// NU is the network units measured by the node in the time range
+	NU uint64 `json:"nu"`
+	// ReportedNU is the network units reported to the chain in the time range
+	ReportedNU uint64 `json:"reported_nu"`
+	// Reports is the number of reports sent to the chain in the time range
+	Reports int `json:"reports"`
+}
+
+// ConsumptionReport is the consumption of the node contracts in a time range
+type ConsumptionReport struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+	// MeasuredSince is the oldest time the node still has measurements for.
+	// NU consumed before that time is not included in the report (only
+	// the reported NU is)
+	MeasuredSince int64                 `json:"measured_since"`
+	Contracts     []ContractConsumption `json:"contracts"`
+}
+
+// WriteCSV writes the report contracts as csv to w
+func (r *ConsumptionReport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{
+		"contract", "twin", "cru", "mru", "sru", "hru", "ipv4u", "nu", "reported_nu", "reports",
+	}); err != nil {
+		return err
+	}
+
+	for _, c := range r.Contracts {
+		if err := out.Write([]string{
+			fmt.Sprint(c.Contract),
+			fmt.Sprint(c.Twin),
+			fmt.Sprint(c.Capacity.CRU),
+			fmt.Sprint(uint64(c.Capacity.MRU)),
+			fmt.Sprint(uint64(c.Capacity.SRU)),
+			fmt.Sprint(uint64(c.Capacity.HRU)),
+			fmt.Sprint(c.Capacity.IPV4U),
+			fmt.Sprint(c.NU),
+			fmt.Sprint(c.ReportedNU),
+			fmt.Sprint(c.Reports),
+		}); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// Consumption gives access to the consumption of the node contracts
+type Consumption interface {
+	// Consumption returns the consumption of all contracts of twin (all
+	// contracts if twin is 0) between from and to (unix timestamps)
+	Consumption(twin uint32, from, to int64) (ConsumptionReport, error)
+}
@@ -4,6 +4,7 @@ package pkg
 //go:generate zbusc -module container -version 0.0.1 -name container -package stubs github.com/threefoldtech/test/pkg+ContainerModule stubs/container_stub.go
 
 import (
+	"context"
 	"time"
 
 	"github.com/threefoldtech/test/pkg/container/logger"
@@ -85,6 +86,101 @@ type Container struct {
 	ShutdownTimeout time.Duration
 }
 
+// ContainerStats is a snapshot of the container cgroup counters
+type ContainerStats struct {
+	Timestamp int64 `json:"timestamp"`
+	// CPUUsage total cpu time consumed by the container in nanoseconds
+	CPUUsage uint64 `json:"cpu_usage"`
+	// CPUThrottled number of periods the container was throttled because
+	// it reached its cpu limit
+	CPUThrottled uint64 `json:"cpu_throttled"`
+	MemoryUsage  uint64 `json:"memory_usage"`
+	MemoryLimit  uint64 `json:"memory_limit"`
+	MemoryCache  uint64 `json:"memory_cache"`
+	// MemoryWorkingSet memory usage without the inactive page cache, this is
+	// the memory that can't be reclaimed when the container reaches its limit
+	MemoryWorkingSet uint64 `json:"memory_working_set"`
+	// MemoryLimitHits number of times the memory usage reached the limit,
+	// this includes the times the page cache was reclaimed to free memory
+	MemoryLimitHits uint64 `json:"memory_limit_hits"`
+	// OOMKills number of processes killed because the container ran out of memory
+	OOMKills    uint64 `json:"oom_kills"`
+	PidsCurrent uint64 `json:"pids_current"`
+	// PidsLimit max number of processes, 0 means no limit
+	PidsLimit  uint64 `json:"pids_limit"`
+	BlockRead  uint64 `json:"block_read"`
+	BlockWrite uint64 `json:"block_write"`
+}
+
+// ContainerLimits is reported in the result of the workloads that run in
+// a container once the container hits its resource limits, it means the
+// workload is under provisioned
+type ContainerLimits struct {
+	// Updated is the time the limits were checked
+	Updated int64 `json:"updated"`
+	// OOMKills number of processes killed because the container ran out of memory
+	OOMKills uint64 `json:"oom_kills"`
+	// MemoryLimitHit is set if the container working set is close to its
+	// memory limit
+	MemoryLimitHit bool `json:"memory_limit_hit"`
+	// PidsLimitHit is set if the container reached its max number of processes
+	PidsLimitHit bool `json:"pids_limit_hit"`
+}
+
+// memoryLimitPercent is the percentage of the memory limit the working set
+// must reach to consider the memory limit hit
+const memoryLimitPercent = 95
+
+// Limits returns the limits the container has hit. The memory limit hits
+// counter is not used since it also counts the page cache reclaims which
+// are expected once the container used its memory for caching
+func (s *ContainerStats) Limits() ContainerLimits {
+	return ContainerLimits{
+		Updated:        s.Timestamp,
+		OOMKills:       s.OOMKills,
+		MemoryLimitHit: s.MemoryLimit > 0 && s.MemoryWorkingSet >= s.MemoryLimit/100*memoryLimitPercent,
+		PidsLimitHit:   s.PidsLimit > 0 && s.PidsCurrent >= s.PidsLimit,
+	}
+}
+
+// Hit returns true if any of the container limits was hit
+func (l *ContainerLimits) Hit() bool {
+	return l.OOMKills > 0 || l.MemoryLimitHit || l.PidsLimitHit
+}
+
+// Streams of a container session output
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputLogs   = "logs"
+)
+
+// ExecRequest describes an interactive process to start inside a container
+type ExecRequest struct {
+	Args []string `json:"args"`
+	// Env extra environment variables in the KEY=VALUE form, added to
+	// the container environment
+	Env []string `json:"env,omitempty"`
+	// TTY allocates a terminal for the process, stdout and stderr
+	// are merged in that case
+	TTY  bool   `json:"tty,omitempty"`
+	Cols uint16 `json:"cols,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+	// Timeout kills the process if it is still running after timeout
+	Timeout time.Duration `json:"timeout,omitempty"`
+}
+
+// ContainerOutput is a chunk of the output of an exec or logs session
+type ContainerOutput struct {
+	Session string `json:"session"`
+	Stream  string `json:"stream"`
+	Data    []byte `json:"data,omitempty"`
+	// Exited is only set on the last message of the session
+	Exited   bool   `json:"exited,omitempty"`
+	ExitCode int    `json:"exit_code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
 // ContainerModule defines rpc interface to containerd
 type ContainerModule interface {
 	// Run creates and starts a container on the node. It also auto
@@ -110,4 +206,24 @@ type ContainerModule interface {
 
 	// Get logs of the container
 	Logs(ns string, containerID string) (logs string, err error)
+
+	// Stats returns the current cgroup stats of the container
+	Stats(ns string, id ContainerID) (ContainerStats, error)
+
+	// ExecStart starts an interactive process in the container and
+	// returns its session id. The process output is published on the
+	// Output stream
+	ExecStart(ns string, id ContainerID, req ExecRequest) (string, error)
+	// LogsFollow publishes the last lines of the container logs then
+	// all the new lines on the Output stream until the session is closed
+	LogsFollow(ns string, id ContainerID, lines int) (string, error)
+	// SessionInput writes data to the stdin of the session process,
+	// empty data closes the process stdin
+	SessionInput(session string, data []byte) error
+	// SessionResize sets the terminal size of a session started with a tty
+	SessionResize(session string, cols, rows uint16) error
+	// SessionClose kills the session process, or stops following the logs
+	SessionClose(session string) error
+	// Output streams the output of all exec and logs sessions
+	Output(ctx context.Context) <-chan ContainerOutput
 }
@@ -78,6 +78,8 @@ type Module struct {
 	root       string
 	client     zbus.Client
 	failures   *cache.Cache
+
+	sessions *sessions
 }
 
 // New return an new pkg.ContainerModule
@@ -92,6 +94,7 @@ func New(client zbus.Client, root string, containerd string) *Module {
 		client:     client,
 		// values are cached only for 1 minute. purge cache every 20 second
 		failures: cache.New(time.Minute, 20*time.Second),
+		sessions: newSessions(),
 	}
 
 	if err := module.startup(); err != nil {
@@ -172,6 +175,22 @@ func (c *Module) Run(ns string, data pkg.Container) (id pkg.ContainerID, err err
 		data.Logs = []logger.Logs{}
 	}
 
+	for i := range data.Logs {
+		if data.Logs[i].Type != logger.RedisType {
+			continue
+		}
+
+		if err := data.Logs[i].Data.Valid(); err != nil {
+			return id, errors.Wrap(err, "invalid redis logs backend")
+		}
+
+		// json records are tagged with the container name
+		// unless the caller sets the workload
+		if len(data.Logs[i].Data.Workload) == 0 {
+			data.Logs[i].Data.Workload = data.Name
+		}
+	}
+
 	if data.ShutdownTimeout == 0 {
 		data.ShutdownTimeout = defaultShutdownTimeout
 	}
@@ -385,6 +404,29 @@ func (c *Module) Exec(ns string, containerID string, timeout time.Duration, args
 	}
 	return nil
 }
+
+// Stats returns the current cgroup stats of the container
+func (c *Module) Stats(ns string, id pkg.ContainerID) (pkg.ContainerStats, error) {
+	client, err := containerd.New(c.containerd)
+	if err != nil {
+		return pkg.ContainerStats{}, err
+	}
+	defer client.Close()
+
+	ctx := namespaces.WithNamespace(context.Background(), ns)
+	container, err := client.LoadContainer(ctx, string(id))
+	if err != nil {
+		return pkg.ContainerStats{}, errors.Wrap(err, "couldn't load container")
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return pkg.ContainerStats{}, errors.Wrap(err, "failed to get container task")
+	}
+
+	return stats.Collect(ctx, task)
+}
+
 func (c *Module) ensureTask(ctx context.Context, container containerd.Container) error {
 	uri, err := url.Parse("binary://" + binaryLogsShim)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"os"
 
 	"github.com/containerd/containerd/cio"
 )
@@ -14,14 +15,20 @@ type Loggers struct {
 	stderrs []io.Writer
 }
 
-// Serialize dumps logs array into a json file
+// Serialize dumps logs array into a json file, the file is only readable
+// by its owner since the backends urls can contain credentials
 func Serialize(path string, logs []Logs) error {
 	data, err := json.Marshal(logs)
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(path, data, 0644)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	// write file does not change the mode of an existing file
+	return os.Chmod(path, 0600)
 }
 
 // Deserialize reads json from disks and returns []Logs
@@ -0,0 +1,54 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/threefoldtech/test/pkg/rotate"
+)
+
+// RotateLogs rotates the containers logs until the context is cancelled
+func (c *Module) RotateLogs(ctx context.Context) {
+	rotate.NewLogsService(rotate.Job{
+		Name:  "containers",
+		Files: c.logFiles,
+		Prune: true,
+	}).Run(ctx)
+}
+
+// logFiles returns the logs files of the existing containers in each
+// namespace logs directory, logs of deleted containers are deleted by
+// the rotation
+func (c *Module) logFiles() (map[string][]string, error) {
+	root := filepath.Join(c.root, "logs")
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]string)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		ns := entry.Name()
+		ids, err := c.List(ns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers in namespace '%s': %w", ns, err)
+		}
+
+		names := make([]string, 0, len(ids))
+		for _, id := range ids {
+			names = append(names, fmt.Sprintf("%s.log", id))
+		}
+
+		files[filepath.Join(root, ns)] = names
+	}
+
+	return files, nil
+}
@@ -0,0 +1,370 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+)
+
+const (
+	// defaultSessionTimeout is the max life time of a session that
+	// does not set its own timeout
+	defaultSessionTimeout = time.Hour
+	// outputChunkSize max size of the data of a single output message
+	outputChunkSize = 32 * 1024
+	// exitTimeout is how long we try to publish the exit message of a
+	// session before giving up
+	exitTimeout     = 10 * time.Second
+	defaultLogLines = 100
+)
+
+// session is a running exec process, or a followed container log
+type session struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stdin and process are only set for exec sessions
+	stdin   *io.PipeWriter
+	process containerd.Process
+	tty     bool
+}
+
+// sessions keeps track of the running sessions and publishes their output
+type sessions struct {
+	m      map[string]*session
+	mu     sync.Mutex
+	output chan pkg.ContainerOutput
+}
+
+func newSessions() *sessions {
+	return &sessions{
+		m:      make(map[string]*session),
+		output: make(chan pkg.ContainerOutput, 128),
+	}
+}
+
+func (s *sessions) add(id string, sess *session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[id] = sess
+}
+
+func (s *sessions) get(id string) (*session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.m[id]
+	if !ok {
+		return nil, fmt.Errorf("session '%s' not found", id)
+	}
+
+	return sess, nil
+}
+
+// exit removes the session and publishes its last message
+func (s *sessions) exit(id string, stream string, code int, err error) {
+	s.mu.Lock()
+	delete(s.m, id)
+	s.mu.Unlock()
+
+	out := pkg.ContainerOutput{
+		Session:  id,
+		Stream:   stream,
+		Exited:   true,
+		ExitCode: code,
+	}
+	if err != nil {
+		out.Error = err.Error()
+	}
+
+	select {
+	case s.output <- out:
+	case <-time.After(exitTimeout):
+		log.Error().Str("session", id).Msg("timed out publishing session exit")
+	}
+}
+
+// writer returns a writer that publishes everything written to it as
+// output of the given session stream
+func (s *sessions) writer(ctx context.Context, id, stream string) io.Writer {
+	return &outputWriter{ctx: ctx, session: id, stream: stream, output: s.output}
+}
+
+type outputWriter struct {
+	ctx     context.Context
+	session string
+	stream  string
+	output  chan<- pkg.ContainerOutput
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	for off := 0; off < len(p); off += outputChunkSize {
+		end := off + outputChunkSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		// the caller is free to reuse p once write returns
+		data := make([]byte, end-off)
+		copy(data, p[off:end])
+
+		select {
+		case w.output <- pkg.ContainerOutput{Session: w.session, Stream: w.stream, Data: data}:
+		case <-w.ctx.Done():
+			return off, w.ctx.Err()
+		}
+	}
+
+	return len(p), nil
+}
+
+// ExecStart starts an interactive process in the container
+func (c *Module) ExecStart(ns string, id pkg.ContainerID, req pkg.ExecRequest) (string, error) {
+	if len(req.Args) == 0 {
+		return "", fmt.Errorf("no command to execute")
+	}
+
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultSessionTimeout
+	}
+
+	client, err := containerd.New(c.containerd)
+	if err != nil {
+		return "", err
+	}
+
+	// the client and the context are kept for the whole session
+	// life time and released once the process exits
+	ctx, cancel := context.WithTimeout(namespaces.WithNamespace(context.Background(), ns), timeout)
+	release := func() {
+		cancel()
+		client.Close()
+	}
+
+	process, stdin, wait, err := c.execStart(ctx, client, id, req)
+	if err != nil {
+		release()
+		return "", err
+	}
+
+	sessionID := process.ID()
+	c.sessions.add(sessionID, &session{
+		ctx:     ctx,
+		cancel:  cancel,
+		stdin:   stdin,
+		process: process,
+		tty:     req.TTY,
+	})
+
+	go func() {
+		defer release()
+		// unblocks any pending input
+		defer stdin.Close()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+		}
+
+		// delete waits for the process output to be flushed
+		deleteCtx, deleteCancel := context.WithTimeout(namespaces.WithNamespace(context.Background(), ns), 5*time.Second)
+		defer deleteCancel()
+
+		code := -1
+		status, err := process.Delete(deleteCtx, containerd.WithProcessKill)
+		if err != nil {
+			err = errors.Wrap(err, "failed to delete process")
+		} else {
+			code = int(status.ExitCode())
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				err = errors.New("execution timed out")
+			}
+		}
+
+		c.sessions.exit(sessionID, pkg.OutputStdout, code, err)
+	}()
+
+	return sessionID, nil
+}
+
+func (c *Module) execStart(ctx context.Context, client *containerd.Client, id pkg.ContainerID, req pkg.ExecRequest) (containerd.Process, *io.PipeWriter, <-chan containerd.ExitStatus, error) {
+	container, err := client.LoadContainer(ctx, string(id))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "couldn't load container")
+	}
+
+	spec, err := container.Spec(ctx)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get container spec")
+	}
+
+	task, err := container.Task(ctx, nil)
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to get container task")
+	}
+
+	// the process inherits the container environment and user
+	p := *spec.Process
+	p.Args = req.Args
+	p.Env = append(append([]string{}, p.Env...), req.Env...)
+	p.Terminal = req.TTY
+	if len(p.Cwd) == 0 {
+		p.Cwd = "/"
+	}
+
+	sessionID := uuid.New().String()
+	stdinReader, stdin := io.Pipe()
+	opts := []cio.Opt{
+		cio.WithStreams(
+			stdinReader,
+			c.sessions.writer(ctx, sessionID, pkg.OutputStdout),
+			c.sessions.writer(ctx, sessionID, pkg.OutputStderr),
+		),
+	}
+	if req.TTY {
+		opts = append(opts, cio.WithTerminal)
+	}
+
+	process, err := task.Exec(ctx, sessionID, &p, cio.NewCreator(opts...))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to exec new process")
+	}
+
+	// wait must be called before start to not miss the process exit
+	wait, err := process.Wait(ctx)
+	if err != nil {
+		_, _ = process.Delete(ctx, containerd.WithProcessKill)
+		return nil, nil, nil, errors.Wrap(err, "failed to wait for process")
+	}
+
+	if err := process.Start(ctx); err != nil {
+		_, _ = process.Delete(ctx, containerd.WithProcessKill)
+		return nil, nil, nil, errors.Wrap(err, "failed to start process")
+	}
+
+	if req.TTY && req.Cols > 0 && req.Rows > 0 {
+		if err := process.Resize(ctx, uint32(req.Cols), uint32(req.Rows)); err != nil {
+			log.Error().Err(err).Str("session", sessionID).Msg("failed to set session terminal size")
+		}
+	}
+
+	return process, stdin, wait, nil
+}
+
+// LogsFollow follows the container logs
+func (c *Module) LogsFollow(ns string, id pkg.ContainerID, lines int) (string, error) {
+	if lines <= 0 {
+		lines = defaultLogLines
+	}
+
+	path := filepath.Join(c.root, "logs", ns, fmt.Sprintf("%s.log", id))
+	if _, err := os.Stat(path); err != nil {
+		return "", errors.Wrap(err, "failed to find container logs")
+	}
+
+	sessionID := uuid.New().String()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultSessionTimeout)
+
+	// -F keeps following the log file if it gets rotated
+	cmd := exec.CommandContext(ctx, "tail", "-n", strconv.Itoa(lines), "-F", path)
+	cmd.Stdout = c.sessions.writer(ctx, sessionID, pkg.OutputLogs)
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return "", errors.Wrap(err, "failed to follow container logs")
+	}
+
+	c.sessions.add(sessionID, &session{ctx: ctx, cancel: cancel})
+
+	go func() {
+		defer cancel()
+		err := cmd.Wait()
+		if ctx.Err() != nil {
+			// the session was closed or timed out
+			err = nil
+		}
+
+		c.sessions.exit(sessionID, pkg.OutputLogs, cmd.ProcessState.ExitCode(), err)
+	}()
+
+	return sessionID, nil
+}
+
+// SessionInput writes data to the session process stdin
+func (c *Module) SessionInput(id string, data []byte) error {
+	sess, err := c.sessions.get(id)
+	if err != nil {
+		return err
+	}
+
+	if sess.stdin == nil {
+		return fmt.Errorf("session '%s' has no input", id)
+	}
+
+	if len(data) == 0 {
+		_ = sess.stdin.Close()
+		return sess.process.CloseIO(sess.ctx, containerd.WithStdinCloser)
+	}
+
+	_, err = sess.stdin.Write(data)
+	return err
+}
+
+// SessionResize sets the session terminal size
+func (c *Module) SessionResize(id string, cols, rows uint16) error {
+	sess, err := c.sessions.get(id)
+	if err != nil {
+		return err
+	}
+
+	if !sess.tty {
+		return fmt.Errorf("session '%s' has no terminal", id)
+	}
+
+	return sess.process.Resize(sess.ctx, uint32(cols), uint32(rows))
+}
+
+// SessionClose closes the session
+func (c *Module) SessionClose(id string) error {
+	sess, err := c.sessions.get(id)
+	if err != nil {
+		return err
+	}
+
+	sess.cancel()
+	return nil
+}
+
+// Output streams the output of all the sessions
+func (c *Module) Output(ctx context.Context) <-chan pkg.ContainerOutput {
+	ch := make(chan pkg.ContainerOutput)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case out := <-c.sessions.output:
+				select {
+				case ch <- out:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,60 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestOutputWriter(t *testing.T) {
+	sessions := newSessions()
+	sessions.output = make(chan pkg.ContainerOutput, 10)
+
+	w := sessions.writer(context.Background(), "session", pkg.OutputStderr)
+	data := bytes.Repeat([]byte("a"), outputChunkSize*2+10)
+	n, err := w.Write(data)
+	require.NoError(t, err)
+	require.Equal(t, len(data), n)
+
+	close(sessions.output)
+	var received []byte
+	var chunks int
+	for out := range sessions.output {
+		require.Equal(t, "session", out.Session)
+		require.Equal(t, pkg.OutputStderr, out.Stream)
+		require.LessOrEqual(t, len(out.Data), outputChunkSize)
+		received = append(received, out.Data...)
+		chunks++
+	}
+
+	require.Equal(t, 3, chunks)
+	require.Equal(t, data, received)
+}
+
+func TestOutputWriterCancelled(t *testing.T) {
+	sessions := newSessions()
+	sessions.output = make(chan pkg.ContainerOutput)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := sessions.writer(ctx, "session", pkg.OutputStdout)
+	_, err := w.Write([]byte("data"))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSessionExit(t *testing.T) {
+	sessions := newSessions()
+	sessions.add("session", &session{})
+
+	go sessions.exit("session", pkg.OutputLogs, 2, nil)
+	out := <-sessions.output
+	require.True(t, out.Exited)
+	require.Equal(t, 2, out.ExitCode)
+
+	_, err := sessions.get("session")
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	v1 "github.com/containerd/cgroups/stats/v1"
@@ -12,6 +13,7 @@ import (
 	"github.com/containerd/containerd/namespaces"
 	"github.com/containerd/typeurl"
 	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
 )
 
 // StatsPushInterval defines how many times we push metrics
@@ -77,38 +79,95 @@ func Monitor(addr string, ns string, id string, backend io.WriteCloser) error {
 }
 
 func monitor(ctx context.Context, task containerd.Task) ([]byte, error) {
-	metric, err := task.Metrics(ctx)
+	stats, err := Collect(ctx, task)
 	if err != nil {
 		log.Error().Err(err).Msg("metrics")
 		return nil, err
 	}
 
-	anydata, err := typeurl.UnmarshalAny(metric.Data)
+	s := &Metrics{
+		Timestamp:   stats.Timestamp,
+		MemoryUsage: stats.MemoryUsage,
+		MemoryLimit: stats.MemoryLimit,
+		MemoryCache: stats.MemoryCache,
+		CPUUsage:    stats.CPUUsage,
+		PidsCurrent: stats.PidsCurrent,
+	}
+
+	b, err := json.Marshal(s)
 	if err != nil {
 		return nil, err
 	}
 
-	var data *v1.Metrics
-	switch v := anydata.(type) {
-	case *v1.Metrics:
-		data = v
-	default:
-		return nil, fmt.Errorf("wrong metric type")
-	}
+	return b, nil
+}
 
-	s := &Metrics{
-		Timestamp:   metric.Timestamp.Unix(),
-		MemoryUsage: data.Memory.Usage.Usage,
-		MemoryLimit: data.Memory.Usage.Limit,
-		MemoryCache: data.Memory.TotalCache,
-		CPUUsage:    data.CPU.Usage.Total,
-		PidsCurrent: data.Pids.Current,
+// Collect reads the current cgroup stats of the container task
+func Collect(ctx context.Context, task containerd.Task) (pkg.ContainerStats, error) {
+	metric, err := task.Metrics(ctx)
+	if err != nil {
+		return pkg.ContainerStats{}, err
 	}
 
-	b, err := json.Marshal(s)
+	anydata, err := typeurl.UnmarshalAny(metric.Data)
 	if err != nil {
-		return nil, err
+		return pkg.ContainerStats{}, err
 	}
 
-	return b, nil
+	data, ok := anydata.(*v1.Metrics)
+	if !ok {
+		return pkg.ContainerStats{}, fmt.Errorf("wrong metric type")
+	}
+
+	return fromMetrics(metric.Timestamp.Unix(), data), nil
+}
+
+// fromMetrics converts cgroup v1 metrics, any of the metrics can be missing
+// if the controller is not enabled
+func fromMetrics(timestamp int64, data *v1.Metrics) pkg.ContainerStats {
+	stats := pkg.ContainerStats{Timestamp: timestamp}
+
+	if cpu := data.CPU; cpu != nil {
+		if cpu.Usage != nil {
+			stats.CPUUsage = cpu.Usage.Total
+		}
+		if cpu.Throttling != nil {
+			stats.CPUThrottled = cpu.Throttling.ThrottledPeriods
+		}
+	}
+
+	if memory := data.Memory; memory != nil {
+		stats.MemoryCache = memory.TotalCache
+		if memory.Usage != nil {
+			stats.MemoryUsage = memory.Usage.Usage
+			stats.MemoryLimit = memory.Usage.Limit
+			stats.MemoryLimitHits = memory.Usage.Failcnt
+		}
+		// same as the working set reported by cadvisor
+		if stats.MemoryUsage > memory.TotalInactiveFile {
+			stats.MemoryWorkingSet = stats.MemoryUsage - memory.TotalInactiveFile
+		}
+	}
+
+	if oom := data.MemoryOomControl; oom != nil {
+		stats.OOMKills = oom.OomKill
+	}
+
+	if pids := data.Pids; pids != nil {
+		stats.PidsCurrent = pids.Current
+		stats.PidsLimit = pids.Limit
+	}
+
+	if blkio := data.Blkio; blkio != nil {
+		for _, entry := range blkio.IoServiceBytesRecursive {
+			switch strings.ToLower(entry.Op) {
+			case "read":
+				stats.BlockRead += entry.Value
+			case "write":
+				stats.BlockWrite += entry.Value
+			}
+		}
+	}
+
+	return stats
 }
@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"testing"
+
+	v1 "github.com/containerd/cgroups/stats/v1"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestFromMetrics(t *testing.T) {
+	require := require.New(t)
+
+	metrics := &v1.Metrics{
+		CPU: &v1.CPUStat{
+			Usage:      &v1.CPUUsage{Total: 100},
+			Throttling: &v1.Throttle{ThrottledPeriods: 3},
+		},
+		Memory: &v1.MemoryStat{
+			TotalCache:        600,
+			TotalInactiveFile: 400,
+			Usage:             &v1.MemoryEntry{Usage: 1000, Limit: 2000, Failcnt: 5},
+		},
+		MemoryOomControl: &v1.MemoryOomControl{OomKill: 1},
+		Pids:             &v1.PidsStat{Current: 10, Limit: 20},
+		Blkio: &v1.BlkIOStat{
+			IoServiceBytesRecursive: []*v1.BlkIOEntry{
+				{Op: "Read", Value: 10},
+				{Op: "Write", Value: 20},
+				{Op: "read", Value: 1},
+				{Op: "Total", Value: 31},
+			},
+		},
+	}
+
+	require.Equal(pkg.ContainerStats{
+		Timestamp:        42,
+		CPUUsage:         100,
+		CPUThrottled:     3,
+		MemoryUsage:      1000,
+		MemoryLimit:      2000,
+		MemoryCache:      600,
+		MemoryWorkingSet: 600,
+		MemoryLimitHits:  5,
+		OOMKills:         1,
+		PidsCurrent:      10,
+		PidsLimit:        20,
+		BlockRead:        11,
+		BlockWrite:       20,
+	}, fromMetrics(42, metrics))
+}
+
+func TestFromMetricsMissing(t *testing.T) {
+	require := require.New(t)
+
+	// controllers that are not enabled are not reported
+	require.Equal(pkg.ContainerStats{Timestamp: 42}, fromMetrics(42, &v1.Metrics{}))
+
+	// usage without inactive file cache
+	stats := fromMetrics(42, &v1.Metrics{
+		Memory: &v1.MemoryStat{
+			TotalInactiveFile: 400,
+			Usage:             &v1.MemoryEntry{Usage: 100},
+		},
+	})
+	require.Equal(uint64(0), stats.MemoryWorkingSet)
+}
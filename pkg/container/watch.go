@@ -76,6 +76,13 @@ func (c *Module) handleEvent(ctx context.Context, ns string, event interface{})
 		// - this method does not return any useful value anyway, so safe to run
 		//   it in the background.
 		go c.handlerEventTaskExit(ctx, ns, event)
+	case *events.TaskOOM:
+		// the oom kills are also counted in the container stats, which
+		// are reported to the workload owner
+		log.Warn().
+			Str("namespace", ns).
+			Str("container", event.ContainerID).
+			Msg("container ran out of memory")
 	default:
 		log.Debug().Msgf("unhandled event: %+v", event)
 	}
@@ -118,7 +125,7 @@ func (c *Module) watch(ctx context.Context) error {
 // Blocks forever. caller need to run this in a go routine
 //
 // different events types are handled differently. Now, only
-// TaskExit event is handled.
+// TaskExit and TaskOOM events are handled.
 func (c *Module) Watch(ctx context.Context) {
 	for {
 		err := c.watch(ctx)
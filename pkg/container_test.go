@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerLimits(t *testing.T) {
+	require := require.New(t)
+
+	stats := ContainerStats{
+		Timestamp:        10,
+		MemoryUsage:      1000,
+		MemoryLimit:      1000,
+		MemoryCache:      800,
+		MemoryWorkingSet: 200,
+		MemoryLimitHits:  42,
+		PidsCurrent:      10,
+		PidsLimit:        100,
+	}
+
+	// page cache reclaims are not reported as limit hits
+	limits := stats.Limits()
+	require.Equal(ContainerLimits{Updated: 10}, limits)
+	require.False(limits.Hit())
+
+	stats.MemoryWorkingSet = 950
+	limits = stats.Limits()
+	require.True(limits.MemoryLimitHit)
+	require.True(limits.Hit())
+
+	stats.MemoryWorkingSet = 200
+	stats.OOMKills = 1
+	limits = stats.Limits()
+	require.Equal(uint64(1), limits.OOMKills)
+	require.True(limits.Hit())
+
+	stats.OOMKills = 0
+	stats.PidsCurrent = 100
+	limits = stats.Limits()
+	require.True(limits.PidsLimitHit)
+	require.True(limits.Hit())
+
+	// no limits set
+	stats = ContainerStats{MemoryWorkingSet: 100, PidsCurrent: 100}
+	limits = stats.Limits()
+	require.False(limits.Hit())
+}
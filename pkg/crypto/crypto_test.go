@@ -24,6 +24,33 @@ func TestPublicKeyEncryption(t *testing.T) {
 	assert.Equal(t, clear, msg)
 }
 
+func TestX25519Encryption(t *testing.T) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPk, otherSk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	msg := []byte("hello world")
+
+	cipher, err := Encrypt(msg, pk)
+	require.NoError(t, err)
+	clear, err := DecryptX25519(cipher, pk, LocalX25519(sk))
+	require.NoError(t, err)
+	assert.Equal(t, msg, clear)
+
+	cipher, err = EncryptECDHX25519(msg, LocalX25519(sk), otherPk)
+	require.NoError(t, err)
+	clear, err = DecryptECDH(cipher, otherSk, pk)
+	require.NoError(t, err)
+	assert.Equal(t, msg, clear)
+
+	cipher, err = EncryptECDH(msg, otherSk, pk)
+	require.NoError(t, err)
+	clear, err = DecryptECDHX25519(cipher, LocalX25519(sk), otherPk)
+	require.NoError(t, err)
+	assert.Equal(t, msg, clear)
+}
+
 func TestSignature(t *testing.T) {
 	pk, sk, err := ed25519.GenerateKey(rand.Reader)
 	require.NoError(t, err)
@@ -133,3 +160,26 @@ func TestECDHPyNACLCompatibility(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, []byte("hello world"), decrypted)
 }
+
+func TestVerifyAnyHex(t *testing.T) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPk, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys, err := KeysFromHex([]string{hex.EncodeToString(otherPk), hex.EncodeToString(pk)})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	msg := []byte("hello world")
+	sig := []byte(hex.EncodeToString(ed25519.Sign(sk, msg)) + "\n")
+
+	assert.NoError(t, VerifyAnyHex(keys, msg, sig))
+	assert.Error(t, VerifyAnyHex(keys[:1], msg, sig))
+	assert.Error(t, VerifyAnyHex(keys, []byte("hello"), sig))
+	assert.Error(t, VerifyAnyHex(keys, msg, []byte("not hex")))
+	assert.Error(t, VerifyAnyHex(nil, msg, sig))
+
+	_, err = KeysFromHex([]string{"abcd"})
+	assert.Error(t, err)
+}
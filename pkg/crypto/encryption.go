@@ -9,9 +9,23 @@ import (
 	box "github.com/whs/nacl-sealed-box"
 	"golang.org/x/crypto/blake2b"
 	"golang.org/x/crypto/curve25519"
+	naclbox "golang.org/x/crypto/nacl/box"
 	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/salsa20/salsa"
 )
 
+// X25519 multiplies point with the curve25519 form of a private key. It's
+// used instead of the private key when the key is kept by an external signer
+type X25519 func(point []byte) ([]byte, error)
+
+// LocalX25519 returns the X25519 function of an in memory private key
+func LocalX25519(sk ed25519.PrivateKey) X25519 {
+	return func(point []byte) ([]byte, error) {
+		private := PrivateKeyToCurve25519(sk)
+		return curve25519.X25519(private[:], point)
+	}
+}
+
 // Encrypt encrypts msg with a cure25519 public key derived from an ed25519 public key
 func Encrypt(msg []byte, pk ed25519.PublicKey) ([]byte, error) {
 	curvePub := PublicKeyToCurve25519(pk)
@@ -100,3 +114,92 @@ func sharedSecret(sk ed25519.PrivateKey, pk ed25519.PublicKey) ([32]byte, error)
 
 	return blake2b.Sum256(shareSecret), nil
 }
+
+// DecryptX25519 is the same as Decrypt but the private key operation is
+// done by x, pk is the public key of the private key used by x
+func DecryptX25519(msg []byte, pk ed25519.PublicKey, x X25519) ([]byte, error) {
+	if len(msg) < 48 {
+		return nil, fmt.Errorf("invalid cipher text too short")
+	}
+
+	curvePub := PublicKeyToCurve25519(pk)
+	var ephemeral [32]byte
+	copy(ephemeral[:], msg[:32])
+
+	shared, err := x(ephemeral[:])
+	if err != nil {
+		return nil, err
+	}
+
+	// same as box.Precompute
+	var input, key [32]byte
+	var zeros [16]byte
+	copy(input[:], shared)
+	salsa.HSalsa20(&key, &zeros, &input, &salsa.Sigma)
+
+	// the nonce of a sealed box is blake2b(ephemeral public key, public key)
+	hasher, err := blake2b.New(24, nil)
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(ephemeral[:])
+	hasher.Write(curvePub[:])
+
+	var nonce [24]byte
+	copy(nonce[:], hasher.Sum(nil))
+
+	decrypted, ok := naclbox.OpenAfterPrecomputation(nil, msg[32:], &nonce, &key)
+	if !ok {
+		return nil, fmt.Errorf("decryption error")
+	}
+
+	return decrypted, nil
+}
+
+// DecryptECDHX25519 is the same as DecryptECDH but the private key operation is done by x
+func DecryptECDHX25519(msg []byte, x X25519, pk ed25519.PublicKey) ([]byte, error) {
+	key, err := sharedSecretX25519(x, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(msg) < 24 {
+		return nil, fmt.Errorf("invalid cipher text too short")
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], msg[:24])
+
+	decrypted, ok := secretbox.Open(nil, msg[24:], &nonce, &key)
+	if !ok {
+		return nil, fmt.Errorf("decryption error")
+	}
+
+	return decrypted, nil
+}
+
+// EncryptECDHX25519 is the same as EncryptECDH but the private key operation is done by x
+func EncryptECDHX25519(msg []byte, x X25519, pk ed25519.PublicKey) ([]byte, error) {
+	key, err := sharedSecretX25519(x, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [24]byte
+	if _, err = rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	return secretbox.Seal(nonce[:], msg, &nonce, &key), nil
+}
+
+func sharedSecretX25519(x X25519, pk ed25519.PublicKey) ([32]byte, error) {
+	public := PublicKeyToCurve25519(pk)
+
+	shareSecret, err := x(public[:])
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return blake2b.Sum256(shareSecret), nil
+}
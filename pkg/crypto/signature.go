@@ -1,7 +1,9 @@
 package crypto
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"golang.org/x/crypto/ed25519"
 )
@@ -17,6 +19,38 @@ func Verify(publicKey ed25519.PublicKey, message, sig []byte) error {
 	return nil
 }
 
+// KeysFromHex parses a list of hex encoded public keys
+func KeysFromHex(keys []string) ([]ed25519.PublicKey, error) {
+	var parsed []ed25519.PublicKey
+	for _, key := range keys {
+		pk, err := KeyFromHex(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key '%s'", key)
+		}
+		parsed = append(parsed, pk)
+	}
+
+	return parsed, nil
+}
+
+// VerifyAnyHex reports whether the hex encoded signature sig is a valid
+// signature of message by one of publicKeys. Surrounding white spaces of
+// sig are ignored so a signature file can end with a new line.
+func VerifyAnyHex(publicKeys []ed25519.PublicKey, message, sig []byte) error {
+	signature, err := hex.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid hex format")
+	}
+
+	for _, pk := range publicKeys {
+		if Verify(pk, message, signature) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("signature verification failed")
+}
+
 // Sign signs the message with privateKey and returns a signature.
 func Sign(privateKey ed25519.PrivateKey, message []byte) ([]byte, error) {
 	if len(privateKey) != ed25519.PrivateKeySize {
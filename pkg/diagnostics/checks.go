@@ -0,0 +1,483 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/network/bridge"
+	"github.com/threefoldtech/test/pkg/network/mycelium"
+	"github.com/threefoldtech/test/pkg/network/namespace"
+	"github.com/threefoldtech/test/pkg/network/types"
+	"github.com/threefoldtech/test/pkg/network/yggdrasil"
+	"github.com/threefoldtech/test/pkg/stubs"
+	"github.com/threefoldtech/test/pkg/zinit"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	// poolWarningUsage and poolCriticalUsage are the pool usage percentages
+	// where a finding is reported
+	poolWarningUsage  = 85
+	poolCriticalUsage = 95
+
+	// clockWarningSkew and clockCriticalSkew are the clock skews where
+	// a finding is reported
+	clockWarningSkew  = time.Minute
+	clockCriticalSkew = 10 * time.Minute
+
+	flistMountpoints = "/var/cache/modules/flistd/mountpoint"
+	dmzNamespace     = "ndmz"
+)
+
+var (
+	// checkTimeout is how long a check can run. Some checks can block
+	// forever (a stat on a dead fuse mount for example), so checks that
+	// don't return in time are reported as timed out and left behind
+	checkTimeout = 30 * time.Second
+)
+
+// Severity of a diagnostics finding
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Finding is a problem found by a diagnostics check
+type Finding struct {
+	// Subsystem is the subsystem of the check (storage, network, etc...)
+	Subsystem string `json:"subsystem"`
+	// Check is the name of the check that reported the finding
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Remediation is a hint on how to fix the problem
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Check is a diagnostics check of a subsystem
+type Check interface {
+	// Subsystem of the check
+	Subsystem() string
+	// Name of the check
+	Name() string
+	// Run the check and return the findings, a check that fails to
+	// run returns an error
+	Run(ctx context.Context) ([]Finding, error)
+}
+
+// CheckFunc is a Check implemented by a function
+type CheckFunc struct {
+	subsystem string
+	name      string
+	fn        func(ctx context.Context) ([]Finding, error)
+}
+
+// NewCheck creates a new check from a function
+func NewCheck(subsystem, name string, fn func(ctx context.Context) ([]Finding, error)) Check {
+	return &CheckFunc{subsystem: subsystem, name: name, fn: fn}
+}
+
+func (c *CheckFunc) Subsystem() string {
+	return c.subsystem
+}
+
+func (c *CheckFunc) Name() string {
+	return c.name
+}
+
+func (c *CheckFunc) Run(ctx context.Context) ([]Finding, error) {
+	return c.fn(ctx)
+}
+
+// DefaultChecks returns the default node checks
+func DefaultChecks(cl zbus.Client) []Check {
+	return []Check{
+		NewCheck("storage", "pools", func(ctx context.Context) ([]Finding, error) {
+			return storagePoolsCheck(ctx, cl)
+		}),
+		NewCheck("network", "bridges", networkBridgesCheck),
+		NewCheck("network", "planetary", planetaryCheck),
+		NewCheck("flist", "mounts", flistMountsCheck),
+		NewCheck("vm", "processes", func(ctx context.Context) ([]Finding, error) {
+			return vmProcessesCheck(ctx, cl)
+		}),
+		NewCheck("system", "clock", func(ctx context.Context) ([]Finding, error) {
+			return clockCheck(ctx, cl)
+		}),
+	}
+}
+
+// checkResult is the findings of the check at index
+type checkResult struct {
+	index    int
+	findings []Finding
+}
+
+// runChecks runs all the checks in parallel and returns the findings sorted
+// by severity (critical first). A check that does not return in checkTimeout
+// (or before ctx is done) is reported with a timed out finding
+func runChecks(ctx context.Context, checks []Check) []Finding {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	// buffered so checks that return after the timeout don't block forever
+	ch := make(chan checkResult, len(checks))
+	for i, check := range checks {
+		go func(i int, check Check) {
+			findings, err := check.Run(ctx)
+			if err != nil {
+				findings = append(findings, Finding{
+					Severity:    SeverityWarning,
+					Message:     fmt.Sprintf("check failed to run: %s", err),
+					Remediation: "make sure the responsible module is running",
+				})
+			}
+
+			ch <- checkResult{index: i, findings: findings}
+		}(i, check)
+	}
+
+	results := make([][]Finding, len(checks))
+	finished := make([]bool, len(checks))
+wait:
+	for pending := len(checks); pending > 0; pending-- {
+		select {
+		case result := <-ch:
+			results[result.index] = result.findings
+			finished[result.index] = true
+		case <-ctx.Done():
+			break wait
+		}
+	}
+
+	for i, check := range checks {
+		if !finished[i] {
+			results[i] = []Finding{{
+				Severity:    SeverityWarning,
+				Message:     fmt.Sprintf("check timed out after %s", checkTimeout),
+				Remediation: "the check is blocked, the responsible module or a mount may be stuck",
+			}}
+		}
+
+		for j := range results[i] {
+			results[i][j].Subsystem = check.Subsystem()
+			results[i][j].Check = check.Name()
+		}
+	}
+
+	findings := make([]Finding, 0)
+	for _, result := range results {
+		findings = append(findings, result...)
+	}
+
+	rank := map[Severity]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return rank[findings[i].Severity] < rank[findings[j].Severity]
+	})
+
+	return findings
+}
+
+// usageSeverity returns the severity of the pool usage, empty if the
+// usage is fine
+func usageSeverity(used, size gridtypes.Unit) Severity {
+	if size == 0 {
+		return ""
+	}
+
+	usage := used * 100 / size
+	switch {
+	case usage >= poolCriticalUsage:
+		return SeverityCritical
+	case usage >= poolWarningUsage:
+		return SeverityWarning
+	default:
+		return ""
+	}
+}
+
+func storagePoolsCheck(ctx context.Context, cl zbus.Client) ([]Finding, error) {
+	storage := stubs.NewStorageModuleStub(cl)
+
+	var findings []Finding
+	for _, pool := range storage.BrokenPools(ctx) {
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("storage pool '%s' is broken: %s", pool.Label, pool.Err),
+			Remediation: "check the disk health and cables, replace the disk if it keeps failing",
+		})
+	}
+
+	for _, device := range storage.BrokenDevices(ctx) {
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("disk '%s' is broken: %s", device.Path, device.Err),
+			Remediation: "check the disk health and cables, replace the disk if it keeps failing",
+		})
+	}
+
+	pools, err := storage.Metrics(ctx)
+	if err != nil {
+		return findings, errors.Wrap(err, "failed to get pools metrics")
+	}
+
+	for _, pool := range pools {
+		severity := usageSeverity(pool.Used, pool.Size)
+		if len(severity) == 0 {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity:    severity,
+			Message:     fmt.Sprintf("storage pool '%s' (%s) is %d%% used", pool.Name, pool.Type, pool.Used*100/pool.Size),
+			Remediation: "add more disks to the node or stop accepting new workloads",
+		})
+	}
+
+	return findings, nil
+}
+
+func networkBridgesCheck(ctx context.Context) ([]Finding, error) {
+	var findings []Finding
+	for _, name := range []string{types.DefaultBridge, types.YggBridge, types.MyceliumBridge} {
+		if bridge.Exists(name) {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("bridge '%s' does not exist", name),
+			Remediation: "restart networkd, reboot the node if the problem persists",
+		})
+	}
+
+	if !namespace.Exists(dmzNamespace) {
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("network namespace '%s' does not exist", dmzNamespace),
+			Remediation: "restart networkd, reboot the node if the problem persists",
+		})
+	}
+
+	return findings, nil
+}
+
+// planetaryCheck checks that yggdrasil and mycelium are running and have
+// an address from their networks
+func planetaryCheck(ctx context.Context) ([]Finding, error) {
+	services := []struct {
+		name    string
+		network net.IPNet
+	}{
+		{name: "yggdrasil", network: yggdrasil.YggRange},
+		{name: "mycelium", network: mycelium.MyRange},
+	}
+
+	init := zinit.Default()
+
+	var findings []Finding
+	for _, service := range services {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+
+		status, err := init.Status(service.name)
+		if err != nil {
+			return findings, errors.Wrapf(err, "failed to get '%s' service status", service.name)
+		}
+
+		if !status.State.Is(zinit.ServiceStateRunning) {
+			findings = append(findings, Finding{
+				Severity:    SeverityCritical,
+				Message:     fmt.Sprintf("%s service is not running (%s)", service.name, status.State.String()),
+				Remediation: fmt.Sprintf("restart the %s service", service.name),
+			})
+			continue
+		}
+
+		connected, err := hasAddressIn(service.network, dmzNamespace, types.PublicNamespace)
+		if err != nil {
+			return findings, err
+		}
+
+		if !connected {
+			findings = append(findings, Finding{
+				Severity:    SeverityWarning,
+				Message:     fmt.Sprintf("%s has no address, the node is not connected to the %s network", service.name, service.name),
+				Remediation: fmt.Sprintf("make sure the node can reach the %s peers (firewall rules)", service.name),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// hasAddressIn checks if any of the interfaces in the given namespaces has an
+// address in the network
+func hasAddressIn(network net.IPNet, namespaces ...string) (bool, error) {
+	found := false
+	for _, name := range namespaces {
+		if !namespace.Exists(name) {
+			continue
+		}
+
+		netNS, err := namespace.GetByName(name)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to get namespace '%s'", name)
+		}
+
+		err = netNS.Do(func(_ ns.NetNS) error {
+			addrs, err := netlink.AddrList(nil, netlink.FAMILY_V6)
+			if err != nil {
+				return err
+			}
+
+			for _, addr := range addrs {
+				if network.Contains(addr.IP) {
+					found = true
+					break
+				}
+			}
+
+			return nil
+		})
+		netNS.Close()
+
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to list addresses in namespace '%s'", name)
+		}
+
+		if found {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// flistMountsCheck checks that all flist mounts are still served
+func flistMountsCheck(ctx context.Context) ([]Finding, error) {
+	entries, err := os.ReadDir(flistMountpoints)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to list flist mounts")
+	}
+
+	var findings []Finding
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+
+		// a mount with a dead fuse process fails with
+		// transport endpoint is not connected
+		if _, err := os.Stat(filepath.Join(flistMountpoints, entry.Name())); err != nil {
+			findings = append(findings, Finding{
+				Severity:    SeverityCritical,
+				Message:     fmt.Sprintf("flist mount '%s' is not accessible: %s", entry.Name(), err),
+				Remediation: "restart the workload using the flist, or restart flistd",
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// vmProcessesCheck compares the running virtual machines with the active
+// vm workloads
+func vmProcessesCheck(ctx context.Context, cl zbus.Client) ([]Finding, error) {
+	activity, err := stubs.NewProvisionStub(cl).Activity(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list active workloads")
+	}
+
+	machines, err := stubs.NewVMModuleStub(cl).List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list virtual machines")
+	}
+
+	running := make(map[string]struct{})
+	for _, name := range machines {
+		running[name] = struct{}{}
+	}
+
+	var findings []Finding
+	expected := make(map[string]struct{})
+	for _, wl := range activity.Workloads {
+		if wl.Type != test.ZMachineType {
+			continue
+		}
+
+		id, err := gridtypes.NewWorkloadID(wl.Twin, wl.Contract, wl.Name)
+		if err != nil {
+			continue
+		}
+
+		expected[id.String()] = struct{}{}
+		if _, ok := running[id.String()]; ok {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity:    SeverityCritical,
+			Message:     fmt.Sprintf("virtual machine '%s' is active but not running", id),
+			Remediation: "check the vmd logs, the machine is restarted automatically by vmd monitor",
+		})
+	}
+
+	for _, name := range machines {
+		if _, ok := expected[name]; ok {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity:    SeverityWarning,
+			Message:     fmt.Sprintf("virtual machine '%s' is running without an active workload", name),
+			Remediation: "the machine is not managed by any deployment, restart the node to clean it up",
+		})
+	}
+
+	return findings, nil
+}
+
+func clockCheck(ctx context.Context, cl zbus.Client) ([]Finding, error) {
+	now, err := stubs.NewSubstrateGatewayStub(cl).GetTime(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get chain time")
+	}
+
+	skew := time.Since(now)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	var severity Severity
+	switch {
+	case skew >= clockCriticalSkew:
+		severity = SeverityCritical
+	case skew >= clockWarningSkew:
+		severity = SeverityWarning
+	default:
+		return nil, nil
+	}
+
+	return []Finding{{
+		Severity:    severity,
+		Message:     fmt.Sprintf("node clock is off by %s from chain time", skew.Round(time.Second)),
+		Remediation: "make sure the node can reach the ntp servers, ntp is restarted by the healthcheck task",
+	}}, nil
+}
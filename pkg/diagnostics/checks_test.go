@@ -0,0 +1,83 @@
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+func TestRunChecks(t *testing.T) {
+	require := require.New(t)
+
+	checks := []Check{
+		NewCheck("storage", "pools", func(ctx context.Context) ([]Finding, error) {
+			return []Finding{{Severity: SeverityWarning, Message: "pool is almost full"}}, nil
+		}),
+		NewCheck("network", "bridges", func(ctx context.Context) ([]Finding, error) {
+			return nil, nil
+		}),
+		NewCheck("vm", "processes", func(ctx context.Context) ([]Finding, error) {
+			return []Finding{{Severity: SeverityCritical, Message: "vm is not running"}}, nil
+		}),
+		NewCheck("system", "clock", func(ctx context.Context) ([]Finding, error) {
+			return nil, fmt.Errorf("chain is not reachable")
+		}),
+	}
+
+	findings := runChecks(context.Background(), checks)
+	require.Len(findings, 3)
+
+	require.Equal(SeverityCritical, findings[0].Severity)
+	require.Equal("vm", findings[0].Subsystem)
+	require.Equal("processes", findings[0].Check)
+
+	require.Equal("storage", findings[1].Subsystem)
+	require.Equal("system", findings[2].Subsystem)
+	require.Contains(findings[2].Message, "chain is not reachable")
+
+	require.NotNil(runChecks(context.Background(), nil))
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+	require := require.New(t)
+
+	timeout := checkTimeout
+	checkTimeout = 100 * time.Millisecond
+	defer func() { checkTimeout = timeout }()
+
+	// a check that ignores ctx and never returns
+	block := make(chan struct{})
+	defer close(block)
+
+	checks := []Check{
+		NewCheck("flist", "mounts", func(ctx context.Context) ([]Finding, error) {
+			<-block
+			return nil, nil
+		}),
+		NewCheck("storage", "pools", func(ctx context.Context) ([]Finding, error) {
+			return []Finding{{Severity: SeverityCritical, Message: "pool is broken"}}, nil
+		}),
+	}
+
+	findings := runChecks(context.Background(), checks)
+	require.Len(findings, 2)
+
+	require.Equal("storage", findings[0].Subsystem)
+	require.Equal("flist", findings[1].Subsystem)
+	require.Equal("mounts", findings[1].Check)
+	require.Equal(SeverityWarning, findings[1].Severity)
+	require.Contains(findings[1].Message, "check timed out")
+}
+
+func TestUsageSeverity(t *testing.T) {
+	require := require.New(t)
+
+	require.Empty(usageSeverity(0, 0))
+	require.Empty(usageSeverity(50*gridtypes.Gigabyte, 100*gridtypes.Gigabyte))
+	require.Equal(SeverityWarning, usageSeverity(85*gridtypes.Gigabyte, 100*gridtypes.Gigabyte))
+	require.Equal(SeverityCritical, usageSeverity(99*gridtypes.Gigabyte, 100*gridtypes.Gigabyte))
+}
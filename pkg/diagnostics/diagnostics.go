@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/gomodule/redigo/redis"
+	"github.com/rs/zerolog/log"
 	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg/rotate"
 	"github.com/threefoldtech/test/pkg/utils"
 )
 
@@ -16,6 +18,13 @@ const (
 	testNetworkKey = "perf.healthcheck"
 )
 
+// logsDirs are the directories of the node logs, reported with their usage
+var logsDirs = map[string]string{
+	"system":     "/var/cache/log",
+	"vms":        "/var/cache/modules/vmd/logs",
+	"containers": "/var/cache/modules/contd/logs",
+}
+
 // Modules is all the registered modules on zbus
 var Modules = []string{
 	"storage",
@@ -46,11 +55,17 @@ type Diagnostics struct {
 	ZosModules map[string]ModuleStatus `json:"modules"`
 	// Healthy is the state of the node health check
 	Healthy bool `json:"healthy"`
+	// Findings are the problems found by the subsystems checks
+	// sorted by severity
+	Findings []Finding `json:"findings"`
+	// Logs is the disk usage of the node logs directories
+	Logs map[string]rotate.Usage `json:"logs"`
 }
 
 type DiagnosticsManager struct {
 	redisPool  *redis.Pool
 	zbusClient zbus.Client
+	checks     []Check
 }
 
 func NewDiagnosticsManager(
@@ -64,9 +79,15 @@ func NewDiagnosticsManager(
 	return &DiagnosticsManager{
 		redisPool:  pool,
 		zbusClient: busClient,
+		checks:     DefaultChecks(busClient),
 	}, nil
 }
 
+// AddCheck registers an extra check to run with the system diagnostics
+func (m *DiagnosticsManager) AddCheck(check Check) {
+	m.checks = append(m.checks, check)
+}
+
 func (m *DiagnosticsManager) GetSystemDiagnostics(ctx context.Context) (Diagnostics, error) {
 	results := Diagnostics{
 		SystemStatusOk: true,
@@ -77,6 +98,13 @@ func (m *DiagnosticsManager) GetSystemDiagnostics(ctx context.Context) (Diagnost
 	var mut sync.Mutex
 	var hasError bool
 
+	var findings []Finding
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		findings = runChecks(ctx, m.checks)
+	}()
+
 	for _, module := range Modules {
 		wg.Add(1)
 		go func(module string) {
@@ -99,10 +127,28 @@ func (m *DiagnosticsManager) GetSystemDiagnostics(ctx context.Context) (Diagnost
 
 	results.SystemStatusOk = !hasError
 	results.Healthy = m.isHealthy()
+	results.Findings = findings
+	results.Logs = logsUsage()
 
 	return results, nil
 }
 
+func logsUsage() map[string]rotate.Usage {
+	rotator := rotate.NewRotator(rotate.LogsPolicy()...)
+	usage := make(map[string]rotate.Usage)
+	for name, dir := range logsDirs {
+		dirUsage, err := rotator.Usage(dir)
+		if err != nil {
+			log.Error().Err(err).Str("dir", dir).Msg("failed to get logs usage")
+			continue
+		}
+
+		usage[name] = dirUsage
+	}
+
+	return usage
+}
+
 func (m *DiagnosticsManager) getModuleStatus(ctx context.Context, module string) ModuleStatus {
 	ctx, cancel := context.WithTimeout(ctx, callTimeout)
 	defer cancel()
@@ -41,13 +41,24 @@ func (c *Config) Merge(cfg Config) {
 	sort.Strings(c.Yggdrasil.Peers)
 }
 
-// GetConfig returns extend config for current run mode
+// GetConfig returns extend config for current run mode, with the
+// local override applied
 func GetConfig() (base Config, err error) {
 	env, err := Get()
 	if err != nil {
 		return
 	}
-	return GetConfigForMode(env.RunningMode)
+
+	base, err = GetConfigForMode(env.RunningMode)
+	if err != nil {
+		return
+	}
+
+	if override, _ := GetOverride(); override != nil {
+		override.applyConfig(&base)
+	}
+
+	return base, nil
 }
 
 // GetConfig returns extend config for specific run mode
@@ -63,6 +63,31 @@ type Environment struct {
 
 	// PubMac value from environment
 	PubMac PubMac
+
+	// NetEgressLimit is the node wide default egress rate limit (in Mbps)
+	// applied on every network resource. A network workload can ask for
+	// a lower limit but never exceed it. 0 means no limit.
+	NetEgressLimit uint64
+	// NetIngressLimit is the node wide default ingress rate limit (in Mbps)
+	// applied on every network resource. 0 means no limit.
+	NetIngressLimit uint64
+	// NetFairShare is the node uplink capacity (in Mbps) shared evenly
+	// between the network resources, each network resource is limited to
+	// its share in both directions. 0 disables the fair share.
+	NetFairShare uint64
+
+	// UpgradeBundle is a local path or a http(s) url of an offline upgrade
+	// bundle. If set, the node is upgraded from this bundle instead of the hub
+	UpgradeBundle string
+	// UpgradeKeys are the hex encoded ed25519 public keys trusted to sign
+	// upgrade bundles
+	UpgradeKeys []string
+
+	// DiskBenchSize is the size (in MiB) of the file written on each pool
+	// by the disk benchmark. 0 means the default size
+	DiskBenchSize uint64
+	// DiskBenchHDD enables the disk benchmark on the HDD pools
+	DiskBenchHDD bool
 }
 
 // RunMode type
@@ -215,7 +240,9 @@ func MustGet() Environment {
 // Get return the running environment of the node
 func Get() (Environment, error) {
 	params := kernel.GetParams()
-	return getEnvironmentFromParams(params)
+	// an invalid override is logged and ignored
+	override, _ := GetOverride()
+	return getEnvironment(params, override)
 }
 
 // GetSubstrate gets a client to subsrate blockchain
@@ -233,6 +260,10 @@ func GetSubstrate() (substrate.Manager, error) {
 }
 
 func getEnvironmentFromParams(params kernel.Params) (Environment, error) {
+	return getEnvironment(params, nil)
+}
+
+func getEnvironment(params kernel.Params, override *Override) (Environment, error) {
 	var env Environment
 	runmode := ""
 	if modes, ok := params.Get("runmode"); ok {
@@ -340,6 +371,52 @@ func getEnvironmentFromParams(params kernel.Params) (Environment, error) {
 		env.PubMac = PubMacRandom
 	}
 
+	if limit, found := params.GetOne("net:egress"); found {
+		mbps, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse net egress limit")
+		}
+		env.NetEgressLimit = mbps
+	}
+
+	if limit, found := params.GetOne("net:ingress"); found {
+		mbps, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse net ingress limit")
+		}
+		env.NetIngressLimit = mbps
+	}
+
+	if limit, found := params.GetOne("net:fair-share"); found {
+		mbps, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse net fair share")
+		}
+		env.NetFairShare = mbps
+	}
+
+	if bundle, found := params.GetOne("upgrade:bundle"); found {
+		env.UpgradeBundle = bundle
+	}
+
+	if keys, found := params.Get("upgrade:key"); found {
+		env.UpgradeKeys = keys
+	}
+
+	if size, found := params.GetOne("perf:diskbench-size"); found {
+		mib, err := strconv.ParseUint(size, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse disk benchmark size")
+		}
+		env.DiskBenchSize = mib
+	}
+
+	env.DiskBenchHDD = params.Exists("perf:diskbench-hdd")
+
+	if override != nil {
+		override.applyEnvironment(&env)
+	}
+
 	// Checking if there environment variable
 	// override default settings
 
@@ -355,5 +432,33 @@ func getEnvironmentFromParams(params kernel.Params) (Environment, error) {
 		env.BinRepo = e
 	}
 
+	if e := os.Getenv("ZOS_NET_EGRESS_MBPS"); e != "" {
+		mbps, err := strconv.ParseUint(e, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse ZOS_NET_EGRESS_MBPS")
+		}
+		env.NetEgressLimit = mbps
+	}
+
+	if e := os.Getenv("ZOS_NET_INGRESS_MBPS"); e != "" {
+		mbps, err := strconv.ParseUint(e, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse ZOS_NET_INGRESS_MBPS")
+		}
+		env.NetIngressLimit = mbps
+	}
+
+	if e := os.Getenv("ZOS_NET_FAIR_SHARE_MBPS"); e != "" {
+		mbps, err := strconv.ParseUint(e, 10, 64)
+		if err != nil {
+			return env, errors.Wrap(err, "failed to parse ZOS_NET_FAIR_SHARE_MBPS")
+		}
+		env.NetFairShare = mbps
+	}
+
+	if e := os.Getenv("ZOS_UPGRADE_BUNDLE"); e != "" {
+		env.UpgradeBundle = e
+	}
+
 	return env, nil
 }
@@ -59,3 +59,66 @@ func TestEnvironmentOverride(t *testing.T) {
 
 	assert.Equal(t, []string{"localhost:1234"}, value.SubstrateURL)
 }
+
+func TestEnvironmentNetLimits(t *testing.T) {
+	params := kernel.Params{"net:egress": {"100"}}
+	value, err := getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(100), value.NetEgressLimit)
+	assert.Equal(t, uint64(0), value.NetIngressLimit)
+
+	os.Setenv("ZOS_NET_INGRESS_MBPS", "50")
+	defer os.Unsetenv("ZOS_NET_INGRESS_MBPS")
+
+	value, err = getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(50), value.NetIngressLimit)
+	assert.Equal(t, uint64(0), value.NetFairShare)
+
+	params = kernel.Params{"net:fair-share": {"1000"}}
+	value, err = getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1000), value.NetFairShare)
+
+	params = kernel.Params{"net:egress": {"fast"}}
+	_, err = getEnvironmentFromParams(params)
+	require.Error(t, err)
+}
+
+func TestEnvironmentUpgradeBundle(t *testing.T) {
+	params := kernel.Params{
+		"upgrade:bundle": {"/var/cache/bundle"},
+		"upgrade:key":    {"aa", "bb"},
+	}
+	value, err := getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/var/cache/bundle", value.UpgradeBundle)
+	assert.Equal(t, []string{"aa", "bb"}, value.UpgradeKeys)
+
+	os.Setenv("ZOS_UPGRADE_BUNDLE", "http://10.0.0.1/bundle")
+	defer os.Unsetenv("ZOS_UPGRADE_BUNDLE")
+
+	value, err = getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://10.0.0.1/bundle", value.UpgradeBundle)
+}
+
+func TestEnvironmentDiskBench(t *testing.T) {
+	value, err := getEnvironmentFromParams(kernel.Params{})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(0), value.DiskBenchSize)
+	assert.False(t, value.DiskBenchHDD)
+
+	params := kernel.Params{"perf:diskbench-size": {"64"}, "perf:diskbench-hdd": {}}
+	value, err = getEnvironmentFromParams(params)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(64), value.DiskBenchSize)
+	assert.True(t, value.DiskBenchHDD)
+}
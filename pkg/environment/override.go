@@ -0,0 +1,228 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg/crypto"
+	"github.com/threefoldtech/test/pkg/kernel"
+)
+
+// A local override is a json file (see Override) on the config partition
+// with a hex encoded ed25519 signature of the file content next to it
+//
+//	override.json      the override
+//	override.json.sig  hex encoded ed25519 signature of override.json
+//
+// The override is only applied if it's signed by one of the keys set with
+// the `config:key` kernel param (hex encoded ed25519 public keys).
+//
+// Precedence from lowest to highest:
+//   - run mode defaults and the organization config (test-config repo)
+//   - kernel params
+//   - local override
+//   - ZOS_* environment variables
+//
+// Peers and authorized users of the override replace the ones of the
+// organization config instead of being merged with them.
+const (
+	// OverrideFile is the path of the local override file
+	OverrideFile = "/var/cache/config/override.json"
+
+	// ConfigKey is the kernel param of the keys trusted to sign the override
+	ConfigKey = "config:key"
+
+	overrideSignatureExt = ".sig"
+	maxOverrideSize      = 1024 * 1024
+)
+
+var (
+	// ErrInvalidOverrideSignature is returned if the override is not signed
+	// by one of the trusted keys
+	ErrInvalidOverrideSignature = fmt.Errorf("invalid override signature")
+
+	overrides = overrideCache{
+		path: OverrideFile,
+		keys: func() []string {
+			keys, _ := kernel.GetParams().Get(ConfigKey)
+			return keys
+		},
+	}
+)
+
+// Override is the local configuration override. Only set fields are applied
+type Override struct {
+	SubstrateURL []string `json:"substrate,omitempty"`
+	RelayURL     []string `json:"relay,omitempty"`
+	GraphQL      []string `json:"graphql,omitempty"`
+	FlistURL     string   `json:"flist,omitempty"`
+
+	Yggdrasil struct {
+		Peers []string `json:"peers,omitempty"`
+	} `json:"yggdrasil"`
+	Mycelium struct {
+		Peers []string `json:"peers,omitempty"`
+	} `json:"mycelium"`
+	Users struct {
+		Authorized []string `json:"authorized,omitempty"`
+	} `json:"users"`
+}
+
+// applyEnvironment sets the override fields on env
+func (o *Override) applyEnvironment(env *Environment) {
+	if len(o.SubstrateURL) > 0 {
+		env.SubstrateURL = o.SubstrateURL
+	}
+
+	if len(o.RelayURL) > 0 {
+		env.RelayURL = o.RelayURL
+	}
+
+	if len(o.GraphQL) > 0 {
+		env.GraphQL = o.GraphQL
+	}
+
+	if len(o.FlistURL) > 0 {
+		env.FlistURL = o.FlistURL
+	}
+}
+
+// applyConfig sets the override fields on cfg
+func (o *Override) applyConfig(cfg *Config) {
+	if len(o.Yggdrasil.Peers) > 0 {
+		cfg.Yggdrasil.Peers = o.Yggdrasil.Peers
+	}
+
+	if len(o.Mycelium.Peers) > 0 {
+		cfg.Mycelium.Peers = o.Mycelium.Peers
+	}
+
+	if len(o.Users.Authorized) > 0 {
+		cfg.Users.Authorized = o.Users.Authorized
+	}
+}
+
+// Fields returns the names of the set fields of the override
+func (o *Override) Fields() []string {
+	var fields []string
+	set := func(name string, ok bool) {
+		if ok {
+			fields = append(fields, name)
+		}
+	}
+
+	set("substrate", len(o.SubstrateURL) > 0)
+	set("relay", len(o.RelayURL) > 0)
+	set("graphql", len(o.GraphQL) > 0)
+	set("flist", len(o.FlistURL) > 0)
+	set("yggdrasil.peers", len(o.Yggdrasil.Peers) > 0)
+	set("mycelium.peers", len(o.Mycelium.Peers) > 0)
+	set("users.authorized", len(o.Users.Authorized) > 0)
+
+	return fields
+}
+
+func readLimited(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(io.LimitReader(file, maxOverrideSize))
+}
+
+// LoadOverride loads the override at path and verifies that it's signed by
+// one of keys. A nil override is returned if the file does not exist
+func LoadOverride(path string, keys []string) (*Override, error) {
+	data, err := readLimited(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to read override")
+	}
+
+	trusted, err := crypto.KeysFromHex(keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid config keys")
+	}
+
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no trusted keys configured for config override, set the '%s' kernel param", ConfigKey)
+	}
+
+	signature, err := readLimited(path + overrideSignatureExt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read override signature")
+	}
+
+	if err := crypto.VerifyAnyHex(trusted, data, signature); err != nil {
+		return nil, ErrInvalidOverrideSignature
+	}
+
+	var ov Override
+	if err := json.Unmarshal(data, &ov); err != nil {
+		return nil, errors.Wrap(err, "failed to decode override")
+	}
+
+	return &ov, nil
+}
+
+// overrideCache keeps the last loaded override. The override is loaded
+// again if the override or signature file changed, this also covers the
+// config partition being mounted after the override was first checked.
+type overrideCache struct {
+	path string
+	keys func() []string
+
+	m        sync.Mutex
+	loaded   bool
+	stamp    string
+	override *Override
+	err      error
+}
+
+// fileStamp identifies the version of the file at path
+func fileStamp(path string) string {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d:%d", stat.ModTime().UnixNano(), stat.Size())
+}
+
+func (c *overrideCache) get() (*Override, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	stamp := fileStamp(c.path) + "/" + fileStamp(c.path+overrideSignatureExt)
+	if c.loaded && stamp == c.stamp {
+		return c.override, c.err
+	}
+
+	c.override, c.err = LoadOverride(c.path, c.keys())
+	c.stamp = stamp
+	c.loaded = true
+
+	if c.err != nil {
+		log.Error().Err(c.err).Str("path", c.path).Msg("ignoring local config override")
+	} else if c.override != nil {
+		log.Info().Str("path", c.path).Strs("fields", c.override.Fields()).Msg("local config override loaded")
+	}
+
+	return c.override, c.err
+}
+
+// GetOverride returns the local override of the node. The override is
+// loaded again when the override files change. A nil override is returned
+// if there is no override file. If the override is invalid an error is
+// returned and the override is not applied
+func GetOverride() (*Override, error) {
+	return overrides.get()
+}
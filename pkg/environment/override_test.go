@@ -0,0 +1,130 @@
+package environment
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/kernel"
+)
+
+func writeOverride(t *testing.T, dir string, data []byte, sk ed25519.PrivateKey) string {
+	path := filepath.Join(dir, "override.json")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	signature := hex.EncodeToString(ed25519.Sign(sk, data))
+	require.NoError(t, os.WriteFile(path+overrideSignatureExt, []byte(signature), 0644))
+
+	return path
+}
+
+func TestLoadOverride(t *testing.T) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPk, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	ov, err := LoadOverride(filepath.Join(dir, "override.json"), nil)
+	require.NoError(t, err)
+	require.Nil(t, ov)
+
+	path := writeOverride(t, dir, []byte(`{
+		"substrate": ["wss://tfchain.local"],
+		"flist": "redis://hub.local:9900",
+		"mycelium": {"peers": ["tcp://10.0.0.1:9651"]}
+	}`), sk)
+
+	_, err = LoadOverride(path, nil)
+	require.Error(t, err)
+
+	_, err = LoadOverride(path, []string{hex.EncodeToString(otherPk)})
+	require.ErrorIs(t, err, ErrInvalidOverrideSignature)
+
+	ov, err = LoadOverride(path, []string{hex.EncodeToString(otherPk), hex.EncodeToString(pk)})
+	require.NoError(t, err)
+	require.NotNil(t, ov)
+
+	assert.Equal(t, []string{"wss://tfchain.local"}, ov.SubstrateURL)
+	assert.Equal(t, "redis://hub.local:9900", ov.FlistURL)
+	assert.Equal(t, []string{"tcp://10.0.0.1:9651"}, ov.Mycelium.Peers)
+}
+
+func TestOverridePrecedence(t *testing.T) {
+	var ov Override
+	ov.SubstrateURL = []string{"wss://tfchain.local"}
+	ov.RelayURL = []string{"wss://relay.local"}
+	ov.Yggdrasil.Peers = []string{"tls://10.0.0.1:9943"}
+
+	params := kernel.Params{
+		"runmode":   {"dev"},
+		"substrate": {"wss://tfchain.param"},
+		"relay":     {"wss://relay.param"},
+	}
+
+	t.Setenv("ZOS_SUBSTRATE_URL", "")
+	env, err := getEnvironment(params, &ov)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"wss://tfchain.local"}, env.SubstrateURL)
+	assert.Equal(t, []string{"wss://relay.local"}, env.RelayURL)
+	// not set in the override
+	assert.Equal(t, envDev.GraphQL, env.GraphQL)
+
+	t.Setenv("ZOS_SUBSTRATE_URL", "wss://tfchain.env")
+
+	env, err = getEnvironment(params, &ov)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"wss://tfchain.env"}, env.SubstrateURL)
+
+	var cfg Config
+	cfg.Yggdrasil.Peers = []string{"tls://1.1.1.1:9943"}
+	cfg.Mycelium.Peers = []string{"tcp://1.1.1.1:9651"}
+
+	ov.applyConfig(&cfg)
+	assert.Equal(t, []string{"tls://10.0.0.1:9943"}, cfg.Yggdrasil.Peers)
+	assert.Equal(t, []string{"tcp://1.1.1.1:9651"}, cfg.Mycelium.Peers)
+}
+
+func TestOverrideReload(t *testing.T) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	cache := overrideCache{
+		path: filepath.Join(dir, "override.json"),
+		keys: func() []string { return []string{hex.EncodeToString(pk)} },
+	}
+
+	// the config partition is not mounted yet
+	ov, err := cache.get()
+	require.NoError(t, err)
+	require.Nil(t, ov)
+
+	writeOverride(t, dir, []byte(`{"flist": "redis://hub.local:9900"}`), sk)
+
+	ov, err = cache.get()
+	require.NoError(t, err)
+	require.NotNil(t, ov)
+	assert.Equal(t, "redis://hub.local:9900", ov.FlistURL)
+	assert.Equal(t, []string{"flist"}, ov.Fields())
+
+	writeOverride(t, dir, []byte(`{"relay": ["wss://relay.local"], "mycelium": {"peers": ["tcp://10.0.0.1:9651"]}}`), sk)
+
+	ov, err = cache.get()
+	require.NoError(t, err)
+	require.NotNil(t, ov)
+	assert.Empty(t, ov.FlistURL)
+	assert.Equal(t, []string{"relay", "mycelium.peers"}, ov.Fields())
+
+	require.NoError(t, os.WriteFile(cache.path+overrideSignatureExt, []byte("00"), 0644))
+
+	_, err = cache.get()
+	require.ErrorIs(t, err, ErrInvalidOverrideSignature)
+}
@@ -1,6 +1,11 @@
 package pkg
 
+//go:generate mkdir -p stubs
+//go:generate zbusc -module node -version 0.0.1 -name events -package stubs github.com/threefoldtech/test/pkg+EventLog stubs/event_log_stub.go
+
 import (
+	"encoding/json"
+
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 )
 
@@ -31,3 +36,40 @@ type PowerTargetChangeEvent struct {
 	NodeID uint32
 	Target substrate.Power
 }
+
+// EventKind is the kind of a chain event
+type EventKind string
+
+const (
+	EventKindPublicConfig      EventKind = "public-config"
+	EventKindContractCancelled EventKind = "contract-cancelled"
+	EventKindContractLocked    EventKind = "contract-locked"
+	EventKindPowerTarget       EventKind = "power-target"
+)
+
+// ChainEvent is a chain event relevant to the node as kept in the event log
+type ChainEvent struct {
+	// Offset of the event in the log
+	Offset uint64 `json:"offset"`
+	// Block where the event was emitted
+	Block uint32 `json:"block"`
+	// Timestamp when the event was received
+	Timestamp int64     `json:"timestamp"`
+	Kind      EventKind `json:"kind"`
+	// Event is the json encoded event (for example ContractCancelledEvent)
+	Event json.RawMessage `json:"event"`
+}
+
+// EventLog is the persistent log of all chain events relevant to the node
+// (provided by noded)
+type EventLog interface {
+	// List returns up to count events starting at offset from. If from is 0
+	// the last count events are returned. An empty kind matches all events
+	List(from uint64, count uint32, kind EventKind) ([]ChainEvent, error)
+	// Last returns the offset of the last event in the log, 0 if the log is empty
+	Last() (uint64, error)
+	// Replay pushes the events starting at offset from again to the given
+	// consumer only (the module name). Power target events are never
+	// replayed. Returns the number of replayed events
+	Replay(consumer string, from uint64) (uint32, error)
+}
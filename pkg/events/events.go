@@ -61,7 +61,8 @@ func (e *FileState) Get(cl *gsrpc.SubstrateAPI) (types.BlockNumber, error) {
 	return block.Block.Header.Number - 1, nil
 }
 
-type Callback func(events *substrate.EventRecords)
+// Callback is called with the events of each block
+type Callback func(block types.BlockNumber, events *substrate.EventRecords)
 
 // Events processor receives all events starting from the given state
 // and for each set of events calls callback cb
@@ -80,7 +81,7 @@ func NewProcessor(sub substrate.Manager, cb Callback, state State) *Processor {
 	}
 }
 
-func (e *Processor) process(changes []types.StorageChangeSet, meta *types.Metadata) {
+func (e *Processor) process(block types.BlockNumber, changes []types.StorageChangeSet, meta *types.Metadata) {
 	for _, set := range changes {
 		for _, change := range set.Changes {
 			if !change.HasStorageData {
@@ -93,7 +94,7 @@ func (e *Processor) process(changes []types.StorageChangeSet, meta *types.Metada
 				continue
 			}
 
-			e.cb(&events)
+			e.cb(block, &events)
 		}
 	}
 }
@@ -133,7 +134,7 @@ func (e *Processor) eventsTo(cl *gsrpc.SubstrateAPI, meta *types.Metadata, block
 			return errors.Wrapf(err, "failed to get block with hash '%s'", hash.Hex())
 		}
 
-		e.process(changes, meta)
+		e.process(i, changes, meta)
 	}
 
 	return nil
@@ -0,0 +1,199 @@
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/test/pkg"
+)
+
+const (
+	// logMaxEvents is the max number of events kept in the log
+	logMaxEvents = 10000
+	// logMaxList is the max number of events returned by a single list
+	logMaxList = 1000
+)
+
+var logBucket = []byte("events")
+
+// Log is a persistent log of chain events. Events are stored by their
+// offset (big endian) so they are sorted in the order they were received.
+type Log struct {
+	db *bolt.DB
+}
+
+// NewLog opens (or creates) the event log at path
+func NewLog(path string) (*Log, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open event log")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(logBucket)
+		return err
+	})
+
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "failed to create event log bucket")
+	}
+
+	return &Log{db: db}, nil
+}
+
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// Append adds an event to the log and drops the oldest events if the log is
+// over logMaxEvents
+func (l *Log) Append(block uint32, kind pkg.EventKind, event interface{}) (pkg.ChainEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return pkg.ChainEvent{}, errors.Wrap(err, "failed to encode event")
+	}
+
+	entry := pkg.ChainEvent{
+		Block:     block,
+		Timestamp: time.Now().Unix(),
+		Kind:      kind,
+		Event:     data,
+	}
+
+	err = l.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(logBucket)
+		offset, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		entry.Offset = offset
+		value, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		if err := bucket.Put(OffsetKey(offset), value); err != nil {
+			return err
+		}
+
+		return Trim(bucket, logMaxEvents)
+	})
+
+	if err != nil {
+		return pkg.ChainEvent{}, errors.Wrap(err, "failed to append event")
+	}
+
+	return entry, nil
+}
+
+// Trim deletes the oldest entries so only max entries are left in a bucket
+// keyed by OffsetKey. Offsets are sequential and only the oldest entries are
+// deleted, so the number of entries is the distance between the first and
+// last offsets. (bucket stats don't include the changes of the current
+// transaction)
+func Trim(bucket *bolt.Bucket, max int) error {
+	cur := bucket.Cursor()
+	first, _ := cur.First()
+	last, _ := cur.Last()
+	if first == nil {
+		return nil
+	}
+
+	extra := int64(lu64(last)-lu64(first)+1) - int64(max)
+	for k, _ := cur.First(); k != nil && extra > 0; k, _ = cur.First() {
+		if err := cur.Delete(); err != nil {
+			return err
+		}
+		extra--
+	}
+
+	return nil
+}
+
+// Last returns the offset of the last event in the log, 0 if the log is empty
+func (l *Log) Last() (uint64, error) {
+	var last uint64
+	err := l.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(logBucket).Cursor().Last()
+		if k != nil {
+			last = lu64(k)
+		}
+		return nil
+	})
+
+	return last, err
+}
+
+// List returns up to count events of the given kind starting at offset from.
+// If from is 0 the last count events are returned. An empty kind matches
+// all events.
+func (l *Log) List(from uint64, count uint32, kind pkg.EventKind) ([]pkg.ChainEvent, error) {
+	if count == 0 || count > logMaxList {
+		count = logMaxList
+	}
+
+	events := []pkg.ChainEvent{}
+	err := l.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(logBucket).Cursor()
+
+		match := func(v []byte) (pkg.ChainEvent, bool, error) {
+			var event pkg.ChainEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return event, false, errors.Wrap(err, "failed to decode event")
+			}
+
+			return event, len(kind) == 0 || event.Kind == kind, nil
+		}
+
+		if from == 0 {
+			// walk backwards to get the last events
+			for k, v := cur.Last(); k != nil && len(events) < int(count); k, v = cur.Prev() {
+				event, ok, err := match(v)
+				if err != nil {
+					return err
+				}
+				if ok {
+					events = append(events, event)
+				}
+			}
+
+			// return them in log order
+			for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+				events[i], events[j] = events[j], events[i]
+			}
+
+			return nil
+		}
+
+		for k, v := cur.Seek(OffsetKey(from)); k != nil && len(events) < int(count); k, v = cur.Next() {
+			event, ok, err := match(v)
+			if err != nil {
+				return err
+			}
+			if ok {
+				events = append(events, event)
+			}
+		}
+
+		return nil
+	})
+
+	return events, err
+}
+
+// OffsetKey returns the key of the entry at offset, big endian so entries
+// are sorted by offset
+func OffsetKey(v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return b[:]
+}
+
+func lu64(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestLog(t *testing.T) {
+	require := require.New(t)
+
+	log, err := NewLog(filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(err)
+	defer log.Close()
+
+	last, err := log.Last()
+	require.NoError(err)
+	require.EqualValues(0, last)
+
+	for i := 0; i < 10; i++ {
+		kind := pkg.EventKindContractCancelled
+		if i%2 == 0 {
+			kind = pkg.EventKindContractLocked
+		}
+
+		entry, err := log.Append(uint32(100+i), kind, pkg.ContractCancelledEvent{Contract: uint64(i)})
+		require.NoError(err)
+		require.EqualValues(i+1, entry.Offset)
+	}
+
+	last, err = log.Last()
+	require.NoError(err)
+	require.EqualValues(10, last)
+
+	events, err := log.List(0, 3, "")
+	require.NoError(err)
+	require.Len(events, 3)
+	require.EqualValues(8, events[0].Offset)
+	require.EqualValues(10, events[2].Offset)
+
+	events, err = log.List(4, 2, "")
+	require.NoError(err)
+	require.Len(events, 2)
+	require.EqualValues(4, events[0].Offset)
+	require.EqualValues(103, events[0].Block)
+
+	events, err = log.List(1, 0, pkg.EventKindContractLocked)
+	require.NoError(err)
+	require.Len(events, 5)
+	for _, event := range events {
+		require.Equal(pkg.EventKindContractLocked, event.Kind)
+	}
+
+	event, err := decodeEvent(events[1])
+	require.NoError(err)
+	require.Equal(pkg.ContractLockedEvent{Contract: 2}, event)
+}
+
+func TestLogTrim(t *testing.T) {
+	require := require.New(t)
+
+	log, err := NewLog(filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(err)
+	defer log.Close()
+
+	for i := 0; i < 20; i++ {
+		_, err := log.Append(uint32(i), pkg.EventKindPowerTarget, pkg.PowerTargetChangeEvent{})
+		require.NoError(err)
+	}
+
+	err = log.db.Update(func(tx *bolt.Tx) error {
+		return Trim(tx.Bucket(logBucket), 5)
+	})
+	require.NoError(err)
+
+	events, err := log.List(1, 0, "")
+	require.NoError(err)
+	require.Len(events, 5)
+	require.EqualValues(16, events[0].Offset)
+}
+
+func TestLogTrimSameTx(t *testing.T) {
+	require := require.New(t)
+
+	log, err := NewLog(filepath.Join(t.TempDir(), "events.db"))
+	require.NoError(err)
+	defer log.Close()
+
+	// keys added in the same transaction must be counted
+	err = log.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(logBucket)
+		for i := 0; i < 20; i++ {
+			offset, err := bucket.NextSequence()
+			require.NoError(err)
+			require.NoError(bucket.Put(OffsetKey(offset), []byte("{}")))
+			require.NoError(Trim(bucket, 5))
+		}
+		return nil
+	})
+	require.NoError(err)
+
+	err = log.db.View(func(tx *bolt.Tx) error {
+		cur := tx.Bucket(logBucket).Cursor()
+		var keys []uint64
+		for k, _ := cur.First(); k != nil; k, _ = cur.Next() {
+			keys = append(keys, lu64(k))
+		}
+		require.Equal([]uint64{16, 17, 18, 19, 20}, keys)
+		return nil
+	})
+	require.NoError(err)
+}
+
+func TestReplayable(t *testing.T) {
+	require := require.New(t)
+
+	require.True(replayable(pkg.EventKindContractCancelled))
+	require.True(replayable(pkg.EventKindContractLocked))
+	require.True(replayable(pkg.EventKindPublicConfig))
+	require.False(replayable(pkg.EventKindPowerTarget))
+
+	require.Equal("stream:contract-lock:replay:provision", replayStream(streamContractGracePeriod, "provision"))
+}
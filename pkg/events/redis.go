@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
@@ -28,15 +30,28 @@ const (
 	streamPowerTargetChange   = "stream:power-target"
 )
 
+// streams maps the event kinds to their redis streams
+var streams = map[pkg.EventKind]string{
+	pkg.EventKindPublicConfig:      streamPublicConfig,
+	pkg.EventKindContractCancelled: streamContractCancelled,
+	pkg.EventKindContractLocked:    streamContractGracePeriod,
+	pkg.EventKindPowerTarget:       streamPowerTargetChange,
+}
+
+var _ pkg.EventLog = (*RedisStream)(nil)
+
 type RedisStream struct {
 	sub   substrate.Manager
 	state string
 	farm  pkg.FarmID
 	node  uint32
 	pool  *redis.Pool
+	log   *Log
 }
 
-func NewRedisStream(sub substrate.Manager, address string, farm pkg.FarmID, node uint32, state string) (*RedisStream, error) {
+// NewRedisStream creates a new stream of chain events. All events are
+// also kept in the event log el so they can be listed and replayed
+func NewRedisStream(sub substrate.Manager, address string, farm pkg.FarmID, node uint32, state string, el *Log) (*RedisStream, error) {
 	pool, err := utils.NewRedisPool(address, 2)
 	if err != nil {
 		return nil, err
@@ -48,6 +63,7 @@ func NewRedisStream(sub substrate.Manager, address string, farm pkg.FarmID, node
 		farm:  farm,
 		node:  node,
 		pool:  pool,
+		log:   el,
 	}, nil
 }
 
@@ -67,7 +83,18 @@ func (r *RedisStream) push(con redis.Conn, queue string, event interface{}) erro
 	return err
 }
 
-func (r *RedisStream) process(events *substrate.EventRecords) {
+// emit records the event in the event log and pushes it to its stream
+func (r *RedisStream) emit(con redis.Conn, block types.BlockNumber, kind pkg.EventKind, event interface{}) {
+	if _, err := r.log.Append(uint32(block), kind, event); err != nil {
+		log.Error().Err(err).Str("kind", string(kind)).Msg("failed to record event")
+	}
+
+	if err := r.push(con, streams[kind], event); err != nil {
+		log.Error().Err(err).Msg("failed to push event")
+	}
+}
+
+func (r *RedisStream) process(block types.BlockNumber, events *substrate.EventRecords) {
 	con := r.pool.Get()
 	defer con.Close()
 
@@ -77,11 +104,9 @@ func (r *RedisStream) process(events *substrate.EventRecords) {
 		}
 		log.Info().Msgf("got a public config update: %+v", event.Config)
 
-		if err := r.push(con, streamPublicConfig, pkg.PublicConfigEvent{
+		r.emit(con, block, pkg.EventKindPublicConfig, pkg.PublicConfigEvent{
 			PublicConfig: event.Config,
-		}); err != nil {
-			log.Error().Err(err).Msg("failed to push event")
-		}
+		})
 	}
 
 	for _, event := range events.SmartContractModule_NodeContractCanceled {
@@ -89,12 +114,10 @@ func (r *RedisStream) process(events *substrate.EventRecords) {
 			continue
 		}
 		log.Info().Uint64("contract", uint64(event.ContractID)).Msg("got contract cancel update")
-		if err := r.push(con, streamContractCancelled, pkg.ContractCancelledEvent{
+		r.emit(con, block, pkg.EventKindContractCancelled, pkg.ContractCancelledEvent{
 			Contract: uint64(event.ContractID),
 			TwinId:   uint32(event.Twin),
-		}); err != nil {
-			log.Error().Err(err).Msg("failed to push event")
-		}
+		})
 	}
 
 	for _, event := range events.SmartContractModule_ContractGracePeriodStarted {
@@ -102,13 +125,11 @@ func (r *RedisStream) process(events *substrate.EventRecords) {
 			continue
 		}
 		log.Info().Uint64("contract", uint64(event.ContractID)).Msg("got contract grace period started")
-		if err := r.push(con, streamContractGracePeriod, pkg.ContractLockedEvent{
+		r.emit(con, block, pkg.EventKindContractLocked, pkg.ContractLockedEvent{
 			Contract: uint64(event.ContractID),
 			TwinId:   uint32(event.TwinID),
 			Lock:     true,
-		}); err != nil {
-			log.Error().Err(err).Msg("failed to push event")
-		}
+		})
 	}
 
 	for _, event := range events.SmartContractModule_ContractGracePeriodEnded {
@@ -116,13 +137,11 @@ func (r *RedisStream) process(events *substrate.EventRecords) {
 			continue
 		}
 		log.Info().Uint64("contract", uint64(event.ContractID)).Msg("got contract grace period ended")
-		if err := r.push(con, streamContractGracePeriod, pkg.ContractLockedEvent{
+		r.emit(con, block, pkg.EventKindContractLocked, pkg.ContractLockedEvent{
 			Contract: uint64(event.ContractID),
 			TwinId:   uint32(event.TwinID),
 			Lock:     false,
-		}); err != nil {
-			log.Error().Err(err).Msg("failed to push event")
-		}
+		})
 	}
 
 	for _, event := range events.TfgridModule_PowerTargetChanged {
@@ -131,15 +150,102 @@ func (r *RedisStream) process(events *substrate.EventRecords) {
 		}
 
 		log.Info().Uint32("node", uint32(event.Node)).Msg("got power target change event")
-		if err := r.push(con, streamPowerTargetChange, pkg.PowerTargetChangeEvent{
+		r.emit(con, block, pkg.EventKindPowerTarget, pkg.PowerTargetChangeEvent{
 			FarmID: pkg.FarmID(event.Farm),
 			NodeID: uint32(event.Node),
 			Target: event.PowerTarget,
-		}); err != nil {
-			log.Error().Err(err).Msg("failed to push event")
+		})
+	}
+
+}
+
+// List returns the events in the event log
+func (r *RedisStream) List(from uint64, count uint32, kind pkg.EventKind) ([]pkg.ChainEvent, error) {
+	return r.log.List(from, count, kind)
+}
+
+// Last returns the offset of the last event in the event log
+func (r *RedisStream) Last() (uint64, error) {
+	return r.log.Last()
+}
+
+// replayable returns true if the event kind can be replayed. Power target
+// events are never replayed since they can shutdown the node.
+func replayable(kind pkg.EventKind) bool {
+	return kind != pkg.EventKindPowerTarget
+}
+
+// replayStream is the stream where the events replayed for consumer are pushed
+func replayStream(stream, consumer string) string {
+	return fmt.Sprintf("%s:replay:%s", stream, consumer)
+}
+
+// Replay pushes the events in the event log starting at offset from to the
+// replay streams of the given consumer only, the live streams (and so the other
+// consumers) are not affected. Power target events are not replayed. The
+// consumer must be able to handle an event more than once.
+func (r *RedisStream) Replay(consumer string, from uint64) (uint32, error) {
+	if len(consumer) == 0 {
+		return 0, fmt.Errorf("consumer is required")
+	}
+
+	con := r.pool.Get()
+	defer con.Close()
+
+	var count uint32
+	for {
+		events, err := r.log.List(from, logMaxList, "")
+		if err != nil {
+			return count, err
 		}
+
+		if len(events) == 0 {
+			return count, nil
+		}
+
+		for _, entry := range events {
+			if !replayable(entry.Kind) {
+				continue
+			}
+
+			event, err := decodeEvent(entry)
+			if err != nil {
+				return count, errors.Wrapf(err, "failed to decode event '%d'", entry.Offset)
+			}
+
+			if err := r.push(con, replayStream(streams[entry.Kind], consumer), event); err != nil {
+				return count, errors.Wrapf(err, "failed to push event '%d'", entry.Offset)
+			}
+
+			count++
+		}
+
+		from = events[len(events)-1].Offset + 1
+	}
+}
+
+// decodeEvent decodes the event of a log entry into its type
+func decodeEvent(entry pkg.ChainEvent) (interface{}, error) {
+	var event interface{}
+	switch entry.Kind {
+	case pkg.EventKindPublicConfig:
+		event = &pkg.PublicConfigEvent{}
+	case pkg.EventKindContractCancelled:
+		event = &pkg.ContractCancelledEvent{}
+	case pkg.EventKindContractLocked:
+		event = &pkg.ContractLockedEvent{}
+	case pkg.EventKindPowerTarget:
+		event = &pkg.PowerTargetChangeEvent{}
+	default:
+		return nil, fmt.Errorf("unknown event kind '%s'", entry.Kind)
 	}
 
+	if err := json.Unmarshal(entry.Event, event); err != nil {
+		return nil, err
+	}
+
+	// push the value not the pointer
+	return reflect.ValueOf(event).Elem().Interface(), nil
 }
 
 func (r *RedisStream) Start(ctx context.Context) {
@@ -216,6 +322,8 @@ func (r *RedisConsumer) ack(ctx context.Context, con redis.Conn, group, stream,
 	return err
 }
 
+// consumer sends the events of the stream, and the events replayed for
+// this consumer, to ch
 func (r *RedisConsumer) consumer(ctx context.Context, stream string, ch reflect.Value) error {
 	chType := ch.Type()
 	if chType.Kind() != reflect.Chan {
@@ -227,17 +335,35 @@ func (r *RedisConsumer) consumer(ctx context.Context, stream string, ch reflect.
 		panic("channel element must be a structure")
 	}
 
+	var wg sync.WaitGroup
+	for _, name := range []string{stream, replayStream(stream, r.id)} {
+		if err := r.read(ctx, &wg, name, elem, ch); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		wg.Wait()
+		ch.Close()
+	}()
+
+	return nil
+}
+
+func (r *RedisConsumer) read(ctx context.Context, wg *sync.WaitGroup, stream string, elem reflect.Type, ch reflect.Value) error {
 	con := r.pool.Get()
 	group, err := r.ensureGroup(con, stream)
 
 	if err != nil && !isBusyGroup(err) {
+		con.Close()
 		return err
 	}
 
 	logger := log.With().Str("stream", stream).Logger()
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		defer con.Close()
-		defer ch.Close()
 
 		for {
 			messages, err := r.pop(con, group, stream)
@@ -0,0 +1,357 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	namespace = "test"
+
+	// pollInterval is how often metrics that are not streamed are collected
+	pollInterval = 1 * time.Minute
+	// retryInterval is how long to wait before subscribing again to a
+	// stream that was closed
+	retryInterval = 10 * time.Second
+
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// Exporter collects the node metrics from the zbus streams and serves
+// them in the prometheus (or openmetrics) text format
+type Exporter struct {
+	cl zbus.Client
+
+	m       sync.RWMutex
+	node    uint32
+	memory  *pkg.VirtualMemoryStat
+	cpu     *pkg.TimesStat
+	disks   pkg.DisksIOCountersStat
+	nics    pkg.NicsIOCounterStat
+	pools   pkg.PoolsStats
+	vms     pkg.MachineMetrics
+	gateway *pkg.GatewayMetrics
+}
+
+// NewExporter creates a new exporter
+func NewExporter(cl zbus.Client) *Exporter {
+	return &Exporter{cl: cl}
+}
+
+// follow keeps calling stream until the context is cancelled. stream must
+// subscribe to a zbus stream and consume it until it's closed.
+func (e *Exporter) follow(ctx context.Context, name string, stream func(ctx context.Context) error) {
+	for {
+		if err := stream(ctx); err != nil {
+			log.Error().Err(err).Str("stream", name).Msg("failed to subscribe to stream")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Start starts collecting the metrics, it blocks until the context is cancelled
+func (e *Exporter) Start(ctx context.Context) {
+	system := stubs.NewSystemMonitorStub(e.cl)
+	storage := stubs.NewStorageModuleStub(e.cl)
+
+	go e.follow(ctx, "memory", func(ctx context.Context) error {
+		ch, err := system.Memory(ctx)
+		if err != nil {
+			return err
+		}
+		for point := range ch {
+			point := point
+			e.m.Lock()
+			e.memory = &point
+			e.m.Unlock()
+		}
+		return nil
+	})
+
+	go e.follow(ctx, "cpu", func(ctx context.Context) error {
+		ch, err := system.CPU(ctx)
+		if err != nil {
+			return err
+		}
+		for point := range ch {
+			point := point
+			e.m.Lock()
+			e.cpu = &point
+			e.m.Unlock()
+		}
+		return nil
+	})
+
+	go e.follow(ctx, "disks", func(ctx context.Context) error {
+		ch, err := system.Disks(ctx)
+		if err != nil {
+			return err
+		}
+		for point := range ch {
+			e.m.Lock()
+			e.disks = point
+			e.m.Unlock()
+		}
+		return nil
+	})
+
+	go e.follow(ctx, "nics", func(ctx context.Context) error {
+		ch, err := system.Nics(ctx)
+		if err != nil {
+			return err
+		}
+		for point := range ch {
+			e.m.Lock()
+			e.nics = point
+			e.m.Unlock()
+		}
+		return nil
+	})
+
+	go e.follow(ctx, "pools", func(ctx context.Context) error {
+		ch, err := storage.Monitor(ctx)
+		if err != nil {
+			return err
+		}
+		for point := range ch {
+			e.m.Lock()
+			e.pools = point
+			e.m.Unlock()
+		}
+		return nil
+	})
+
+	e.poll(ctx)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.poll(ctx)
+		}
+	}
+}
+
+// poll collects the metrics that are not available as streams
+func (e *Exporter) poll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	node := stubs.NewSystemMonitorStub(e.cl).NodeID(ctx)
+
+	vms, err := stubs.NewVMModuleStub(e.cl).Metrics(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get vms metrics")
+	}
+
+	gateway, err := stubs.NewGatewayStub(e.cl).Metrics(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get gateway metrics")
+	}
+
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.node = node
+	e.vms = vms
+	e.gateway = &gateway
+}
+
+func name(n string) string {
+	return fmt.Sprintf("%s_%s", namespace, n)
+}
+
+// Collect returns all the current metrics
+func (e *Exporter) Collect() []Family {
+	e.m.RLock()
+	defer e.m.RUnlock()
+
+	var families []Family
+
+	info := Family{Name: name("node_info"), Help: "Node information", Type: Gauge}
+	if e.node != 0 {
+		info.Add(1, L("node", fmt.Sprint(e.node)))
+	}
+	families = append(families, info)
+
+	if e.memory != nil {
+		families = append(families,
+			Family{Name: name("memory_total_bytes"), Help: "Total memory in bytes", Type: Gauge,
+				Samples: []Sample{{Value: float64(e.memory.Total)}}},
+			Family{Name: name("memory_used_bytes"), Help: "Used memory in bytes", Type: Gauge,
+				Samples: []Sample{{Value: float64(e.memory.Used)}}},
+			Family{Name: name("memory_available_bytes"), Help: "Available memory in bytes", Type: Gauge,
+				Samples: []Sample{{Value: float64(e.memory.Available)}}},
+		)
+	}
+
+	if e.cpu != nil {
+		seconds := Family{Name: name("cpu_seconds_total"), Help: "Seconds the cpus spent in each mode", Type: Counter}
+		seconds.Add(e.cpu.User, L("mode", "user"))
+		seconds.Add(e.cpu.System, L("mode", "system"))
+		seconds.Add(e.cpu.Idle, L("mode", "idle"))
+		seconds.Add(e.cpu.Iowait, L("mode", "iowait"))
+		seconds.Add(e.cpu.Steal, L("mode", "steal"))
+
+		families = append(families,
+			Family{Name: name("cpu_used_percent"), Help: "Cpu usage in percent", Type: Gauge,
+				Samples: []Sample{{Value: e.cpu.Percent}}},
+			seconds,
+		)
+	}
+
+	families = append(families, e.collectDisks()...)
+	families = append(families, e.collectNics()...)
+	families = append(families, e.collectPools()...)
+	families = append(families, e.collectVMs()...)
+	families = append(families, e.collectGateway()...)
+
+	return families
+}
+
+func sortedKeys(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *Exporter) collectDisks() []Family {
+	readBytes := Family{Name: name("disk_read_bytes_total"), Help: "Bytes read from the disk", Type: Counter}
+	writeBytes := Family{Name: name("disk_written_bytes_total"), Help: "Bytes written to the disk", Type: Counter}
+	reads := Family{Name: name("disk_reads_completed_total"), Help: "Reads completed by the disk", Type: Counter}
+	writes := Family{Name: name("disk_writes_completed_total"), Help: "Writes completed by the disk", Type: Counter}
+
+	var devices []string
+	for device := range e.disks {
+		devices = append(devices, device)
+	}
+
+	for _, device := range sortedKeys(devices) {
+		stat := e.disks[device]
+		readBytes.Add(float64(stat.ReadBytes), L("device", device))
+		writeBytes.Add(float64(stat.WriteBytes), L("device", device))
+		reads.Add(float64(stat.ReadCount), L("device", device))
+		writes.Add(float64(stat.WriteCount), L("device", device))
+	}
+
+	return []Family{readBytes, writeBytes, reads, writes}
+}
+
+func (e *Exporter) collectNics() []Family {
+	rx := Family{Name: name("network_receive_bytes_total"), Help: "Bytes received by the interface", Type: Counter}
+	tx := Family{Name: name("network_transmit_bytes_total"), Help: "Bytes transmitted by the interface", Type: Counter}
+	rxRate := Family{Name: name("network_receive_rate_bytes"), Help: "Receive rate of the interface in bytes per second", Type: Gauge}
+	txRate := Family{Name: name("network_transmit_rate_bytes"), Help: "Transmit rate of the interface in bytes per second", Type: Gauge}
+
+	nics := make([]pkg.NicIOCounterStat, len(e.nics))
+	copy(nics, e.nics)
+	sort.Slice(nics, func(i, j int) bool { return nics[i].Name < nics[j].Name })
+
+	for _, nic := range nics {
+		rx.Add(float64(nic.BytesRecv), L("interface", nic.Name))
+		tx.Add(float64(nic.BytesSent), L("interface", nic.Name))
+		rxRate.Add(float64(nic.RateIn), L("interface", nic.Name))
+		txRate.Add(float64(nic.RateOut), L("interface", nic.Name))
+	}
+
+	return []Family{rx, tx, rxRate, txRate}
+}
+
+func (e *Exporter) collectPools() []Family {
+	size := Family{Name: name("pool_size_bytes"), Help: "Size of the storage pool in bytes", Type: Gauge}
+	used := Family{Name: name("pool_used_bytes"), Help: "Used space of the storage pool in bytes", Type: Gauge}
+
+	var pools []string
+	for pool := range e.pools {
+		pools = append(pools, pool)
+	}
+
+	for _, pool := range sortedKeys(pools) {
+		stat := e.pools[pool]
+		size.Add(float64(stat.Total), L("pool", pool))
+		used.Add(float64(stat.Used), L("pool", pool))
+	}
+
+	return []Family{size, used}
+}
+
+func (e *Exporter) collectVMs() []Family {
+	rx := Family{Name: name("vm_network_receive_bytes_total"), Help: "Bytes received by the virtual machine", Type: Counter}
+	tx := Family{Name: name("vm_network_transmit_bytes_total"), Help: "Bytes transmitted by the virtual machine", Type: Counter}
+
+	var vms []string
+	for vm := range e.vms {
+		vms = append(vms, vm)
+	}
+
+	for _, vm := range sortedKeys(vms) {
+		metric := e.vms[vm]
+		for _, net := range []struct {
+			name   string
+			metric pkg.NetMetric
+		}{{"public", metric.Public}, {"private", metric.Private}} {
+			rx.Add(float64(net.metric.NetRxBytes), L("workload", vm), L("network", net.name))
+			tx.Add(float64(net.metric.NetTxBytes), L("workload", vm), L("network", net.name))
+		}
+	}
+
+	return []Family{rx, tx}
+}
+
+func (e *Exporter) collectGateway() []Family {
+	if e.gateway == nil {
+		return nil
+	}
+
+	requests := Family{Name: name("gateway_request_bytes_total"), Help: "Request bytes served by the gateway service", Type: Counter}
+	responses := Family{Name: name("gateway_response_bytes_total"), Help: "Response bytes served by the gateway service", Type: Counter}
+
+	add := func(family *Family, values map[string]float64) {
+		var services []string
+		for service := range values {
+			services = append(services, service)
+		}
+
+		for _, service := range sortedKeys(services) {
+			family.Add(values[service], L("service", service))
+		}
+	}
+
+	add(&requests, e.gateway.Request)
+	add(&responses, e.gateway.Response)
+
+	return []Family{requests, responses}
+}
+
+// ServeHTTP implements http.Handler
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+
+	contentType := contentTypeText
+	if openMetrics {
+		contentType = contentTypeOpenMetrics
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if err := Write(w, e.Collect(), openMetrics); err != nil {
+		log.Error().Err(err).Msg("failed to write metrics")
+	}
+}
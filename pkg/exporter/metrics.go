@@ -0,0 +1,110 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MetricType is the type of a metric family
+type MetricType string
+
+const (
+	// Gauge is a value that can go up and down
+	Gauge MetricType = "gauge"
+	// Counter is a value that only goes up (until reset)
+	Counter MetricType = "counter"
+)
+
+// Label is a metric label
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a single value of a metric family
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a group of samples with the same name. Counter names
+// must end with _total
+type Family struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []Sample
+}
+
+// Add adds a sample to the family
+func (f *Family) Add(value float64, labels ...Label) {
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+// L is a short hand to create a label
+func L(name, value string) Label {
+	return Label{Name: name, Value: value}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Write writes the families in the prometheus text format. If openMetrics
+// is set the OpenMetrics text format is used instead.
+func Write(w io.Writer, families []Family, openMetrics bool) error {
+	buf := bufio.NewWriter(w)
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+
+		name := family.Name
+		if openMetrics && family.Type == Counter {
+			// in openmetrics the family name of a counter has no _total suffix
+			name = strings.TrimSuffix(name, "_total")
+		}
+
+		fmt.Fprintf(buf, "# HELP %s %s\n", name, helpEscaper.Replace(family.Help))
+		fmt.Fprintf(buf, "# TYPE %s %s\n", name, family.Type)
+
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name)
+			if len(sample.Labels) != 0 {
+				buf.WriteByte('{')
+				for i, label := range sample.Labels {
+					if i > 0 {
+						buf.WriteByte(',')
+					}
+					fmt.Fprintf(buf, `%s="%s"`, label.Name, labelEscaper.Replace(label.Value))
+				}
+				buf.WriteByte('}')
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(formatValue(sample.Value))
+			buf.WriteByte('\n')
+		}
+	}
+
+	if openMetrics {
+		buf.WriteString("# EOF\n")
+	}
+
+	return buf.Flush()
+}
@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestWrite(t *testing.T) {
+	require := require.New(t)
+
+	read := Family{Name: "test_read_bytes_total", Help: "Bytes read", Type: Counter}
+	read.Add(10, L("device", "sda"))
+	read.Add(1.5, L("device", `sd"b`))
+
+	families := []Family{
+		{Name: "test_used", Help: "Used\nmemory", Type: Gauge, Samples: []Sample{{Value: 100}}},
+		{Name: "test_empty", Help: "Not written", Type: Gauge},
+		read,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(Write(&buf, families, false))
+	require.Equal(`# HELP test_used Used\nmemory
+# TYPE test_used gauge
+test_used 100
+# HELP test_read_bytes_total Bytes read
+# TYPE test_read_bytes_total counter
+test_read_bytes_total{device="sda"} 10
+test_read_bytes_total{device="sd\"b"} 1.5
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(Write(&buf, families, true))
+	require.Equal(`# HELP test_used Used\nmemory
+# TYPE test_used gauge
+test_used 100
+# HELP test_read_bytes Bytes read
+# TYPE test_read_bytes counter
+test_read_bytes_total{device="sda"} 10
+test_read_bytes_total{device="sd\"b"} 1.5
+# EOF
+`, buf.String())
+}
+
+func TestCollect(t *testing.T) {
+	require := require.New(t)
+
+	exp := Exporter{
+		node:   10,
+		memory: &pkg.VirtualMemoryStat{Total: 1024, Used: 512, Available: 256},
+		pools: pkg.PoolsStats{
+			"b": pkg.PoolStats{},
+			"a": pkg.PoolStats{},
+		},
+	}
+
+	families := map[string]Family{}
+	for _, family := range exp.Collect() {
+		families[family.Name] = family
+	}
+
+	require.Equal([]Sample{{Labels: []Label{L("node", "10")}, Value: 1}}, families["test_node_info"].Samples)
+	require.Equal([]Sample{{Value: 1024}}, families["test_memory_total_bytes"].Samples)
+	require.Equal([]Sample{{Value: 512}}, families["test_memory_used_bytes"].Samples)
+	require.Empty(families["test_cpu_used_percent"].Samples)
+
+	size := families["test_pool_size_bytes"].Samples
+	require.Len(size, 2)
+	require.Equal("a", size[0].Labels[0].Value)
+	require.Equal("b", size[1].Labels[0].Value)
+}
@@ -77,6 +77,15 @@ type LogsData struct {
 
 	// SecretStderr like stderr but encrypted with node public key
 	SecretStderr string `json:"secret_stderr"`
+
+	// Format of the published logs, raw (default) publishes the lines as is,
+	// json publishes every line as a record tagged with the workload id, twin
+	// and stream
+	Format string `json:"format,omitempty"`
+
+	// CA is a PEM encoded certificate used to verify the server of a
+	// rediss:// url, system certificates are used if not set
+	CA string `json:"ca,omitempty"`
 }
 
 // Stats defines a stats backend
@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"net"
 
 	"github.com/jbenet/go-base58"
 	"github.com/threefoldtech/test/pkg/gridtypes"
@@ -49,19 +50,20 @@ func NetworkIDFromWorkloadID(wl gridtypes.WorkloadID) (NetID, error) {
 // Since the user library creates all deployments upfront then all wireguard keys, and ports must be pre-determinstic and must be
 // also created upfront.
 // A network structure basically must consist of
-// - The network information (IP range) must be an ipv4 /16 range
+// - The network information (IP range) must be an ipv4 /16 range, and/or an IPv6 ULA range
 // - The local (node) peer definition (subnet of the network ip range, wireguard secure key, wireguard port if any)
 // - List of other peers that are part of the same network with their own config
 // - For each PC or a laptop (for each wireguard peer) there must be a peer in the peer list (on all nodes)
 // This is why this can get complicated.
 type Network struct {
 	// IP range of the network, must be an IPv4 /16
-	// for example a 10.1.0.0/16
+	// for example a 10.1.0.0/16. It can only be omitted
+	// for IPv6 only networks (IPv6Range is set)
 	NetworkIPRange gridtypes.IPNet `json:"ip_range"`
 
 	// IPV4 subnet for this network resource
 	// this must be a valid subnet of the entire network ip range.
-	// for example 10.1.1.0/24
+	// for example 10.1.1.0/24. Must be set if NetworkIPRange is set
 	Subnet gridtypes.IPNet `json:"subnet"`
 
 	// The private wg key of this node (this peer) which is installing this
@@ -85,6 +87,49 @@ type Network struct {
 	// if no mycelium configuration is provided, vms can't
 	// get mycelium IPs.
 	Mycelium *Mycelium `json:"mycelium,omitempty"`
+
+	// Optional IPv6 ULA range of the entire network, for example
+	// fd12:3456:789a::/48. If set, IPv6Subnet must be set as well and
+	// the network becomes dual stack, or IPv6 only if no IPv4 range is set.
+	IPv6Range gridtypes.IPNet `json:"ipv6_range"`
+
+	// IPv6Subnet is the /64 of this network resource. It must be part
+	// of the IPv6Range. The node gets the first address of the subnet (::1)
+	// and advertise the subnet to the workloads. On dual stack networks the
+	// workloads IPv6 is derived from the last byte of their IPv4, so a
+	// workload with 10.1.1.5 gets ::5 of the subnet.
+	IPv6Subnet gridtypes.IPNet `json:"ipv6_subnet"`
+
+	// Optional bandwidth limits of the network resource on this node. If not
+	// provided the node wide defaults (if any) are applied.
+	Bandwidth *Bandwidth `json:"bandwidth,omitempty"`
+}
+
+// Bandwidth defines the rate limits applied on the traffic of a network
+// resource going to (egress) and coming from (ingress) the public internet.
+// A zero value means no limit.
+type Bandwidth struct {
+	// Egress rate limit in Mbps
+	Egress uint64 `json:"egress_mbps"`
+	// Ingress rate limit in Mbps
+	Ingress uint64 `json:"ingress_mbps"`
+}
+
+func (b *Bandwidth) Challenge(w io.Writer) error {
+	// the separator makes sure (1, 23) and (12, 3) have different challenges
+	if _, err := fmt.Fprintf(w, "%d:%d", b.Egress, b.Ingress); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (b *Bandwidth) Valid() error {
+	if b.Egress == 0 && b.Ingress == 0 {
+		return fmt.Errorf("bandwidth limits cannot be all empty")
+	}
+
+	return nil
 }
 
 type MyceliumPeer string
@@ -127,14 +172,60 @@ func (c *Mycelium) Valid() error {
 	return nil
 }
 
+// ulaRange is the IPv6 unique local address range fc00::/7
+var ulaRange = net.IPNet{
+	IP:   net.ParseIP("fc00::"),
+	Mask: net.CIDRMask(7, 128),
+}
+
+// HasIPv6 returns true if the network has a private IPv6 range
+func (n *Network) HasIPv6() bool {
+	return !n.IPv6Range.Nil()
+}
+
+// HasIPv4 returns true if the network has a private IPv4 range, only
+// IPv6 only networks has no IPv4 range
+func (n *Network) HasIPv4() bool {
+	return !n.NetworkIPRange.Nil()
+}
+
+func (n *Network) validIPv6() error {
+	if n.IPv6Range.Nil() && n.IPv6Subnet.Nil() {
+		return nil
+	}
+
+	if n.IPv6Range.Nil() || n.IPv6Subnet.Nil() {
+		return fmt.Errorf("ipv6 range and ipv6 subnet must be both set")
+	}
+
+	if n.IPv6Range.IP.To4() != nil || !ulaRange.Contains(n.IPv6Range.IP) {
+		return fmt.Errorf("ipv6 range must be an IPv6 unique local address range (fc00::/7)")
+	}
+
+	ones, _ := n.IPv6Range.Mask.Size()
+	if ones < 8 || ones > 64 {
+		return fmt.Errorf("ipv6 range prefix length must be between /8 and /64")
+	}
+
+	if ones, bits := n.IPv6Subnet.Mask.Size(); ones != 64 || bits != 128 {
+		return fmt.Errorf("ipv6 subnet must be a /64")
+	}
+
+	if !n.IPv6Range.Contains(n.IPv6Subnet.IP) {
+		return fmt.Errorf("ipv6 subnet %s is not part of ipv6 range %s", n.IPv6Subnet.String(), n.IPv6Range.String())
+	}
+
+	return nil
+}
+
 // Valid checks if the network resource is valid.
 func (n Network) Valid(getter gridtypes.WorkloadGetter) error {
 
-	if n.NetworkIPRange.Nil() {
+	if !n.HasIPv4() && !n.HasIPv6() {
 		return fmt.Errorf("network IP range cannot be empty")
 	}
 
-	if len(n.Subnet.IP) == 0 {
+	if n.HasIPv4() && len(n.Subnet.IP) == 0 {
 		return fmt.Errorf("network resource subnet cannot empty")
 	}
 
@@ -154,6 +245,16 @@ func (n Network) Valid(getter gridtypes.WorkloadGetter) error {
 		}
 	}
 
+	if n.Bandwidth != nil {
+		if err := n.Bandwidth.Valid(); err != nil {
+			return err
+		}
+	}
+
+	if err := n.validIPv6(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -187,6 +288,22 @@ func (n Network) Challenge(b io.Writer) error {
 		}
 	}
 
+	if n.Bandwidth != nil {
+		if err := n.Bandwidth.Challenge(b); err != nil {
+			return err
+		}
+	}
+
+	if n.HasIPv6() {
+		if _, err := fmt.Fprintf(b, "%s", n.IPv6Range.String()); err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(b, "%s", n.IPv6Subnet.String()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -199,6 +316,10 @@ func (n Network) Capacity() (gridtypes.Capacity, error) {
 type Peer struct {
 	// IPV4 subnet of the network resource of the peer
 	Subnet gridtypes.IPNet `json:"subnet"`
+	// IPv6Subnet is the IPv6 /64 of the network resource of the peer
+	// if the network has an IPv6 range. It's always added to the peer
+	// allowed IPs
+	IPv6Subnet gridtypes.IPNet `json:"ipv6_subnet,omitempty"`
 	// WGPublicKey of the peer (driven from its private key)
 	WGPublicKey string `json:"wireguard_public_key"`
 	// Allowed Ips is related to his subnet.
@@ -210,10 +331,16 @@ type Peer struct {
 
 // Valid checks if peer is valid
 func (p *Peer) Valid() error {
-	if p.Subnet.Nil() {
+	if p.Subnet.Nil() && p.IPv6Subnet.Nil() {
 		return fmt.Errorf("peer wireguard subnet cannot empty")
 	}
 
+	if !p.IPv6Subnet.Nil() {
+		if ones, bits := p.IPv6Subnet.Mask.Size(); ones != 64 || bits != 128 {
+			return fmt.Errorf("peer ipv6 subnet must be a /64")
+		}
+	}
+
 	if len(p.AllowedIPs) <= 0 {
 		return fmt.Errorf("peer wireguard allowedIPs cannot empty")
 	}
@@ -241,5 +368,10 @@ func (p Peer) Challenge(w io.Writer) error {
 			return err
 		}
 	}
+	if !p.IPv6Subnet.Nil() {
+		if _, err := fmt.Fprintf(w, "%s", p.IPv6Subnet.String()); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -0,0 +1,90 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+)
+
+func TestNetworkIPv6Valid(t *testing.T) {
+	cases := []struct {
+		name   string
+		rng    string
+		subnet string
+		valid  bool
+	}{
+		{"none", "", "", true},
+		{"valid", "fd12:3456:789a::/48", "fd12:3456:789a:1::/64", true},
+		{"missing subnet", "fd12:3456:789a::/48", "", false},
+		{"missing range", "", "fd12:3456:789a:1::/64", false},
+		{"not ula", "2001:db8::/48", "2001:db8:0:1::/64", false},
+		{"ipv4", "10.0.0.0/16", "10.0.1.0/24", false},
+		{"subnet not /64", "fd12:3456:789a::/48", "fd12:3456:789a:1::/56", false},
+		{"subnet out of range", "fd12:3456:789a::/48", "fd12:3456:789b:1::/64", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n := Network{
+				IPv6Range:  gridtypes.MustParseIPNet(c.rng),
+				IPv6Subnet: gridtypes.MustParseIPNet(c.subnet),
+			}
+
+			err := n.validIPv6()
+			if c.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestNetworkIPv6Only(t *testing.T) {
+	n := Network{
+		WGPrivateKey: "key",
+		IPv6Range:    gridtypes.MustParseIPNet("fd12:3456:789a::/48"),
+		IPv6Subnet:   gridtypes.MustParseIPNet("fd12:3456:789a:1::/64"),
+		Peers: []Peer{
+			{
+				WGPublicKey: "peer",
+				IPv6Subnet:  gridtypes.MustParseIPNet("fd12:3456:789a:2::/64"),
+				AllowedIPs:  []gridtypes.IPNet{gridtypes.MustParseIPNet("fd12:3456:789a:2::/64")},
+			},
+		},
+	}
+
+	require.NoError(t, n.Valid(nil))
+
+	n.IPv6Range = gridtypes.IPNet{}
+	n.IPv6Subnet = gridtypes.IPNet{}
+	require.Error(t, n.Valid(nil))
+
+	n.NetworkIPRange = gridtypes.MustParseIPNet("10.1.0.0/16")
+	require.Error(t, n.Valid(nil), "ipv4 range requires a subnet")
+}
+
+func TestPeerIPv6Subnet(t *testing.T) {
+	p := Peer{
+		WGPublicKey: "peer",
+		IPv6Subnet:  gridtypes.MustParseIPNet("fd12:3456:789a:2::/56"),
+		AllowedIPs:  []gridtypes.IPNet{gridtypes.MustParseIPNet("10.1.2.0/24")},
+	}
+
+	require.Error(t, p.Valid())
+
+	p.IPv6Subnet = gridtypes.MustParseIPNet("fd12:3456:789a:2::/64")
+	require.NoError(t, p.Valid())
+}
+
+func TestBandwidthChallenge(t *testing.T) {
+	challenge := func(b Bandwidth) string {
+		var buf strings.Builder
+		require.NoError(t, b.Challenge(&buf))
+		return buf.String()
+	}
+
+	require.NotEqual(t, challenge(Bandwidth{Egress: 1, Ingress: 23}), challenge(Bandwidth{Egress: 12, Ingress: 3}))
+}
@@ -21,7 +21,8 @@ type MachineInterface struct {
 	// Network name (znet name) to join
 	Network gridtypes.Name `json:"network"`
 	// IP of the zmachine on this network must be a valid Ip in the
-	// selected network
+	// selected network. On IPv6 only networks this is an IPv6 of
+	// the network resource IPv6 subnet
 	IP net.IP `json:"ip"`
 }
 
@@ -158,6 +159,42 @@ func (g GPU) Parts() (slot, vendor, device string, err error) {
 	return parts[0], parts[1], parts[2], nil
 }
 
+// MachineSRIOV requests a virtual function of an SR-IOV capable network
+// card to be passed through to the VM. The node picks a free virtual function
+// on the requested physical function and sets its mac address and vlan.
+type MachineSRIOV struct {
+	// PF is the physical function id in the format <slot>/<vendor>/<device>
+	// as listed via the node rmb API.
+	PF string `json:"pf"`
+	// VLAN tag the virtual function traffic is tagged with. 0 means untagged
+	VLAN uint16 `json:"vlan"`
+}
+
+// Challenge builder
+func (s *MachineSRIOV) Challenge(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s", s.PF); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "%d", s.VLAN); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MachineVF is a virtual function assigned to a VM
+type MachineVF struct {
+	// PF is the physical function id the virtual function belongs to
+	PF string `json:"pf"`
+	// Slot of the virtual function
+	Slot string `json:"slot"`
+	// Index of the virtual function on the physical function
+	Index int `json:"index"`
+	// MAC address assigned to the virtual function
+	MAC string `json:"mac"`
+}
+
 // ZMachine reservation data
 type ZMachine struct {
 	// Flist of the zmachine, must be a valid url to an flist.
@@ -189,6 +226,10 @@ type ZMachine struct {
 	// - Not used by other VMs
 	// - Only possible on `dedicated` nodes
 	GPU []GPU `json:"gpu,omitempty"`
+
+	// SRIOV virtual functions attached to the VM
+	// - Only possible on `dedicated` nodes
+	SRIOV []MachineSRIOV `json:"sriov,omitempty"`
 }
 
 func (m *ZMachine) MinRootSize() gridtypes.Unit {
@@ -278,6 +319,16 @@ func (v ZMachine) Valid(getter gridtypes.WorkloadGetter) error {
 		}
 	}
 
+	for _, vf := range v.SRIOV {
+		if len(strings.Split(vf.PF, "/")) != 3 {
+			return fmt.Errorf("invalid sriov physical function id format '%s'", vf.PF)
+		}
+
+		if vf.VLAN > 4094 {
+			return fmt.Errorf("invalid vlan '%d' for sriov physical function '%s'", vf.VLAN, vf.PF)
+		}
+	}
+
 	return nil
 }
 
@@ -344,6 +395,12 @@ func (v ZMachine) Challenge(b io.Writer) error {
 		}
 	}
 
+	for _, vf := range v.SRIOV {
+		if err := vf.Challenge(b); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -354,16 +411,19 @@ type ZMachineResult struct {
 	PlanetaryIP string `json:"planetary_ip"`
 	MyceliumIP  string `json:"mycelium_ip"`
 	ConsoleURL  string `json:"console_url"`
+	// VFs are the sriov virtual functions assigned to the machine
+	VFs []MachineVF `json:"vfs,omitempty"`
 }
 
 func (r *ZMachineResult) UnmarshalJSON(data []byte) error {
 	var deprecated struct {
-		ID          string `json:"id"`
-		IP          string `json:"ip"`
-		YggIP       string `json:"ygg_ip"`
-		PlanetaryIP string `json:"planetary_ip"`
-		MyceliumIP  string `json:"mycelium_ip"`
-		ConsoleURL  string `json:"console_url"`
+		ID          string      `json:"id"`
+		IP          string      `json:"ip"`
+		YggIP       string      `json:"ygg_ip"`
+		PlanetaryIP string      `json:"planetary_ip"`
+		MyceliumIP  string      `json:"mycelium_ip"`
+		ConsoleURL  string      `json:"console_url"`
+		VFs         []MachineVF `json:"vfs,omitempty"`
 	}
 
 	if err := json.Unmarshal(data, &deprecated); err != nil {
@@ -378,6 +438,7 @@ func (r *ZMachineResult) UnmarshalJSON(data []byte) error {
 	}
 	r.MyceliumIP = deprecated.MyceliumIP
 	r.ConsoleURL = deprecated.ConsoleURL
+	r.VFs = deprecated.VFs
 
 	return nil
 }
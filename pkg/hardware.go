@@ -0,0 +1,119 @@
+package pkg
+
+//go:generate mkdir -p stubs
+//go:generate zbusc -module node -version 0.0.1 -name hardware -package stubs github.com/threefoldtech/test/pkg+HardwareManager stubs/hardware_manager_stub.go
+
+// HardwareCPU is the cpu information of the node
+type HardwareCPU struct {
+	Model   string   `json:"model"`
+	Sockets uint32   `json:"sockets"`
+	Cores   uint32   `json:"cores"`
+	Threads uint32   `json:"threads"`
+	Flags   []string `json:"flags"`
+}
+
+// HardwareDIMM is an installed memory module
+type HardwareDIMM struct {
+	Locator      string `json:"locator"`
+	Size         uint64 `json:"size"`
+	Type         string `json:"type"`
+	Speed        string `json:"speed"`
+	Manufacturer string `json:"manufacturer"`
+	Serial       string `json:"serial"`
+	PartNumber   string `json:"part_number"`
+}
+
+// HardwareNIC is a physical network interface
+type HardwareNIC struct {
+	Name   string `json:"name"`
+	Mac    string `json:"mac"`
+	Driver string `json:"driver"`
+	// Speed is the link speed in Mbps, 0 if the link is down or unknown
+	Speed uint32 `json:"speed"`
+	Up    bool   `json:"up"`
+}
+
+// HardwareDisk is a disk as reported by smartctl
+type HardwareDisk struct {
+	Path     string `json:"path"`
+	Model    string `json:"model"`
+	Serial   string `json:"serial"`
+	Capacity string `json:"capacity"`
+	// Health is the smart overall health (for example PASSED, FAILED or OK),
+	// empty if smart is not supported by the device
+	Health string `json:"health"`
+}
+
+// Failed returns true if smart reports the disk as failing
+func (d *HardwareDisk) Failed() bool {
+	return len(d.Health) != 0 && d.Health != "PASSED" && d.Health != "OK"
+}
+
+// HardwarePCI is a pci device
+type HardwarePCI struct {
+	Slot   string `json:"slot"`
+	Vendor string `json:"vendor"`
+	Device string `json:"device"`
+	Class  string `json:"class"`
+	// Name is the vendor and device name from the pci database
+	Name string `json:"name"`
+	// IOMMUGroup of the device, -1 if iommu is not enabled
+	IOMMUGroup int `json:"iommu_group"`
+}
+
+// HardwareInventory is the hardware of the node
+type HardwareInventory struct {
+	// Timestamp when the inventory was collected
+	Timestamp int64          `json:"timestamp"`
+	CPU       HardwareCPU    `json:"cpu"`
+	Memory    []HardwareDIMM `json:"memory"`
+	NICs      []HardwareNIC  `json:"nics"`
+	Disks     []HardwareDisk `json:"disks"`
+	PCI       []HardwarePCI  `json:"pci"`
+	// Missing are the components that failed to be collected, they
+	// keep the value of the last inventory where they were collected
+	Missing []HardwareComponent `json:"missing,omitempty"`
+}
+
+// HardwareEventKind is the kind of a hardware change
+type HardwareEventKind string
+
+const (
+	HardwareAdded   HardwareEventKind = "added"
+	HardwareRemoved HardwareEventKind = "removed"
+	HardwareFailed  HardwareEventKind = "failed"
+	HardwareChanged HardwareEventKind = "changed"
+)
+
+// HardwareComponent is the kind of a hardware component
+type HardwareComponent string
+
+const (
+	HardwareComponentCPU    HardwareComponent = "cpu"
+	HardwareComponentMemory HardwareComponent = "memory"
+	HardwareComponentNIC    HardwareComponent = "nic"
+	HardwareComponentDisk   HardwareComponent = "disk"
+	HardwareComponentPCI    HardwareComponent = "pci"
+)
+
+// HardwareEvent is a hardware change detected between two inventories
+type HardwareEvent struct {
+	// Offset of the event in the log
+	Offset    uint64            `json:"offset"`
+	Timestamp int64             `json:"timestamp"`
+	Kind      HardwareEventKind `json:"kind"`
+	Component HardwareComponent `json:"component"`
+	// ID identifies the component (for example the disk serial or nic mac)
+	ID      string `json:"id"`
+	Details string `json:"details"`
+}
+
+// HardwareManager keeps the hardware inventory of the node. The inventory
+// is collected on every boot and compared with the previous one, changes are
+// recorded as events (provided by noded)
+type HardwareManager interface {
+	// Inventory returns the last collected inventory
+	Inventory() (HardwareInventory, error)
+	// Events returns the last count hardware events, 0 means all
+	Events(count uint32) ([]HardwareEvent, error)
+}
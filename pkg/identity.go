@@ -64,7 +64,8 @@ type IdentityManager interface {
 	// DecryptECDH decrypt aes encrypted msg using a shared key derived from private key of the node and public key of the other party using Elliptic curve Diffie Helman algorithm
 	DecryptECDH(msg []byte, publicKey []byte) ([]byte, error)
 
-	// PrivateKey sends the keypair
+	// PrivateKey sends the keypair. It returns nil if the key is held by an
+	// external signer, so callers should prefer the signing methods above
 	PrivateKey() []byte
 }
 
@@ -1,23 +1,33 @@
 package identity
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"strings"
 
+	"github.com/jbenet/go-base58"
 	"github.com/rs/zerolog/log"
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/test/pkg/crypto"
+	"github.com/threefoldtech/test/pkg/identity/signer"
 	"github.com/threefoldtech/test/pkg/identity/store"
+	"github.com/threefoldtech/test/pkg/kernel"
 
 	"github.com/pkg/errors"
 	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/environment"
 )
 
+// pkcs11Prefix marks a test-signer kernel param value as the path of a
+// PKCS#11 module instead of a signer socket
+const pkcs11Prefix = "pkcs11:"
+
 type identityManager struct {
-	kind string
-	key  KeyPair
-	sub  substrate.Manager
-	env  environment.Environment
+	kind   string
+	signer signer.Signer
+	pk     ed25519.PublicKey
+	sub    substrate.Manager
+	env    environment.Environment
 
 	farm string
 }
@@ -29,10 +39,83 @@ type identityManager struct {
 // mode. Right now only the key store uses this flag. In case of debug migrated keys
 // to tpm are not deleted from disks. This allow switching back and forth between tpm
 // and non-tpm key stores.
+// If the test-signer kernel param is set, the key is held by the external signer
+// (a signer process listening on a unix socket, or a PKCS#11 token) and the key
+// store is not used.
 func NewManager(root string, debug bool) (pkg.IdentityManager, error) {
+	var (
+		sig  signer.Signer
+		kind string
+	)
+
+	params := kernel.GetParams()
+	if value, ok := params.GetOne(kernel.Signer); ok {
+		var err error
+		sig, err = externalSigner(params, value)
+		if err != nil {
+			return nil, err
+		}
+		kind = sig.Kind()
+	} else {
+		key, storeKind, err := loadKey(root, debug)
+		if err != nil {
+			return nil, err
+		}
+		sig = signer.NewKeySigner(key)
+		kind = storeKind
+	}
+
+	pk, err := sig.PublicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get node public key")
+	}
+
+	sub, err := environment.GetSubstrate()
+	if err != nil {
+		return nil, err
+	}
+	env, err := environment.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	return &identityManager{
+		kind:   kind,
+		signer: sig,
+		pk:     pk,
+		sub:    sub,
+		env:    env,
+	}, nil
+}
+
+// externalSigner creates the signer configured by the test-signer kernel param
+func externalSigner(params kernel.Params, value string) (signer.Signer, error) {
+	module, ok := strings.CutPrefix(value, pkcs11Prefix)
+	if !ok {
+		log.Info().Str("socket", value).Msg("using external signer")
+		return signer.NewSocketSigner(value), nil
+	}
+
+	cfg := signer.PKCS11Config{Module: module}
+	cfg.Token, _ = params.GetOne(kernel.SignerToken)
+	cfg.Key, _ = params.GetOne(kernel.SignerKey)
+	cfg.Pin, _ = params.GetOne(kernel.SignerPin)
+
+	sig, err := signer.NewPKCS11Signer(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pkcs11 signer")
+	}
+
+	log.Info().Str("module", module).Str("token", cfg.Token).Str("key", cfg.Key).Msg("using pkcs11 signer")
+	return sig, nil
+}
+
+// loadKey loads the node key from the key store, a new key is generated
+// and stored if the store is empty
+func loadKey(root string, debug bool) (ed25519.PrivateKey, string, error) {
 	st, err := NewStore(root, !debug)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create key store")
+		return nil, "", errors.Wrap(err, "failed to create key store")
 	}
 	log.Info().Str("kind", st.Kind()).Msg("key store loaded")
 	key, err := st.Get()
@@ -40,36 +123,22 @@ func NewManager(root string, debug bool) (pkg.IdentityManager, error) {
 	if errors.Is(err, store.ErrKeyDoesNotExist) {
 		pair, err = GenerateKeyPair()
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to generate key pair")
+			return nil, "", errors.Wrap(err, "failed to generate key pair")
 		}
 		if err := st.Set(pair.PrivateKey); err != nil {
-			return nil, errors.Wrap(err, "failed to persist key seed")
+			return nil, "", errors.Wrap(err, "failed to persist key seed")
 		}
 	} else if err != nil {
 		log.Error().Err(err).Msg("failed to load key. to recover the key data will be deleted and regenerated")
 		if err := st.Annihilate(); err != nil {
 			log.Error().Err(err).Msg("failed to clean up key store")
 		}
-		return nil, errors.Wrap(err, "failed to load seed")
+		return nil, "", errors.Wrap(err, "failed to load seed")
 	} else {
 		pair = KeyPairFromKey(key)
 	}
 
-	sub, err := environment.GetSubstrate()
-	if err != nil {
-		return nil, err
-	}
-	env, err := environment.Get()
-	if err != nil {
-		return nil, err
-	}
-
-	return &identityManager{
-		kind: st.Kind(),
-		key:  pair,
-		sub:  sub,
-		env:  env,
-	}, nil
+	return pair.PrivateKey, st.Kind(), nil
 }
 
 // StoreKind returns store kind
@@ -79,16 +148,16 @@ func (d *identityManager) StoreKind() string {
 
 // NodeID returns the node identity
 func (d *identityManager) NodeID() pkg.StrIdentifier {
-	return pkg.StrIdentifier(d.key.Identity())
+	return pkg.StrIdentifier(base58.Encode(d.pk))
 }
 
 // NodeID returns the node identity
 func (d *identityManager) Address() (pkg.Address, error) {
-	id, err := substrate.NewIdentityFromEd25519Key(d.key.PrivateKey)
+	address, err := substrate.FromKeyBytes(d.pk)
 	if err != nil {
 		return "", err
 	}
-	return pkg.Address(id.Address()), nil
+	return pkg.Address(address), nil
 }
 
 func (d *identityManager) Farm() (string, error) {
@@ -133,36 +202,43 @@ func (d *identityManager) FarmSecret() (string, error) {
 
 // Sign signs the message with privateKey and returns a signature.
 func (d *identityManager) Sign(message []byte) ([]byte, error) {
-	return crypto.Sign(d.key.PrivateKey, message)
+	return d.signer.Sign(message)
 }
 
 // Verify reports whether sig is a valid signature of message by publicKey.
 func (d *identityManager) Verify(message, sig []byte) error {
-	return crypto.Verify(d.key.PublicKey, message, sig)
+	return crypto.Verify(d.pk, message, sig)
 }
 
 // Encrypt encrypts message with the public key of the node
 func (d *identityManager) Encrypt(message []byte) ([]byte, error) {
-	return crypto.Encrypt(message, d.key.PublicKey)
+	return crypto.Encrypt(message, d.pk)
 }
 
 // Decrypt decrypts message with the private of the node
 func (d *identityManager) Decrypt(message []byte) ([]byte, error) {
-	return crypto.Decrypt(message, d.key.PrivateKey)
+	return crypto.DecryptX25519(message, d.pk, d.signer.X25519)
 }
 
 // EncryptECDH encrypt msg using AES with shared key derived from private key of the node and public key of the other party using Elliptic curve Diffie Helman algorithm
 // the nonce if prepended to the encrypted message
 func (d *identityManager) EncryptECDH(msg []byte, pk []byte) ([]byte, error) {
-	return crypto.EncryptECDH(msg, d.key.PrivateKey, pk)
+	return crypto.EncryptECDHX25519(msg, d.signer.X25519, pk)
 }
 
 // DecryptECDH decrypt AES encrypted msg using a shared key derived from private key of the node and public key of the other party using Elliptic curve Diffie Helman algorithm
 func (d *identityManager) DecryptECDH(msg []byte, pk []byte) ([]byte, error) {
-	return crypto.DecryptECDH(msg, d.key.PrivateKey, pk)
+	return crypto.DecryptECDHX25519(msg, d.signer.X25519, pk)
 }
 
-// PrivateKey returns the private key of the node
+// PrivateKey returns the private key of the node. It returns nil if the
+// key is held by an external signer, in that case the private key never
+// leaves the signer and all operations must go through the signing methods.
 func (d *identityManager) PrivateKey() []byte {
-	return d.key.PrivateKey
+	holder, ok := d.signer.(signer.KeyHolder)
+	if !ok {
+		return nil
+	}
+
+	return holder.PrivateKey()
 }
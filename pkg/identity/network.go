@@ -0,0 +1,33 @@
+package identity
+
+import (
+	"context"
+	"crypto/ed25519"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// networkKeyMessage is signed by the node to derive its overlay network key
+const networkKeyMessage = "test:overlay-network-key"
+
+// KeyProvider is the part of the identity manager (stub) needed to get
+// the network key
+type KeyProvider interface {
+	Sign(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// NetworkKey returns the key used by the overlay networks (yggdrasil and
+// mycelium). The key is derived from the (deterministic) node signature of
+// a fixed message, so the overlay addresses are stable and the same whether
+// the node key is kept by test or held by an external signer. The node key
+// itself never leaves the signer.
+func NetworkKey(ctx context.Context, provider KeyProvider) (ed25519.PrivateKey, error) {
+	signature, err := provider.Sign(ctx, []byte(networkKeyMessage))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign network key message")
+	}
+
+	seed := blake2b.Sum256(signature)
+	return ed25519.NewKeyFromSeed(seed[:]), nil
+}
@@ -0,0 +1,42 @@
+package signer
+
+import (
+	"crypto/ed25519"
+
+	"github.com/threefoldtech/test/pkg/crypto"
+)
+
+// KeySigner signs with a private key kept in memory
+type KeySigner struct {
+	key ed25519.PrivateKey
+}
+
+var (
+	_ Signer    = (*KeySigner)(nil)
+	_ KeyHolder = (*KeySigner)(nil)
+)
+
+// NewKeySigner creates a signer from a private key
+func NewKeySigner(key ed25519.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (k *KeySigner) Kind() string {
+	return "key-signer"
+}
+
+func (k *KeySigner) PublicKey() (ed25519.PublicKey, error) {
+	return k.key.Public().(ed25519.PublicKey), nil
+}
+
+func (k *KeySigner) Sign(message []byte) ([]byte, error) {
+	return crypto.Sign(k.key, message)
+}
+
+func (k *KeySigner) X25519(point []byte) ([]byte, error) {
+	return crypto.LocalX25519(k.key)(point)
+}
+
+func (k *KeySigner) PrivateKey() ed25519.PrivateKey {
+	return k.key
+}
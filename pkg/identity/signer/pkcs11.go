@@ -0,0 +1,211 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+const (
+	// the edwards curve definitions were added in PKCS#11 v3.0 and are
+	// not part of the pkcs11 package yet
+	ckkECEdwards = 0x00000040
+	ckmEdDSA     = 0x00001057
+
+	// asn1 tag of an octet string, a token can return the CKA_EC_POINT
+	// of an edwards key either raw or DER encoded as an octet string
+	asn1OctetString = 0x04
+)
+
+// PKCS11Config is the configuration of a PKCS#11 signer
+type PKCS11Config struct {
+	// Module is the path of the PKCS#11 module (shared library) of the token
+	Module string
+	// Token is the label of the token that holds the key
+	Token string
+	// Key is the label of the ed25519 key pair on the token
+	Key string
+	// Pin is the user pin of the token
+	Pin string
+}
+
+// PKCS11Signer signs with an ed25519 key held by a PKCS#11 token (an HSM).
+// The private key never leaves the token.
+type PKCS11Signer struct {
+	ctx *pkcs11.Ctx
+
+	m       sync.Mutex
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	pk      ed25519.PublicKey
+}
+
+var _ Signer = (*PKCS11Signer)(nil)
+
+// NewPKCS11Signer loads the PKCS#11 module, logs into the token and looks up
+// the key pair with the configured label
+func NewPKCS11Signer(cfg PKCS11Config) (*PKCS11Signer, error) {
+	ctx := pkcs11.New(cfg.Module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load pkcs11 module '%s'", cfg.Module)
+	}
+
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, errors.Wrap(err, "failed to initialize pkcs11 module")
+	}
+
+	s := &PKCS11Signer{ctx: ctx}
+	if err := s.open(cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *PKCS11Signer) open(cfg PKCS11Config) error {
+	slot, err := s.slot(cfg.Token)
+	if err != nil {
+		return err
+	}
+
+	s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return errors.Wrap(err, "failed to open pkcs11 session")
+	}
+
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, cfg.Pin); err != nil {
+		return errors.Wrapf(err, "failed to login to token '%s'", cfg.Token)
+	}
+
+	s.key, err = s.find(pkcs11.CKO_PRIVATE_KEY, cfg.Key)
+	if err != nil {
+		return err
+	}
+
+	public, err := s.find(pkcs11.CKO_PUBLIC_KEY, cfg.Key)
+	if err != nil {
+		return err
+	}
+
+	attrs, err := s.ctx.GetAttributeValue(s.session, public, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to read public key")
+	}
+
+	s.pk, err = edwardsPoint(attrs[0].Value)
+	return err
+}
+
+// slot returns the slot of the token with the given label
+func (s *PKCS11Signer) slot(token string) (uint, error) {
+	slots, err := s.ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list pkcs11 slots")
+	}
+
+	for _, slot := range slots {
+		info, err := s.ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to get info of slot '%d'", slot)
+		}
+
+		if info.Label == token {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("token '%s' not found", token)
+}
+
+// find returns the ed25519 key object of class with the given label
+func (s *PKCS11Signer) find(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, ckkECEdwards),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, errors.Wrap(err, "failed to search key")
+	}
+	defer s.ctx.FindObjectsFinal(s.session)
+
+	objects, _, err := s.ctx.FindObjects(s.session, 1)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to search key")
+	}
+
+	if len(objects) == 0 {
+		return 0, fmt.Errorf("ed25519 key '%s' not found", label)
+	}
+
+	return objects[0], nil
+}
+
+// edwardsPoint decodes the CKA_EC_POINT value of an ed25519 public key
+func edwardsPoint(value []byte) (ed25519.PublicKey, error) {
+	if len(value) == ed25519.PublicKeySize+2 &&
+		value[0] == asn1OctetString && value[1] == ed25519.PublicKeySize {
+		value = value[2:]
+	}
+
+	if len(value) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("token returned public key of wrong size %d", len(value))
+	}
+
+	return ed25519.PublicKey(value), nil
+}
+
+func (s *PKCS11Signer) Kind() string {
+	return "pkcs11-signer"
+}
+
+func (s *PKCS11Signer) PublicKey() (ed25519.PublicKey, error) {
+	return s.pk, nil
+}
+
+func (s *PKCS11Signer) Sign(message []byte) ([]byte, error) {
+	// a session can only run one operation at a time
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	mechanism := []*pkcs11.Mechanism{pkcs11.NewMechanism(ckmEdDSA, nil)}
+	if err := s.ctx.SignInit(s.session, mechanism, s.key); err != nil {
+		return nil, errors.Wrap(err, "failed to initialize signing")
+	}
+
+	signature, err := s.ctx.Sign(s.session, message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign message")
+	}
+
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("token returned signature of wrong size %d", len(signature))
+	}
+
+	return signature, nil
+}
+
+// X25519 is not supported, PKCS#11 has no mechanism to derive a shared
+// key from an ed25519 key
+func (s *PKCS11Signer) X25519(point []byte) ([]byte, error) {
+	return nil, fmt.Errorf("x25519 is not supported by the pkcs11 signer")
+}
+
+// Close logs out of the token and unloads the module
+func (s *PKCS11Signer) Close() {
+	if s.session != 0 {
+		_ = s.ctx.Logout(s.session)
+		_ = s.ctx.CloseSession(s.session)
+	}
+
+	_ = s.ctx.Finalize()
+	s.ctx.Destroy()
+}
@@ -0,0 +1,26 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdwardsPoint(t *testing.T) {
+	pk, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	// raw point
+	decoded, err := edwardsPoint(pk)
+	require.NoError(t, err)
+	require.Equal(t, pk, decoded)
+
+	// DER encoded octet string
+	decoded, err = edwardsPoint(append([]byte{asn1OctetString, ed25519.PublicKeySize}, pk...))
+	require.NoError(t, err)
+	require.Equal(t, pk, decoded)
+
+	_, err = edwardsPoint(pk[:16])
+	require.Error(t, err)
+}
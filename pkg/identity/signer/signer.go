@@ -0,0 +1,29 @@
+/*
+Signer implements the private key operations of the node identity. The key
+is either kept in memory, held by a PKCS#11 token (an HSM), or held by an
+external signer process that test talks to over a unix socket. In the last two
+cases the private key never leaves the signer.
+*/
+package signer
+
+import (
+	"crypto/ed25519"
+)
+
+type Signer interface {
+	// Kind returns signer kind
+	Kind() string
+	// PublicKey returns the public key of the signing key
+	PublicKey() (ed25519.PublicKey, error)
+	// Sign signs the message with the private key
+	Sign(message []byte) ([]byte, error)
+	// X25519 multiplies point with the curve25519 form of the private key.
+	// This is all what is needed to derive the shared keys used for encryption
+	X25519(point []byte) ([]byte, error)
+}
+
+// KeyHolder is implemented by signers that have access to the private key
+// itself. An external signer does not implement it.
+type KeyHolder interface {
+	PrivateKey() ed25519.PrivateKey
+}
@@ -0,0 +1,199 @@
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// The signer protocol is a single json request followed by a single json
+// response over a unix socket connection. byte fields are base64 encoded
+// (as done by encoding/json).
+//
+//	-> {"method": "sign", "data": "<message>"}
+//	<- {"data": "<signature>"}
+//
+// on failure the response has an error set instead
+//
+//	<- {"error": "some error"}
+const (
+	// MethodPublicKey returns the ed25519 public key of the signer
+	MethodPublicKey = "public_key"
+	// MethodSign signs data with the ed25519 private key
+	MethodSign = "sign"
+	// MethodX25519 multiplies data (a curve25519 point) with the
+	// curve25519 form of the private key
+	MethodX25519 = "x25519"
+
+	socketTimeout = 10 * time.Second
+)
+
+// Request is a signer request
+type Request struct {
+	Method string `json:"method"`
+	Data   []byte `json:"data,omitempty"`
+}
+
+// Response is a signer response
+type Response struct {
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// SocketSigner delegates the private key operations to a signer process
+// listening on a unix socket
+type SocketSigner struct {
+	path string
+
+	m  sync.Mutex
+	pk ed25519.PublicKey
+}
+
+var _ Signer = (*SocketSigner)(nil)
+
+// NewSocketSigner creates a signer that talks to the signer process
+// listening on the unix socket at path
+func NewSocketSigner(path string) *SocketSigner {
+	return &SocketSigner{path: path}
+}
+
+func (s *SocketSigner) Kind() string {
+	return "socket-signer"
+}
+
+func (s *SocketSigner) call(method string, data []byte) ([]byte, error) {
+	con, err := net.DialTimeout("unix", s.path, socketTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to signer at '%s'", s.path)
+	}
+	defer con.Close()
+
+	if err := con.SetDeadline(time.Now().Add(socketTimeout)); err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(con).Encode(Request{Method: method, Data: data}); err != nil {
+		return nil, errors.Wrap(err, "failed to send signer request")
+	}
+
+	var response Response
+	if err := json.NewDecoder(con).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "failed to read signer response")
+	}
+
+	if len(response.Error) != 0 {
+		return nil, fmt.Errorf("signer error: %s", response.Error)
+	}
+
+	return response.Data, nil
+}
+
+// PublicKey returns the public key of the signer, the key is
+// cached after the first successful call
+func (s *SocketSigner) PublicKey() (ed25519.PublicKey, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.pk != nil {
+		return s.pk, nil
+	}
+
+	data, err := s.call(MethodPublicKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("signer returned public key of wrong size %d", len(data))
+	}
+
+	s.pk = ed25519.PublicKey(data)
+	return s.pk, nil
+}
+
+func (s *SocketSigner) Sign(message []byte) ([]byte, error) {
+	signature, err := s.call(MethodSign, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(signature) != ed25519.SignatureSize {
+		return nil, fmt.Errorf("signer returned signature of wrong size %d", len(signature))
+	}
+
+	return signature, nil
+}
+
+func (s *SocketSigner) X25519(point []byte) ([]byte, error) {
+	return s.call(MethodX25519, point)
+}
+
+func handle(con net.Conn, signer Signer) {
+	defer con.Close()
+
+	if err := con.SetDeadline(time.Now().Add(socketTimeout)); err != nil {
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(con).Decode(&request); err != nil {
+		log.Error().Err(err).Msg("failed to read signer request")
+		return
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	switch request.Method {
+	case MethodPublicKey:
+		data, err = signer.PublicKey()
+	case MethodSign:
+		data, err = signer.Sign(request.Data)
+	case MethodX25519:
+		data, err = signer.X25519(request.Data)
+	default:
+		err = fmt.Errorf("unknown method '%s'", request.Method)
+	}
+
+	var response Response
+	if err != nil {
+		response.Error = err.Error()
+	} else {
+		response.Data = data
+	}
+
+	if err := json.NewEncoder(con).Encode(response); err != nil {
+		log.Error().Err(err).Msg("failed to send signer response")
+	}
+}
+
+// Serve serves signer requests on listener until the context is cancelled.
+// It's the server side of the SocketSigner and can be used to build a signer
+// process around any Signer implementation
+func Serve(ctx context.Context, listener net.Listener, signer Signer) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	for {
+		con, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "failed to accept connection")
+		}
+
+		go handle(con, signer)
+	}
+}
@@ -0,0 +1,61 @@
+package signer
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/crypto"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestSocketSigner(t *testing.T) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir, err := os.MkdirTemp("", "signer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	local := NewKeySigner(sk)
+	go func() {
+		_ = Serve(ctx, listener, local)
+	}()
+
+	remote := NewSocketSigner(path)
+
+	public, err := remote.PublicKey()
+	require.NoError(t, err)
+	assert.Equal(t, pk, public)
+
+	message := []byte("hello world")
+	signature, err := remote.Sign(message)
+	require.NoError(t, err)
+	assert.NoError(t, crypto.Verify(pk, message, signature))
+
+	otherPk, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	point := crypto.PublicKeyToCurve25519(otherPk)
+
+	expected, err := local.X25519(point[:])
+	require.NoError(t, err)
+	shared, err := remote.X25519(point[:])
+	require.NoError(t, err)
+	assert.Equal(t, expected, shared)
+
+	// the signer error is returned to the caller
+	_, err = remote.X25519([]byte("short"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,83 @@
+package signer
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/pkg/errors"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/vedhavyas/go-subkey"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// maxSignedSize is the size above which the chain signs the blake2b
+	// hash of a payload instead of the payload itself
+	maxSignedSize = 256
+)
+
+// ErrNoKeyPair is returned by the KeyPair method of a signer identity
+var ErrNoKeyPair = fmt.Errorf("key pair is held by the signer")
+
+// Identity is a chain identity that signs with a Signer. It can be used
+// anywhere a substrate.Identity is only used to sign, like the chain
+// extrinsics and the rmb messages.
+type Identity struct {
+	signer  Signer
+	pk      ed25519.PublicKey
+	address string
+}
+
+var _ substrate.Identity = (*Identity)(nil)
+
+// NewIdentity creates a chain identity that signs with signer
+func NewIdentity(signer Signer) (*Identity, error) {
+	pk, err := signer.PublicKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get signer public key")
+	}
+
+	address, err := substrate.FromKeyBytes(pk)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get signer address")
+	}
+
+	return &Identity{signer: signer, pk: pk, address: address}, nil
+}
+
+// KeyPair always fails since the private key is held by the signer
+func (i *Identity) KeyPair() (subkey.KeyPair, error) {
+	return nil, ErrNoKeyPair
+}
+
+// Sign signs data the same way a chain ed25519 identity does
+func (i *Identity) Sign(data []byte) ([]byte, error) {
+	if len(data) > maxSignedSize {
+		h := blake2b.Sum256(data)
+		data = h[:]
+	}
+
+	return i.signer.Sign(data)
+}
+
+func (i *Identity) Type() string {
+	return "ed25519"
+}
+
+func (i *Identity) MultiSignature(sig []byte) types.MultiSignature {
+	return types.MultiSignature{IsEd25519: true, AsEd25519: types.NewSignature(sig)}
+}
+
+func (i *Identity) Address() string {
+	return i.address
+}
+
+func (i *Identity) PublicKey() []byte {
+	return i.pk
+}
+
+// URI is empty since there is no secret uri for the key
+func (i *Identity) URI() string {
+	return ""
+}
@@ -0,0 +1,47 @@
+package signer
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestIdentity(t *testing.T) {
+	require := require.New(t)
+
+	_, sk, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	expected, err := substrate.NewIdentityFromEd25519Key(sk)
+	require.NoError(err)
+
+	identity, err := NewIdentity(NewKeySigner(sk))
+	require.NoError(err)
+
+	require.Equal(expected.Address(), identity.Address())
+	require.Equal(expected.PublicKey(), identity.PublicKey())
+	require.Equal(expected.Type(), identity.Type())
+
+	_, err = identity.KeyPair()
+	require.ErrorIs(err, ErrNoKeyPair)
+
+	short := []byte("extrinsic payload")
+	long := make([]byte, maxSignedSize+1)
+	_, err = rand.Read(long)
+	require.NoError(err)
+
+	// ed25519 signatures are deterministic so both identities must produce
+	// the same signatures
+	for _, data := range [][]byte{short, long} {
+		signature, err := identity.Sign(data)
+		require.NoError(err)
+
+		sig, err := expected.Sign(data)
+		require.NoError(err)
+		require.Equal(sig, signature)
+		require.Equal(expected.MultiSignature(sig), identity.MultiSignature(signature))
+	}
+}
@@ -0,0 +1,52 @@
+package signer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/threefoldtech/test/pkg/crypto"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	zbusTimeout = 30 * time.Second
+)
+
+// ZbusSigner signs with the node identity through identityd, so other
+// modules can sign with the node key wherever it's held
+type ZbusSigner struct {
+	identity *stubs.IdentityManagerStub
+}
+
+var _ Signer = (*ZbusSigner)(nil)
+
+// NewZbusSigner creates a signer that uses the identityd stub
+func NewZbusSigner(identity *stubs.IdentityManagerStub) *ZbusSigner {
+	return &ZbusSigner{identity: identity}
+}
+
+func (z *ZbusSigner) Kind() string {
+	return "zbus-signer"
+}
+
+func (z *ZbusSigner) PublicKey() (ed25519.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), zbusTimeout)
+	defer cancel()
+
+	return crypto.KeyFromID(z.identity.NodeID(ctx))
+}
+
+func (z *ZbusSigner) Sign(message []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), zbusTimeout)
+	defer cancel()
+
+	return z.identity.Sign(ctx, message)
+}
+
+// X25519 is not exposed by identityd, the ECDH methods of identityd should
+// be used instead
+func (z *ZbusSigner) X25519(point []byte) ([]byte, error) {
+	return nil, fmt.Errorf("x25519 is not supported by the zbus signer")
+}
@@ -22,6 +22,21 @@ const (
 	// This allows the node to work without ssd disk. If ssd disk is available
 	// it will still be preferred for workloads. Otherwise fall back on HDD
 	MissingSSD = "missing-ssd"
+
+	// Exporter enables the prometheus metrics exporter on the node
+	Exporter = "test-exporter"
+
+	// Signer is the external signer that holds the node identity key. It's
+	// either the path of the unix socket of a signer process, for example
+	// test-signer=/var/run/signer.sock, or the path of the PKCS#11 module of
+	// an HSM, for example test-signer=pkcs11:/usr/lib/libsofthsm2.so
+	Signer = "test-signer"
+	// SignerToken is the label of the PKCS#11 token that holds the key
+	SignerToken = "test-signer-token"
+	// SignerKey is the label of the ed25519 key on the PKCS#11 token
+	SignerKey = "test-signer-key"
+	// SignerPin is the user pin of the PKCS#11 token
+	SignerPin = "test-signer-pin"
 )
 
 // Params represent the parameters passed to the kernel at boot
@@ -68,6 +83,11 @@ func (k Params) IsGPUDisabled() bool {
 	return k.Exists(DisableGPU)
 }
 
+// IsExporterEnabled checks if test-exporter is set
+func (k Params) IsExporterEnabled() bool {
+	return k.Exists(Exporter)
+}
+
 // IsVirtualMachine checks if test-debug-vm is set
 func (k Params) IsVirtualMachine() bool {
 	return k.Exists(VirtualMachine)
@@ -100,4 +100,40 @@ type HostMonitor interface {
 type VersionMonitor interface {
 	GetVersion() semver.Version
 	Version(ctx context.Context) <-chan semver.Version
+	// LastUpgrade returns the report of the last upgrade of the node
+	LastUpgrade() (UpgradeReport, error)
+}
+
+// UpgradeState is the state of an upgrade
+type UpgradeState string
+
+const (
+	// UpgradePending the new release is being installed, or is installed
+	// and the health gates are still being checked
+	UpgradePending UpgradeState = "pending"
+	// UpgradeHealthy the new release passed all health gates
+	UpgradeHealthy UpgradeState = "healthy"
+	// UpgradeFailed the new release could not be installed, the
+	// previous release was restored. The upgrade is tried again later
+	UpgradeFailed UpgradeState = "failed"
+	// UpgradeRolledBack the new release failed the health gates
+	// and the node was rolled back to the previous release
+	UpgradeRolledBack UpgradeState = "rolled-back"
+	// UpgradeRollbackFailed the new release failed (health gates or
+	// installation) but the previous release could not be restored.
+	// This is a final state, it requires the node to be rebooted.
+	UpgradeRollbackFailed UpgradeState = "rollback-failed"
+)
+
+// UpgradeReport describes the last upgrade of the node
+type UpgradeReport struct {
+	// From is the release the node was running before the upgrade
+	From string `json:"from"`
+	// To is the release the node was upgraded to
+	To    string       `json:"to"`
+	State UpgradeState `json:"state"`
+	// Reason is set if the upgrade was rolled back
+	Reason   string `json:"reason,omitempty"`
+	Started  uint64 `json:"started"`
+	Finished uint64 `json:"finished,omitempty"`
 }
@@ -59,6 +59,22 @@ func (e *ExitDevice) String() string {
 
 type NetResourceMetrics map[string]NetMetric
 
+// QdiscStats statistics of a single traffic control qdisc
+type QdiscStats struct {
+	// Limit is the configured rate limit in Mbps
+	Limit      uint64 `json:"limit_mbps"`
+	Bytes      uint64 `json:"bytes"`
+	Packets    uint64 `json:"packets"`
+	Drops      uint64 `json:"drops"`
+	Overlimits uint64 `json:"overlimits"`
+}
+
+// ShapingStats current traffic shaping statistics of a network resource
+type ShapingStats struct {
+	Egress  *QdiscStats `json:"egress,omitempty"`
+	Ingress *QdiscStats `json:"ingress,omitempty"`
+}
+
 // Networker is the interface for the network module
 type Networker interface {
 	// Ready return nil is networkd is ready to operate
@@ -162,6 +178,14 @@ type Networker interface {
 	// GetNet returns the full network range of the network
 	GetNet(networkID NetID) (net.IPNet, error)
 
+	// GetNet6 returns the private IPv6 range of the network, it's empty
+	// if the network is not dual stack
+	GetNet6(networkID NetID) (net.IPNet, error)
+
+	// GetSubnet6 returns the private IPv6 /64 of the local network resource, it's
+	// empty if the network is not dual stack
+	GetSubnet6(networkID NetID) (net.IPNet, error)
+
 	// GetPublicIPv6Subnet returns the IPv6 prefix op the public subnet of the host
 	GetPublicIPv6Subnet() (net.IPNet, error)
 
@@ -183,6 +207,10 @@ type Networker interface {
 	// if they are physical
 	Interfaces(iface string, netns string) (Interfaces, error)
 
+	// PhysicalNICs lists the network interfaces of the host that are backed
+	// by a device
+	PhysicalNICs() ([]HardwareNIC, error)
+
 	// Addrs return the IP addresses of interface
 	// if the interface is in a network namespace netns needs to be not empty
 	// [obsolete] please use Interfaces instead
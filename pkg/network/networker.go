@@ -3,7 +3,6 @@ package network
 import (
 	"bytes"
 	"context"
-	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -22,6 +21,7 @@ import (
 	"github.com/threefoldtech/test/pkg/environment"
 	"github.com/threefoldtech/test/pkg/gridtypes"
 	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/identity"
 	"github.com/threefoldtech/test/pkg/network/bootstrap"
 	"github.com/threefoldtech/test/pkg/network/iperf"
 	"github.com/threefoldtech/test/pkg/network/mycelium"
@@ -787,7 +787,12 @@ func (n *networker) GetDefaultGwIP(networkID pkg.NetID) (net.IP, net.IP, error)
 		return nil, nil, errors.Wrapf(err, "couldn't load network with id (%s)", networkID)
 	}
 
-	// only IP4 atm
+	gw6 := nr.New(localNR, n.myceliumKeyDir).GatewayIPv6()
+	if !localNR.HasIPv4() {
+		// ipv6 only network
+		return nil, gw6.IP, nil
+	}
+
 	ip := localNR.Subnet.IP.To4()
 	if ip == nil {
 		return nil, nil, errors.New("nr subnet is not valid IPv4")
@@ -797,8 +802,7 @@ func (n *networker) GetDefaultGwIP(networkID pkg.NetID) (net.IP, net.IP, error)
 	// also a subnet in a NR is assumed to be a /24
 	ip[len(ip)-1] = 1
 
-	// ipv6 is derived from the ipv4
-	return ip, nr.Convert4to6(string(networkID), ip), nil
+	return ip, gw6.IP, nil
 }
 
 // GetIPv6From4 generates an IPv6 address from a given IPv4 address in a NR
@@ -806,7 +810,35 @@ func (n *networker) GetIPv6From4(networkID pkg.NetID, ip net.IP) (net.IPNet, err
 	if ip.To4() == nil {
 		return net.IPNet{}, errors.New("invalid IPv4 address")
 	}
-	return net.IPNet{IP: nr.Convert4to6(string(networkID), ip), Mask: net.CIDRMask(64, 128)}, nil
+
+	localNR, err := n.networkOf(networkID)
+	if err != nil {
+		return net.IPNet{}, errors.Wrapf(err, "couldn't load network with id (%s)", networkID)
+	}
+
+	return nr.New(localNR, n.myceliumKeyDir).IPv6From4(ip), nil
+}
+
+// GetNet6 returns the private IPv6 range of the network identified by the network ID
+// an empty IPNet is returned if the network has no private IPv6 range
+func (n *networker) GetNet6(networkID pkg.NetID) (net.IPNet, error) {
+	localNR, err := n.networkOf(networkID)
+	if err != nil {
+		return net.IPNet{}, errors.Wrapf(err, "couldn't load network with id (%s)", networkID)
+	}
+
+	return localNR.IPv6Range.IPNet, nil
+}
+
+// GetSubnet6 returns the private IPv6 /64 of the local network resource identified
+// by the network ID. An empty IPNet is returned if the network has no private IPv6 range
+func (n *networker) GetSubnet6(networkID pkg.NetID) (net.IPNet, error) {
+	localNR, err := n.networkOf(networkID)
+	if err != nil {
+		return net.IPNet{}, errors.Wrapf(err, "couldn't load network with id (%s)", networkID)
+	}
+
+	return localNR.IPv6Subnet.IPNet, nil
 }
 
 func (n *networker) SetPublicExitDevice(iface string) error {
@@ -976,10 +1008,14 @@ func (n *networker) CreateNR(wl gridtypes.WorkloadID, netNR pkg.Network) (string
 	}
 
 	// setup mycelium
-	if err := netr.SetMycelium(); err != nil {
+	if err = netr.SetMycelium(); err != nil {
 		return "", errors.Wrap(err, "failed to setup mycelium")
 	}
 
+	if err = netr.SetRouterAdvertisement(); err != nil {
+		return "", errors.Wrap(err, "failed to setup ipv6 router advertisement")
+	}
+
 	exists, err := netr.HasWireguard()
 	if err != nil {
 		return "", errors.Wrap(err, "failed to check if network resource has wireguard setup")
@@ -1009,9 +1045,96 @@ func (n *networker) CreateNR(wl gridtypes.WorkloadID, netNR pkg.Network) (string
 		return "", errors.Wrap(err, "failed to configure network resource")
 	}
 
+	if err = n.applyShaping(netr); err != nil {
+		return "", errors.Wrap(err, "failed to apply network resource shaping")
+	}
+
 	return netr.Namespace()
 }
 
+// networks lists the ids of the networks deployed on the node
+func (n *networker) networks() ([]test.NetID, error) {
+	entries, err := os.ReadDir(n.networkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]test.NetID, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ids = append(ids, test.NetID(entry.Name()))
+	}
+
+	return ids, nil
+}
+
+// fairShare returns the current share of the node uplink of each network
+func (n *networker) fairShare(env environment.Environment) (uint64, error) {
+	if env.NetFairShare == 0 {
+		return 0, nil
+	}
+
+	ids, err := n.networks()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list networks")
+	}
+
+	return nr.FairShare(env, len(ids)), nil
+}
+
+// applyShaping applies the shaping of the network resource. If the fair
+// share is enabled the other network resources are shaped again since
+// their share has changed
+func (n *networker) applyShaping(netr *nr.NetResource) error {
+	env, err := environment.Get()
+	if err != nil {
+		return errors.Wrap(err, "failed to get node environment")
+	}
+
+	share, err := n.fairShare(env)
+	if err != nil {
+		return err
+	}
+
+	if err := netr.ApplyShaping(share); err != nil {
+		return err
+	}
+
+	if share != 0 {
+		n.reshape(netr.ID(), share)
+	}
+
+	return nil
+}
+
+// reshape applies the given fair share on all the networks except skip,
+// failures are only logged since the networks keep their previous share
+func (n *networker) reshape(skip string, share uint64) {
+	ids, err := n.networks()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list networks")
+		return
+	}
+
+	for _, id := range ids {
+		if string(id) == skip {
+			continue
+		}
+
+		netNR, err := n.networkOf(id)
+		if err != nil {
+			log.Error().Err(err).Str("network", string(id)).Msg("failed to load network resource")
+			continue
+		}
+
+		if err := nr.New(netNR, n.myceliumKeyDir).ApplyShaping(share); err != nil {
+			log.Error().Err(err).Str("network", string(id)).Msg("failed to apply network resource fair share")
+		}
+	}
+}
+
 func (n *networker) rmNetwork(wl gridtypes.WorkloadID) error {
 	netID, err := test.NetworkIDFromWorkloadID(wl)
 	if err != nil {
@@ -1087,6 +1210,16 @@ func (n *networker) DeleteNR(wl gridtypes.WorkloadID) error {
 		log.Error().Err(err).Msg("failed to remove file mapping between network ID and namespace")
 	}
 
+	// the remaining networks get a bigger share of the uplink
+	if env, err := environment.Get(); err == nil && env.NetFairShare != 0 {
+		share, err := n.fairShare(env)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to compute network fair share")
+		} else {
+			n.reshape("", share)
+		}
+	}
+
 	return nil
 }
 
@@ -1129,7 +1262,10 @@ func (n *networker) SetPublicConfig(cfg pkg.PublicConfig) error {
 	// when public setup is updated. it can take a while but the capacityd
 	// will detect this change and take necessary actions to update the node
 	ctx := context.Background()
-	sk := ed25519.PrivateKey(n.identity.PrivateKey(ctx))
+	sk, err := identity.NetworkKey(ctx, n.identity)
+	if err != nil {
+		return errors.Wrap(err, "failed to get network key")
+	}
 	ns, err := yggdrasil.NewYggdrasilNamespace(public.PublicNamespace)
 	if err != nil {
 		return errors.Wrap(err, "failed to setup public namespace for yggdrasil")
@@ -1276,6 +1412,16 @@ func (n *networker) Metrics() (pkg.NetResourceMetrics, error) {
 		return nil, errors.Wrap(err, "failed to list networks")
 	}
 
+	env, err := environment.Get()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get node environment")
+	}
+
+	share, err := n.fairShare(env)
+	if err != nil {
+		return nil, err
+	}
+
 	metrics := make(pkg.NetResourceMetrics)
 	for _, link := range links {
 		if link.IsDir() {
@@ -1291,7 +1437,7 @@ func (n *networker) Metrics() (pkg.NetResourceMetrics, error) {
 		}
 		nsName := n.Namespace(test.NetID(filepath.Base(sym)))
 		logger.Debug().Str("namespace", nsName).Msg("collecting namespace statistics")
-		nr, err := namespace.GetByName(nsName)
+		netNS, err := namespace.GetByName(nsName)
 		if err != nil {
 			// this happens on some node. it's weird the the namespace is suddenly gone
 			// while the workload is still active.
@@ -1304,8 +1450,8 @@ func (n *networker) Metrics() (pkg.NetResourceMetrics, error) {
 			continue
 		}
 
-		defer nr.Close()
-		err = nr.Do(func(_ ns.NetNS) error {
+		defer netNS.Close()
+		err = netNS.Do(func(_ ns.NetNS) error {
 			// get stats of public interface.
 			m, err := metricsForNics("public")
 			if err != nil {
@@ -1317,7 +1463,24 @@ func (n *networker) Metrics() (pkg.NetResourceMetrics, error) {
 		})
 		if err != nil {
 			log.Error().Err(err).Msg("failed to collect metrics for network")
+			continue
+		}
+
+		netNR, err := n.networkOf(test.NetID(filepath.Base(sym)))
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to load network resource")
+			continue
+		}
+
+		shaping, err := nr.New(netNR, n.myceliumKeyDir).ShapingStats(env, share)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to collect shaping statistics for network")
+			continue
 		}
+
+		m := metrics[wl]
+		m.Shaping = shaping
+		metrics[wl] = m
 	}
 
 	return metrics, nil
@@ -1565,3 +1728,9 @@ func metricsForNics(nics ...string) (m pkg.NetMetric, err error) {
 
 	return
 }
+
+// PhysicalNICs implements pkg.Networker interface. networkd runs in the host
+// namespace so all the host interfaces are visible from its sysfs.
+func (n *networker) PhysicalNICs() ([]pkg.HardwareNIC, error) {
+	return physicalNICs(netDir)
+}
@@ -0,0 +1,59 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/threefoldtech/test/pkg"
+)
+
+const (
+	netDir = "/sys/class/net"
+)
+
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// physicalNICs lists the network interfaces that are backed by a device
+func physicalNICs(root string) ([]pkg.HardwareNIC, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var nics []pkg.HardwareNIC
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if _, err := os.Stat(filepath.Join(path, "device")); err != nil {
+			// virtual interface
+			continue
+		}
+
+		nic := pkg.HardwareNIC{
+			Name: entry.Name(),
+			Mac:  readString(filepath.Join(path, "address")),
+			Up:   readString(filepath.Join(path, "operstate")) == "up",
+		}
+
+		if driver, err := os.Readlink(filepath.Join(path, "device", "driver")); err == nil {
+			nic.Driver = filepath.Base(driver)
+		}
+
+		// speed is -1 (or can't be read) if the link is down
+		if speed, err := strconv.ParseInt(readString(filepath.Join(path, "speed")), 10, 64); err == nil && speed > 0 {
+			nic.Speed = uint32(speed)
+		}
+
+		nics = append(nics, nic)
+	}
+
+	return nics, nil
+}
@@ -0,0 +1,39 @@
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestPhysicalNICs(t *testing.T) {
+	root := t.TempDir()
+
+	write := func(nic, name, value string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, nic, name), []byte(value+"\n"), 0644))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "eth0", "device"), 0755))
+	write("eth0", "address", "aa:bb:cc:dd:ee:ff")
+	write("eth0", "operstate", "up")
+	write("eth0", "speed", "10000")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "eth1", "device"), 0755))
+	write("eth1", "address", "aa:bb:cc:dd:ee:00")
+	write("eth1", "operstate", "down")
+	write("eth1", "speed", "-1")
+
+	// virtual interface
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "br0"), 0755))
+
+	nics, err := physicalNICs(root)
+	require.NoError(t, err)
+	require.Len(t, nics, 2)
+
+	assert.Equal(t, pkg.HardwareNIC{Name: "eth0", Mac: "aa:bb:cc:dd:ee:ff", Speed: 10000, Up: true}, nics[0])
+	assert.Equal(t, pkg.HardwareNIC{Name: "eth1", Mac: "aa:bb:cc:dd:ee:00"}, nics[1])
+}
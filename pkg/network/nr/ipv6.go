@@ -0,0 +1,182 @@
+package nr
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg/zinit"
+)
+
+const (
+	// radvdConfDir is where the router advertisement daemon
+	// configuration of each network resource is stored
+	radvdConfDir = "/var/run/radvd"
+)
+
+var radvdTmpl = template.Must(template.New("radvd").Parse(`
+interface {{.Iface}} {
+	AdvSendAdvert on;
+	MinRtrAdvInterval 30;
+	MaxRtrAdvInterval 100;
+	AdvDefaultLifetime 300;
+	prefix {{.Subnet}} {
+		AdvOnLink on;
+		AdvAutonomous on;
+	};
+	route {{.Range}} {
+	};
+};
+`))
+
+// IPv6From4 returns the private IPv6 of the given private IPv4 of the network.
+// If the network has a private IPv6 subnet, the IP is mapped inside this subnet
+// otherwise it's derived from the network ID as with Convert4to6
+func (nr *NetResource) IPv6From4(ip net.IP) net.IPNet {
+	if !nr.resource.HasIPv6() {
+		return net.IPNet{
+			IP:   Convert4to6(nr.ID(), ip),
+			Mask: net.CIDRMask(64, 128),
+		}
+	}
+
+	return net.IPNet{
+		IP:   MapIPv4ToSubnet(nr.resource.IPv6Subnet.IPNet, ip),
+		Mask: net.CIDRMask(64, 128),
+	}
+}
+
+// MapIPv4ToSubnet maps an ipv4 to an IPv6 inside the given /64 subnet. Since the
+// NR subnet is always a /24, the last byte of the IPv4 is used as the last byte
+// of the IPv6, so 10.20.3.4 in fd00:1:2:3::/64 is mapped to fd00:1:2:3::4 and
+// the NR gateway 10.20.3.1 gets the first address of the subnet fd00:1:2:3::1
+func MapIPv4ToSubnet(subnet net.IPNet, ip net.IP) net.IP {
+	ipv6 := make(net.IP, net.IPv6len)
+	copy(ipv6[0:8], subnet.IP.To16()[0:8])
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ipv6[15] = ip4[3]
+	}
+
+	return ipv6
+}
+
+// GatewayIPv6 returns the private IPv6 of the network resource gateway. It's
+// the first address of the IPv6 subnet if the network has a private IPv6 range
+// otherwise it's derived from the gateway IPv4 (x.x.x.1)
+func (nr *NetResource) GatewayIPv6() net.IPNet {
+	if nr.resource.HasIPv6() {
+		return net.IPNet{
+			IP:   MapIPv4ToSubnet(nr.resource.IPv6Subnet.IPNet, net.IPv4(0, 0, 0, 1)),
+			Mask: net.CIDRMask(64, 128),
+		}
+	}
+
+	ip := make(net.IP, net.IPv4len)
+	copy(ip, nr.resource.Subnet.IP.To4())
+	ip[len(ip)-1] = 0x01
+
+	return nr.IPv6From4(ip)
+}
+
+func (nr *NetResource) radvdServiceName() string {
+	return fmt.Sprintf("radvd-%s", nr.ID())
+}
+
+// SetRouterAdvertisement starts a router advertisement daemon inside the network
+// resource namespace that advertise the network resource IPv6 subnet to the
+// workloads. It does nothing if the network has no private IPv6 range
+func (nr *NetResource) SetRouterAdvertisement() error {
+	if !nr.resource.HasIPv6() {
+		return nil
+	}
+
+	if _, err := exec.LookPath("radvd"); err != nil {
+		return errors.Wrap(err, "radvd is required for networks with an ipv6 range")
+	}
+
+	nsName, err := nr.Namespace()
+	if err != nil {
+		return err
+	}
+
+	iface, err := nr.NRIface()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(radvdConfDir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create radvd config directory")
+	}
+
+	subnet := nr.resource.IPv6Subnet.IPNet
+	subnet.IP = subnet.IP.Mask(subnet.Mask)
+	rng := nr.resource.IPv6Range.IPNet
+	rng.IP = rng.IP.Mask(rng.Mask)
+
+	var buf bytes.Buffer
+	err = radvdTmpl.Execute(&buf, struct {
+		Iface  string
+		Subnet string
+		Range  string
+	}{
+		Iface:  iface,
+		Subnet: subnet.String(),
+		Range:  rng.String(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build radvd config")
+	}
+
+	conf := filepath.Join(radvdConfDir, fmt.Sprintf("%s.conf", nr.ID()))
+	if err := os.WriteFile(conf, buf.Bytes(), 0644); err != nil {
+		return errors.Wrap(err, "failed to write radvd config")
+	}
+
+	name := nr.radvdServiceName()
+	init := zinit.Default()
+	exists, err := init.Exists(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to check radvd service")
+	}
+
+	if exists {
+		// radvd reloads its configuration on SIGHUP
+		return init.Kill(name, zinit.SIGHUP)
+	}
+
+	err = zinit.AddService(name, zinit.InitService{
+		Exec: fmt.Sprintf("ip netns exec %s radvd -n -C %s -p %s", nsName, conf, filepath.Join(radvdConfDir, fmt.Sprintf("%s.pid", nr.ID()))),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to add radvd service for nr")
+	}
+
+	return init.Monitor(name)
+}
+
+// removeRouterAdvertisement stops the router advertisement daemon of the
+// network resource if running
+func (nr *NetResource) removeRouterAdvertisement() {
+	name := nr.radvdServiceName()
+	init := zinit.Default()
+	exists, err := init.Exists(name)
+	if err != nil || !exists {
+		return
+	}
+
+	if err := init.StopMultiple(10*time.Second, name); err != nil {
+		log.Error().Err(err).Msg("failed to stop radvd for network resource")
+	}
+
+	_ = init.Forget(name)
+	_ = zinit.RemoveService(name)
+	_ = os.Remove(filepath.Join(radvdConfDir, fmt.Sprintf("%s.conf", nr.ID())))
+}
@@ -519,7 +519,9 @@ func (nr *NetResource) ConfigureWG(privateKey string) error {
 		}
 
 		newAddrs := mapset.NewSet()
-		newAddrs.Add(wgIP(&nr.resource.Subnet.IPNet).String())
+		if nr.resource.HasIPv4() {
+			newAddrs.Add(wgIP(&nr.resource.Subnet.IPNet).String())
+		}
 
 		toRemove := curAddrs.Difference(newAddrs)
 		toAdd := newAddrs.Difference(curAddrs)
@@ -544,16 +546,30 @@ func (nr *NetResource) ConfigureWG(privateKey string) error {
 			}
 		}
 
-		route := &netlink.Route{
-			LinkIndex: wg.Attrs().Index,
-			Dst:       &nr.networkIPRange,
+		var routes []*netlink.Route
+		if nr.resource.HasIPv4() {
+			routes = append(routes, &netlink.Route{
+				LinkIndex: wg.Attrs().Index,
+				Dst:       &nr.networkIPRange,
+			})
 		}
-		if err := netlink.RouteAdd(route); err != nil && !os.IsExist(err) {
-			log.Error().
-				Err(err).
-				Str("route", route.String()).
-				Msg("fail to set route")
-			return errors.Wrapf(err, "failed to add route %s", route.String())
+
+		if nr.resource.HasIPv6() {
+			// other network resources /64 are reachable over wireguard
+			routes = append(routes, &netlink.Route{
+				LinkIndex: wg.Attrs().Index,
+				Dst:       &nr.resource.IPv6Range.IPNet,
+			})
+		}
+
+		for _, route := range routes {
+			if err := netlink.RouteAdd(route); err != nil && !os.IsExist(err) {
+				log.Error().
+					Err(err).
+					Str("route", route.String()).
+					Msg("fail to set route")
+				return errors.Wrapf(err, "failed to add route %s", route.String())
+			}
 		}
 
 		return nil
@@ -578,6 +594,8 @@ func (nr *NetResource) Delete() error {
 		return err
 	}
 
+	nr.removeRouterAdvertisement()
+
 	myceliumName := nr.myceliumServiceName()
 	init := zinit.Default()
 	exists, err := init.Exists(myceliumName)
@@ -638,11 +656,21 @@ func (nr *NetResource) wgPeers() ([]*wireguard.Peer, error) {
 
 	for _, peer := range nr.resource.Peers {
 
-		allowedIPs := make([]string, 0, len(peer.AllowedIPs))
+		allowedIPs := make([]string, 0, len(peer.AllowedIPs)+1)
 		for _, ip := range peer.AllowedIPs {
 			allowedIPs = append(allowedIPs, ip.String())
 		}
 
+		// the peer /64 must be routed over the tunnel for the private
+		// IPv6 of the peer network resource to be reachable
+		if !peer.IPv6Subnet.Nil() {
+			subnet := peer.IPv6Subnet.IPNet
+			subnet.IP = subnet.IP.Mask(subnet.Mask)
+			if !slices.Contains(allowedIPs, subnet.String()) {
+				allowedIPs = append(allowedIPs, subnet.String())
+			}
+		}
+
 		wgPeer := &wireguard.Peer{
 			PublicKey:  peer.WGPublicKey,
 			AllowedIPs: allowedIPs,
@@ -722,20 +750,19 @@ func (nr *NetResource) attachToNRBridge() error {
 			return err
 		}
 
-		ipnet := nr.resource.Subnet
-		ipnet.IP[len(ipnet.IP)-1] = 0x01
-		log.Info().Str("addr", ipnet.String()).Msg("set address on macvlan interface")
+		if nr.resource.HasIPv4() {
+			ipnet := nr.resource.Subnet
+			ipnet.IP[len(ipnet.IP)-1] = 0x01
+			log.Info().Str("addr", ipnet.String()).Msg("set address on macvlan interface")
 
-		addr := &netlink.Addr{IPNet: &ipnet.IPNet, Label: ""}
-		if err = netlink.AddrAdd(link, addr); err != nil && !os.IsExist(err) {
-			return err
+			addr := &netlink.Addr{IPNet: &ipnet.IPNet, Label: ""}
+			if err = netlink.AddrAdd(link, addr); err != nil && !os.IsExist(err) {
+				return err
+			}
 		}
 
-		ipv6 := Convert4to6(nr.ID(), ipnet.IP)
-		addr = &netlink.Addr{IPNet: &net.IPNet{
-			IP:   ipv6,
-			Mask: net.CIDRMask(64, 128),
-		}}
+		ipv6 := nr.GatewayIPv6()
+		addr := &netlink.Addr{IPNet: &ipv6}
 		if err = netlink.AddrAdd(link, addr); err != nil && !os.IsExist(err) {
 			return err
 		}
@@ -9,6 +9,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
 	"github.com/vishvananda/netlink"
 )
 
@@ -211,3 +213,58 @@ func TestMyceliumIP(t *testing.T) {
 	require.Equal(t, "3b4:ca67:822d:b0c1::1/64", gw.String())
 
 }
+
+func TestMapIPv4ToSubnet(t *testing.T) {
+	_, subnet, err := net.ParseCIDR("fd00:1:2:3::/64")
+	require.NoError(t, err)
+
+	ip := MapIPv4ToSubnet(*subnet, net.ParseIP("10.20.3.4"))
+	require.Equal(t, net.ParseIP("fd00:1:2:3::4"), ip)
+}
+
+func TestGatewayIPv6(t *testing.T) {
+	network := pkg.Network{
+		NetID: "net1",
+		Network: test.Network{
+			NetworkIPRange: gridtypes.MustParseIPNet("10.20.0.0/16"),
+			Subnet:         gridtypes.MustParseIPNet("10.20.3.0/24"),
+			IPv6Range:      gridtypes.MustParseIPNet("fd00:1:2::/48"),
+			IPv6Subnet:     gridtypes.MustParseIPNet("fd00:1:2:3::/64"),
+		},
+	}
+
+	nr := New(network, "")
+	gw := nr.GatewayIPv6()
+	require.Equal(t, "fd00:1:2:3::1/64", gw.String())
+	// the gateway ipv6 is the one mapped from the gateway ipv4
+	require.Equal(t, gw, nr.IPv6From4(net.ParseIP("10.20.3.1")))
+
+	network.NetworkIPRange = gridtypes.IPNet{}
+	network.Subnet = gridtypes.IPNet{}
+	gw = New(network, "").GatewayIPv6()
+	require.Equal(t, "fd00:1:2:3::1/64", gw.String())
+}
+
+func TestWGPeersIPv6(t *testing.T) {
+	network := pkg.Network{
+		NetID: "net1",
+		Network: test.Network{
+			Peers: []test.Peer{
+				{
+					WGPublicKey: "key",
+					Subnet:      gridtypes.MustParseIPNet("10.20.4.0/24"),
+					IPv6Subnet:  gridtypes.MustParseIPNet("fd00:1:2:4::/64"),
+					AllowedIPs: []gridtypes.IPNet{
+						gridtypes.MustParseIPNet("10.20.4.0/24"),
+						gridtypes.MustParseIPNet("100.64.20.4/32"),
+					},
+				},
+			},
+		},
+	}
+
+	peers, err := New(network, "").wgPeers()
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	require.Equal(t, []string{"10.20.4.0/24", "100.64.20.4/32", "fd00:1:2:4::/64"}, peers[0].AllowedIPs)
+}
@@ -0,0 +1,238 @@
+package nr
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/environment"
+)
+
+const (
+	// nrPubIface is the interface inside the NR namespace that is
+	// connected to the ndmz bridge (created by ndmz.AttachNR)
+	nrPubIface = "public"
+	// tbfLatency is the max time a packet can stay in the tbf queue
+	tbfLatency = "50ms"
+	// minBurst is the minimum burst size in bytes
+	minBurst = 32 * 1024
+	// minFairShare is the minimum share (in Mbps) of a network resource
+	// when the node uplink is shared between many network resources
+	minFairShare = 10
+)
+
+// qdisc is the subset of the `tc -s -j qdisc` output we care about
+type qdisc struct {
+	Kind       string `json:"kind"`
+	Root       bool   `json:"root"`
+	Bytes      uint64 `json:"bytes"`
+	Packets    uint64 `json:"packets"`
+	Drops      uint64 `json:"drops"`
+	Overlimits uint64 `json:"overlimits"`
+}
+
+// effectiveLimit returns the rate limit to apply given the requested limit
+// by the user, and the node wide limit. The user can always ask for less but
+// never for more than the node limit. A zero value means no limit.
+func effectiveLimit(requested, node uint64) uint64 {
+	if requested == 0 {
+		return node
+	}
+
+	if node == 0 || requested < node {
+		return requested
+	}
+
+	return node
+}
+
+// burst computes the bucket size (in bytes) for a rate in Mbps. It holds
+// around 10ms worth of traffic
+func burst(mbps uint64) uint64 {
+	b := mbps * 1000 * 1000 / 8 / 100
+	if b < minBurst {
+		return minBurst
+	}
+
+	return b
+}
+
+// FairShare returns the share (in Mbps) of each network resource when the
+// node uplink capacity is shared evenly between count network resources.
+// It returns 0 (no limit) if the fair share is disabled
+func FairShare(env environment.Environment, count int) uint64 {
+	if env.NetFairShare == 0 {
+		return 0
+	}
+
+	share := env.NetFairShare
+	if count > 1 {
+		share /= uint64(count)
+	}
+
+	if share < minFairShare {
+		return minFairShare
+	}
+
+	return share
+}
+
+// Limits returns the effective egress and ingress limits (in Mbps) of
+// this network resource, given the node environment and the fair share
+// of the network resource (0 means no fair share)
+func (nr *NetResource) Limits(env environment.Environment, share uint64) (egress, ingress uint64) {
+	if bw := nr.resource.Bandwidth; bw != nil {
+		egress, ingress = bw.Egress, bw.Ingress
+	}
+
+	egress = effectiveLimit(effectiveLimit(egress, env.NetEgressLimit), share)
+	ingress = effectiveLimit(effectiveLimit(ingress, env.NetIngressLimit), share)
+
+	return egress, ingress
+}
+
+// ApplyShaping configures the traffic shaping on the public interface
+// of the network resource. Egress traffic is shaped with a tbf root qdisc
+// while ingress traffic is policed on the ingress qdisc. Limits that are not
+// set are cleared.
+func (nr *NetResource) ApplyShaping(share uint64) error {
+	env, err := environment.Get()
+	if err != nil {
+		return errors.Wrap(err, "failed to get node environment")
+	}
+
+	nsName, err := nr.Namespace()
+	if err != nil {
+		return err
+	}
+
+	egress, ingress := nr.Limits(env, share)
+	log.Debug().
+		Str("namespace", nsName).
+		Uint64("egress", egress).
+		Uint64("ingress", ingress).
+		Msg("apply network resource shaping")
+
+	if egress == 0 {
+		if err := tcDelete(nsName, "root"); err != nil {
+			return err
+		}
+	} else {
+		err := tc(nsName,
+			"qdisc", "replace", "dev", nrPubIface, "root", "handle", "1:",
+			"tbf",
+			"rate", fmt.Sprintf("%dmbit", egress),
+			"burst", fmt.Sprint(burst(egress)),
+			"latency", tbfLatency,
+		)
+		if err != nil {
+			return errors.Wrap(err, "failed to set egress shaping")
+		}
+	}
+
+	// the police filter can't be replaced in place, so we always
+	// start from a clean ingress qdisc
+	if err := tcDelete(nsName, "ingress"); err != nil {
+		return err
+	}
+
+	if ingress == 0 {
+		return nil
+	}
+
+	if err := tc(nsName, "qdisc", "add", "dev", nrPubIface, "handle", "ffff:", "ingress"); err != nil {
+		return errors.Wrap(err, "failed to add ingress qdisc")
+	}
+
+	err = tc(nsName,
+		"filter", "add", "dev", nrPubIface, "parent", "ffff:",
+		"protocol", "all", "prio", "1",
+		"u32", "match", "u32", "0", "0",
+		"police",
+		"rate", fmt.Sprintf("%dmbit", ingress),
+		"burst", fmt.Sprint(burst(ingress)),
+		"drop", "flowid", ":1",
+	)
+
+	return errors.Wrap(err, "failed to set ingress policing")
+}
+
+// ShapingStats returns current shaping statistics of the network resource.
+// It returns nil if no limits are applied
+func (nr *NetResource) ShapingStats(env environment.Environment, share uint64) (*pkg.ShapingStats, error) {
+	egress, ingress := nr.Limits(env, share)
+	if egress == 0 && ingress == 0 {
+		return nil, nil
+	}
+
+	nsName, err := nr.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := exec.Command("ip", "netns", "exec", nsName, "tc", "-s", "-j", "qdisc", "show", "dev", nrPubIface).Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get qdisc statistics")
+	}
+
+	var qdiscs []qdisc
+	if err := json.Unmarshal(output, &qdiscs); err != nil {
+		return nil, errors.Wrap(err, "failed to parse qdisc statistics")
+	}
+
+	var stats pkg.ShapingStats
+	for _, q := range qdiscs {
+		qs := pkg.QdiscStats{
+			Bytes:      q.Bytes,
+			Packets:    q.Packets,
+			Drops:      q.Drops,
+			Overlimits: q.Overlimits,
+		}
+
+		switch {
+		case q.Kind == "tbf" && q.Root && egress != 0:
+			qs.Limit = egress
+			stats.Egress = &qs
+		case q.Kind == "ingress" && ingress != 0:
+			qs.Limit = ingress
+			stats.Ingress = &qs
+		}
+	}
+
+	return &stats, nil
+}
+
+// tc runs the tc command inside the given namespace
+func tc(ns string, args ...string) error {
+	args = append([]string{"netns", "exec", ns, "tc"}, args...)
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "tc: %s", strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// tcDelete deletes the given qdisc (root or ingress) from the NR public
+// interface, it's not an error if the qdisc does not exist.
+func tcDelete(ns string, parent string) error {
+	args := []string{"netns", "exec", ns, "tc", "qdisc", "del", "dev", nrPubIface, parent}
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	// deleting a non existing qdisc is not an error
+	output := string(out)
+	if strings.Contains(output, "No such file or directory") ||
+		strings.Contains(output, "Cannot delete qdisc with handle of zero") ||
+		strings.Contains(output, "Invalid handle") {
+		return nil
+	}
+
+	return errors.Wrapf(err, "failed to delete %s qdisc: %s", parent, strings.TrimSpace(output))
+}
@@ -0,0 +1,57 @@
+package nr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/environment"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+)
+
+func TestEffectiveLimit(t *testing.T) {
+	require.Equal(t, uint64(0), effectiveLimit(0, 0))
+	require.Equal(t, uint64(100), effectiveLimit(0, 100))
+	require.Equal(t, uint64(50), effectiveLimit(50, 0))
+	require.Equal(t, uint64(50), effectiveLimit(50, 100))
+	require.Equal(t, uint64(100), effectiveLimit(200, 100))
+}
+
+func TestLimits(t *testing.T) {
+	env := environment.Environment{NetEgressLimit: 100}
+
+	nr := New(pkg.Network{}, "")
+	egress, ingress := nr.Limits(env, 0)
+	require.Equal(t, uint64(100), egress)
+	require.Equal(t, uint64(0), ingress)
+
+	nr = New(pkg.Network{
+		Network: test.Network{
+			Bandwidth: &test.Bandwidth{Egress: 500, Ingress: 20},
+		},
+	}, "")
+
+	egress, ingress = nr.Limits(env, 0)
+	require.Equal(t, uint64(100), egress)
+	require.Equal(t, uint64(20), ingress)
+
+	// the fair share limits both directions
+	egress, ingress = nr.Limits(env, 50)
+	require.Equal(t, uint64(50), egress)
+	require.Equal(t, uint64(20), ingress)
+}
+
+func TestFairShare(t *testing.T) {
+	require.Equal(t, uint64(0), FairShare(environment.Environment{}, 10))
+
+	env := environment.Environment{NetFairShare: 1000}
+	require.Equal(t, uint64(1000), FairShare(env, 0))
+	require.Equal(t, uint64(1000), FairShare(env, 1))
+	require.Equal(t, uint64(250), FairShare(env, 4))
+	require.Equal(t, uint64(minFairShare), FairShare(env, 1000))
+}
+
+func TestBurst(t *testing.T) {
+	require.Equal(t, uint64(minBurst), burst(1))
+	require.Equal(t, uint64(1250000), burst(1000))
+}
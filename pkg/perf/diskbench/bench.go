@@ -0,0 +1,185 @@
+package diskbench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// alignment required by O_DIRECT
+	alignment = 4096
+	// seqBlockSize block size used for sequential read/write
+	seqBlockSize = 1024 * 1024
+	// randBlockSize block size used for random read/write
+	randBlockSize = 4096
+)
+
+// alignedBuffer allocates a buffer of the given size that is aligned
+// to the required O_DIRECT alignment
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+alignment)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) & (alignment - 1)); rem != 0 {
+		offset = alignment - rem
+	}
+
+	return buf[offset : offset+size]
+}
+
+// openDirect opens the file with O_DIRECT to bypass the page cache. If
+// the filesystem does not support direct IO, the file is opened normally
+// and direct is false.
+func openDirect(path string, flag int) (file *os.File, direct bool, err error) {
+	file, err = os.OpenFile(path, flag|syscall.O_DIRECT, 0600)
+	if errors.Is(err, syscall.EINVAL) {
+		file, err = os.OpenFile(path, flag, 0600)
+		return file, false, err
+	}
+
+	return file, err == nil, err
+}
+
+// dropCache evicts the pages of the file from the page cache, so reads
+// without O_DIRECT still hit the disk. Only the pages of this file are
+// dropped, the cache of the workloads is not touched.
+func dropCache(file *os.File) error {
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	return unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
+
+// seqWrite writes size bytes to the file at path, and returns the throughput in MB/s
+func seqWrite(path string, size int64) (float64, error) {
+	file, _, err := openDirect(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	buf := alignedBuffer(seqBlockSize)
+	rand.Read(buf)
+
+	start := time.Now()
+	for written := int64(0); written < size; written += seqBlockSize {
+		if _, err := file.Write(buf); err != nil {
+			return 0, fmt.Errorf("failed to write: %w", err)
+		}
+	}
+
+	if err := file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync: %w", err)
+	}
+
+	return throughput(size, time.Since(start)), nil
+}
+
+// seqRead reads the file at path entirely, and returns the throughput in MB/s
+func seqRead(path string) (float64, error) {
+	file, direct, err := openDirect(path, os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	if !direct {
+		if err := dropCache(file); err != nil {
+			return 0, fmt.Errorf("failed to drop file cache: %w", err)
+		}
+	}
+
+	buf := alignedBuffer(seqBlockSize)
+
+	var total int64
+	start := time.Now()
+	for {
+		n, err := file.Read(buf)
+		total += int64(n)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to read: %w", err)
+		}
+	}
+
+	return throughput(total, time.Since(start)), nil
+}
+
+// randIO runs count random reads (or writes) of randBlockSize on the file
+// at path, and returns the IOPS and the average latency in microseconds.
+// The file must already exist.
+func randIO(path string, count int, write bool) (iops float64, latency float64, err error) {
+	flag := os.O_RDONLY
+	if write {
+		flag = os.O_WRONLY
+	}
+
+	file, direct, err := openDirect(path, flag)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	if !direct && !write {
+		if err := dropCache(file); err != nil {
+			return 0, 0, fmt.Errorf("failed to drop file cache: %w", err)
+		}
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blocks := stat.Size() / randBlockSize
+	if blocks == 0 {
+		return 0, 0, fmt.Errorf("file is too small for random io")
+	}
+
+	buf := alignedBuffer(randBlockSize)
+	rand.Read(buf)
+
+	start := time.Now()
+	for i := 0; i < count; i++ {
+		offset := rand.Int63n(blocks) * randBlockSize
+		if write {
+			_, err = file.WriteAt(buf, offset)
+		} else {
+			_, err = file.ReadAt(buf, offset)
+		}
+
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed random io at offset %d: %w", offset, err)
+		}
+	}
+
+	if write {
+		if err := file.Sync(); err != nil {
+			return 0, 0, fmt.Errorf("failed to sync: %w", err)
+		}
+	}
+
+	elapsed := time.Since(start)
+	iops = float64(count) / elapsed.Seconds()
+	latency = float64(elapsed.Microseconds()) / float64(count)
+
+	return iops, latency, nil
+}
+
+// throughput in MB/s
+func throughput(bytes int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(bytes) / (1000 * 1000) / elapsed.Seconds()
+}
@@ -0,0 +1,67 @@
+package diskbench
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignedBuffer(t *testing.T) {
+	for _, size := range []int{randBlockSize, seqBlockSize} {
+		buf := alignedBuffer(size)
+		require.Len(t, buf, size)
+		require.Zero(t, uintptr(unsafe.Pointer(&buf[0]))&(alignment-1))
+	}
+}
+
+func TestThroughput(t *testing.T) {
+	require.Equal(t, float64(0), throughput(1000, 0))
+	require.Equal(t, float64(2), throughput(2*1000*1000, time.Second))
+	require.Equal(t, float64(4), throughput(2*1000*1000, 500*time.Millisecond))
+}
+
+func TestSeqAndRandIO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench")
+	size := int64(4 * seqBlockSize)
+
+	write, err := seqWrite(path, size)
+	require.NoError(t, err)
+	require.Greater(t, write, float64(0))
+
+	read, err := seqRead(path)
+	require.NoError(t, err)
+	require.Greater(t, read, float64(0))
+
+	iops, latency, err := randIO(path, 10, true)
+	require.NoError(t, err)
+	require.Greater(t, iops, float64(0))
+	require.GreaterOrEqual(t, latency, float64(0))
+
+	_, _, err = randIO(path, 10, false)
+	require.NoError(t, err)
+}
+
+func TestRandIOSmallFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench")
+	_, err := seqWrite(path, 0)
+	require.NoError(t, err)
+
+	_, _, err = randIO(path, 10, false)
+	require.Error(t, err)
+}
+
+func TestDropCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench")
+	_, err := seqWrite(path, seqBlockSize)
+	require.NoError(t, err)
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	require.NoError(t, dropCache(file))
+}
@@ -0,0 +1,167 @@
+package diskbench
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/environment"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/perf"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	// scratchVolume is the name of the volume created on each pool
+	// to run the benchmark
+	scratchVolume = "perf-diskbench"
+	// defaultFileSize is the size of the file used for the benchmark, it can
+	// be changed with the perf:diskbench-size kernel param
+	defaultFileSize = 256 * gridtypes.Megabyte
+	// randOps number of random io operations
+	randOps = 2000
+)
+
+// DiskBenchmarkTask defines the disk benchmark task.
+type DiskBenchmarkTask struct{}
+
+// PoolBenchmark holds the benchmark results of a single storage pool.
+type PoolBenchmark struct {
+	Pool string         `json:"pool"`
+	Type pkg.DeviceType `json:"type"`
+	// SeqWrite sequential write throughput in MB/s
+	SeqWrite float64 `json:"seq_write"`
+	// SeqRead sequential read throughput in MB/s
+	SeqRead float64 `json:"seq_read"`
+	// RandWriteIOPS random 4k writes per second
+	RandWriteIOPS float64 `json:"rand_write_iops"`
+	// RandReadIOPS random 4k reads per second
+	RandReadIOPS float64 `json:"rand_read_iops"`
+	// RandWriteLatency average latency of a random 4k write in microseconds
+	RandWriteLatency float64 `json:"rand_write_latency"`
+	// RandReadLatency average latency of a random 4k read in microseconds
+	RandReadLatency float64 `json:"rand_read_latency"`
+	// Error is set if the benchmark failed on this pool
+	Error string `json:"error,omitempty"`
+	// Skipped is set if the pool is not benchmarked
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// DiskBenchmarkResult holds the benchmark results of all pools with the workloads number during the benchmark.
+type DiskBenchmarkResult struct {
+	Pools     []PoolBenchmark `json:"pools"`
+	Workloads int             `json:"workloads"`
+}
+
+var _ perf.Task = (*DiskBenchmarkTask)(nil)
+
+// NewTask returns a new disk benchmark task.
+func NewTask() perf.Task {
+	return &DiskBenchmarkTask{}
+}
+
+// ID returns task ID.
+func (d *DiskBenchmarkTask) ID() string {
+	return "disk-benchmark"
+}
+
+// Cron returns task cron schedule.
+func (d *DiskBenchmarkTask) Cron() string {
+	return "0 30 3 * * *"
+}
+
+// Description returns task description.
+func (d *DiskBenchmarkTask) Description() string {
+	return "Measures the sequential and random read/write throughput and latency of each storage pool of the node."
+}
+
+// Jitter returns the max number of seconds the job can sleep before actual execution.
+func (d *DiskBenchmarkTask) Jitter() uint32 {
+	return 30 * 60
+}
+
+// Run executes the disk benchmark on all pools.
+func (d *DiskBenchmarkTask) Run(ctx context.Context) (interface{}, error) {
+	client := perf.MustGetZbusClient(ctx)
+	storage := stubs.NewStorageModuleStub(client)
+	statistics := stubs.NewStatisticsStub(client)
+
+	env := environment.MustGet()
+	size := defaultFileSize
+	if env.DiskBenchSize != 0 {
+		size = gridtypes.Unit(env.DiskBenchSize) * gridtypes.Megabyte
+	}
+
+	pools, err := storage.Metrics(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage pools: %w", err)
+	}
+
+	var result DiskBenchmarkResult
+	for _, pool := range pools {
+		bench := PoolBenchmark{
+			Pool: pool.Name,
+			Type: pool.Type,
+		}
+
+		// hdd pools are slow to benchmark and the benchmark competes
+		// with the workloads io, so they are only benchmarked on demand
+		if pool.Type == test.HDDDevice && !env.DiskBenchHDD {
+			bench.Skipped = true
+			result.Pools = append(result.Pools, bench)
+			continue
+		}
+
+		if err := benchmarkPool(ctx, storage, &bench, size); err != nil {
+			log.Error().Err(err).Str("pool", pool.Name).Msg("disk benchmark failed")
+			bench.Error = err.Error()
+		}
+
+		result.Pools = append(result.Pools, bench)
+	}
+
+	workloads, err := statistics.Workloads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workloads number: %w", err)
+	}
+
+	result.Workloads = workloads
+	return result, nil
+}
+
+// benchmarkPool runs the benchmark on a scratch volume of the pool
+func benchmarkPool(ctx context.Context, storage *stubs.StorageModuleStub, bench *PoolBenchmark, size gridtypes.Unit) (err error) {
+	volume, err := storage.PoolVolumeCreate(ctx, bench.Pool, scratchVolume, 2*size)
+	if err != nil {
+		return fmt.Errorf("failed to create scratch volume: %w", err)
+	}
+
+	defer func() {
+		if err := storage.PoolVolumeDelete(ctx, bench.Pool, scratchVolume); err != nil {
+			log.Error().Err(err).Str("pool", bench.Pool).Msg("failed to delete scratch volume")
+		}
+	}()
+
+	path := filepath.Join(volume.Path, "bench")
+
+	if bench.SeqWrite, err = seqWrite(path, int64(size)); err != nil {
+		return fmt.Errorf("sequential write: %w", err)
+	}
+
+	if bench.SeqRead, err = seqRead(path); err != nil {
+		return fmt.Errorf("sequential read: %w", err)
+	}
+
+	if bench.RandWriteIOPS, bench.RandWriteLatency, err = randIO(path, randOps, true); err != nil {
+		return fmt.Errorf("random write: %w", err)
+	}
+
+	if bench.RandReadIOPS, bench.RandReadLatency, err = randIO(path, randOps, false); err != nil {
+		return fmt.Errorf("random read: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package perf
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/test/pkg"
+)
+
+const (
+	// historyMaxRuns is the max number of runs kept per task
+	historyMaxRuns = 2000
+	// historyRetention is how long a task run is kept in the history
+	historyRetention = 90 * 24 * time.Hour
+)
+
+// history is a bounded store of all task runs. Runs of each task are kept
+// in a separate bucket keyed by the run time (unix nano, big endian) so
+// they are sorted by time.
+type history struct {
+	db *bolt.DB
+}
+
+func newHistory(path string) (*history, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open history db")
+	}
+
+	return &history{db: db}, nil
+}
+
+func (h *history) Close() error {
+	return h.db.Close()
+}
+
+// add stores a task run, and drop runs that are older than the
+// retention or over the max number of runs of the task
+func (h *history) add(at time.Time, run pkg.TaskRun) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal task run")
+	}
+
+	return h.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(run.Name))
+		if err != nil {
+			return errors.Wrapf(err, "failed to create history bucket for '%s'", run.Name)
+		}
+
+		if err := bucket.Put(u64(uint64(at.UnixNano())), data); err != nil {
+			return errors.Wrap(err, "failed to store task run")
+		}
+
+		return retain(bucket, uint64(at.Add(-historyRetention).UnixNano()), historyMaxRuns)
+	})
+}
+
+// retain deletes all runs before the given time, and the oldest runs
+// so that only max runs are left in the bucket. The bucket is walked from
+// the newest run, since keys are sorted by time once a run is dropped all
+// the older runs are dropped as well.
+func retain(bucket *bolt.Bucket, before uint64, max int) error {
+	var drop [][]byte
+	kept := 0
+	cur := bucket.Cursor()
+	for k, _ := cur.Last(); k != nil; k, _ = cur.Prev() {
+		if kept < max && lu64(k) >= before {
+			kept++
+			continue
+		}
+
+		drop = append(drop, append([]byte{}, k...))
+	}
+
+	for _, k := range drop {
+		if err := bucket.Delete(k); err != nil {
+			return errors.Wrap(err, "failed to delete old task run")
+		}
+	}
+
+	return nil
+}
+
+// runs returns the task runs in the [from, to] range (unix timestamps)
+func (h *history) runs(name string, from, to uint64) ([]pkg.TaskRun, error) {
+	runs := []pkg.TaskRun{}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return nil
+		}
+
+		end := time.Unix(int64(to), 0).Add(time.Second).UnixNano()
+		cur := bucket.Cursor()
+		for k, v := cur.Seek(u64(uint64(time.Unix(int64(from), 0).UnixNano()))); k != nil; k, v = cur.Next() {
+			if lu64(k) >= uint64(end) {
+				break
+			}
+
+			var run pkg.TaskRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				return errors.Wrap(err, "failed to unmarshal task run")
+			}
+
+			runs = append(runs, run)
+		}
+
+		return nil
+	})
+
+	return runs, err
+}
+
+// History returns the history of the given task in the time range [from, to]
+func (pm *PerformanceMonitor) History(taskName string, from, to uint64) (pkg.TaskHistory, error) {
+	if _, ok := pm.task(taskName); !ok {
+		return pkg.TaskHistory{}, fmt.Errorf("task '%s' not found", taskName)
+	}
+
+	if to == 0 {
+		to = uint64(time.Now().Unix())
+	}
+
+	if from > to {
+		return pkg.TaskHistory{}, fmt.Errorf("invalid time range, from is after to")
+	}
+
+	runs, err := pm.history.runs(taskName, from, to)
+	if err != nil {
+		return pkg.TaskHistory{}, errors.Wrapf(err, "failed to get history of task '%s'", taskName)
+	}
+
+	return pkg.TaskHistory{
+		Name:    taskName,
+		From:    from,
+		To:      to,
+		Runs:    runs,
+		Summary: summarize(runs),
+	}, nil
+}
+
+// aggregator computes a MetricSummary incrementally
+type aggregator struct {
+	pkg.MetricSummary
+	sum float64
+}
+
+func (a *aggregator) add(v float64) {
+	if a.Count == 0 || v < a.Min {
+		a.Min = v
+	}
+	if a.Count == 0 || v > a.Max {
+		a.Max = v
+	}
+	a.Count++
+	a.sum += v
+	a.Avg = a.sum / float64(a.Count)
+}
+
+// summarize aggregates the given runs. Failed runs only count
+// toward the number of failures.
+func summarize(runs []pkg.TaskRun) pkg.HistorySummary {
+	var (
+		duration aggregator
+		metrics  = make(map[string]*aggregator)
+		summary  = pkg.HistorySummary{
+			Runs:    uint64(len(runs)),
+			Metrics: make(map[string]pkg.MetricSummary),
+		}
+	)
+
+	for _, run := range runs {
+		duration.add(float64(run.Duration))
+		if len(run.Error) != 0 {
+			summary.Failures++
+			continue
+		}
+
+		numbers(run.Result, "", func(path string, value float64) {
+			agg, ok := metrics[path]
+			if !ok {
+				agg = &aggregator{}
+				metrics[path] = agg
+			}
+			agg.add(value)
+		})
+	}
+
+	summary.Duration = duration.MetricSummary
+	for path, agg := range metrics {
+		summary.Metrics[path] = agg.MetricSummary
+	}
+
+	return summary
+}
+
+// numbers walks a decoded json value and calls fn for every numeric
+// value with its dot separated path
+func numbers(value interface{}, path string, fn func(path string, value float64)) {
+	join := func(key string) string {
+		if len(path) == 0 {
+			return key
+		}
+		return strings.Join([]string{path, key}, ".")
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) && len(path) != 0 {
+			fn(path, v)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			numbers(v[key], join(key), fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			numbers(item, join(fmt.Sprint(i)), fn)
+		}
+	}
+}
+
+func lu64(v []byte) uint64 {
+	return binary.BigEndian.Uint64(v)
+}
+
+func u64(u uint64) []byte {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], u)
+	return v[:]
+}
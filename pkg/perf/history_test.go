@@ -0,0 +1,129 @@
+package perf
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestRetain(t *testing.T) {
+	history := testMonitor(t).history
+
+	keys := func() []uint64 {
+		var keys []uint64
+		err := history.db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket([]byte("task")).ForEach(func(k, _ []byte) error {
+				keys = append(keys, lu64(k))
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return keys
+	}
+
+	retainIn := func(before uint64, max int, add ...uint64) {
+		err := history.db.Update(func(tx *bolt.Tx) error {
+			bucket, err := tx.CreateBucketIfNotExists([]byte("task"))
+			if err != nil {
+				return err
+			}
+			// keys added in the same transaction must be accounted for
+			for _, k := range add {
+				if err := bucket.Put(u64(k), []byte("{}")); err != nil {
+					return err
+				}
+			}
+			return retain(bucket, before, max)
+		})
+		require.NoError(t, err)
+	}
+
+	retainIn(0, 3, 1, 2, 3, 4, 5)
+	require.Equal(t, []uint64{3, 4, 5}, keys())
+
+	retainIn(0, 3, 6)
+	require.Equal(t, []uint64{4, 5, 6}, keys())
+
+	retainIn(6, 3)
+	require.Equal(t, []uint64{6}, keys())
+
+	retainIn(10, 3)
+	require.Empty(t, keys())
+}
+
+func TestHistoryAddRuns(t *testing.T) {
+	history := testMonitor(t).history
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		run := pkg.TaskRun{Name: "task", Timestamp: uint64(now.Add(time.Duration(i) * time.Minute).Unix())}
+		require.NoError(t, history.add(now.Add(time.Duration(i)*time.Minute), run))
+	}
+
+	runs, err := history.runs("task", uint64(now.Unix()), uint64(now.Add(time.Minute).Unix()))
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	runs, err = history.runs("unknown", 0, uint64(now.Unix()))
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+func TestNumbers(t *testing.T) {
+	var value interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"write": 10,
+		"name": "pool",
+		"pools": [{"read": 1.5}, {"read": 2}],
+		"nested": {"a": {"b": 3}}
+	}`), &value))
+
+	found := map[string]float64{}
+	numbers(value, "", func(path string, v float64) {
+		found[path] = v
+	})
+
+	require.Equal(t, map[string]float64{
+		"write":        10,
+		"pools.0.read": 1.5,
+		"pools.1.read": 2,
+		"nested.a.b":   3,
+	}, found)
+
+	// a bare number has no path
+	numbers(float64(1), "", func(path string, v float64) {
+		t.Fatalf("unexpected number at path '%s'", path)
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	runs := []pkg.TaskRun{
+		{Duration: 10, Result: map[string]interface{}{"speed": float64(100)}},
+		{Duration: 20, Result: map[string]interface{}{"speed": float64(300)}},
+		{Duration: 30, Error: "failed"},
+	}
+
+	summary := summarize(runs)
+	require.EqualValues(t, 3, summary.Runs)
+	require.EqualValues(t, 1, summary.Failures)
+
+	require.EqualValues(t, 3, summary.Duration.Count)
+	require.Equal(t, float64(10), summary.Duration.Min)
+	require.Equal(t, float64(30), summary.Duration.Max)
+	require.Equal(t, float64(20), summary.Duration.Avg)
+
+	speed, ok := summary.Metrics["speed"]
+	require.True(t, ok)
+	require.EqualValues(t, 2, speed.Count)
+	require.Equal(t, float64(100), speed.Min)
+	require.Equal(t, float64(300), speed.Max)
+	require.Equal(t, float64(200), speed.Avg)
+
+	empty := summarize(nil)
+	require.Zero(t, empty.Runs)
+	require.Empty(t, empty.Metrics)
+}
@@ -0,0 +1,142 @@
+package perf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+)
+
+const (
+	// jobTTL how long a job status is kept in redis
+	jobTTL = 24 * time.Hour
+	// triggerInterval is the minimum interval between two on demand
+	// runs of the same task
+	triggerInterval = 5 * time.Minute
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// jobKey generates the redis key of a job. It must not match
+// the task results keys pattern, see GetAll
+func jobKey(id string) string {
+	return fmt.Sprintf("%s-job.%s", moduleName, id)
+}
+
+// Trigger runs the task with the given name on demand. The task is executed in the
+// background and the returned job ID can be used to poll the job status with Job.
+// A task can not be triggered more than once every triggerInterval, or while it's
+// still running (on demand or by the scheduler).
+func (pm *PerformanceMonitor) Trigger(taskName string) (string, error) {
+	task, ok := pm.task(taskName)
+	if !ok {
+		return "", fmt.Errorf("task '%s' not found", taskName)
+	}
+
+	pm.m.Lock()
+	defer pm.m.Unlock()
+
+	if pm.ctx == nil {
+		return "", fmt.Errorf("performance monitor is not running")
+	}
+
+	if _, ok := pm.running[task.ID()]; ok {
+		return "", fmt.Errorf("task '%s' is already running", task.ID())
+	}
+
+	if last, ok := pm.triggered[task.ID()]; ok {
+		if wait := triggerInterval - time.Since(last); wait > 0 {
+			return "", fmt.Errorf("task '%s' was triggered recently, try again in %s", task.ID(), wait.Round(time.Second))
+		}
+	}
+
+	job := pkg.TaskJob{
+		ID:      uuid.NewString(),
+		Task:    task.ID(),
+		Status:  pkg.JobPending,
+		Created: uint64(time.Now().Unix()),
+	}
+
+	if err := pm.setJob(job); err != nil {
+		return "", err
+	}
+
+	pm.triggered[task.ID()] = time.Now()
+	pm.running[task.ID()] = struct{}{}
+
+	go pm.runJob(pm.ctx, job, task)
+
+	return job.ID, nil
+}
+
+// runJob executes the task of the job, and keep the job status up to date.
+// the task must be already marked as running
+func (pm *PerformanceMonitor) runJob(ctx context.Context, job pkg.TaskJob, task Task) {
+	defer pm.end(task.ID())
+
+	log := log.With().Str("task", task.ID()).Str("job", job.ID).Logger()
+	log.Info().Msg("running task on demand")
+
+	job.Status = pkg.JobRunning
+	if err := pm.setJob(job); err != nil {
+		log.Error().Err(err).Msg("failed to update job status")
+	}
+
+	result, err := pm.execute(ctx, task)
+	job.Finished = uint64(time.Now().Unix())
+	if err != nil {
+		log.Error().Err(err).Msg("on demand task failed")
+		job.Status = pkg.JobFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = pkg.JobDone
+		job.Result = &result
+	}
+
+	if err := pm.setJob(job); err != nil {
+		log.Error().Err(err).Msg("failed to update job status")
+	}
+}
+
+// setJob stores the job in redis
+func (pm *PerformanceMonitor) setJob(job pkg.TaskJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal job to JSON")
+	}
+
+	conn := pm.pool.Get()
+	defer conn.Close()
+
+	_, err = conn.Do("SET", jobKey(job.ID), data, "EX", int(jobTTL.Seconds()))
+	return err
+}
+
+// Job returns the status of the on demand job with the given id
+func (pm *PerformanceMonitor) Job(id string) (pkg.TaskJob, error) {
+	var job pkg.TaskJob
+
+	conn := pm.pool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", jobKey(id)))
+	if errors.Is(err, redis.ErrNil) {
+		return job, ErrJobNotFound
+	} else if err != nil {
+		return job, errors.Wrap(err, "failed to get job")
+	}
+
+	if err := json.Unmarshal(data, &job); err != nil {
+		return job, errors.Wrap(err, "failed to unmarshal job from json")
+	}
+
+	return job, nil
+}
@@ -0,0 +1,130 @@
+package membench
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/threefoldtech/test/pkg/perf"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	// bufferSize is the size of the buffers used for the benchmark, it
+	// must be big enough to not fit in the CPU caches but small enough
+	// to not put pressure on the node memory
+	bufferSize = 64 * 1024 * 1024
+	// rounds number of times the buffer is processed
+	rounds = 32
+)
+
+// MemoryBenchmarkTask defines the memory benchmark task.
+type MemoryBenchmarkTask struct{}
+
+// MemoryBenchmarkResult holds the memory bandwidth in MB/s with the workloads number during the benchmark.
+type MemoryBenchmarkResult struct {
+	// Write bandwidth in MB/s
+	Write float64 `json:"write"`
+	// Read bandwidth in MB/s
+	Read float64 `json:"read"`
+	// Copy bandwidth in MB/s
+	Copy      float64 `json:"copy"`
+	Workloads int     `json:"workloads"`
+}
+
+var _ perf.Task = (*MemoryBenchmarkTask)(nil)
+
+// NewTask returns a new memory benchmark task.
+func NewTask() perf.Task {
+	return &MemoryBenchmarkTask{}
+}
+
+// ID returns task ID.
+func (m *MemoryBenchmarkTask) ID() string {
+	return "memory-benchmark"
+}
+
+// Cron returns task cron schedule.
+func (m *MemoryBenchmarkTask) Cron() string {
+	return "0 15 */6 * * *"
+}
+
+// Description returns task description.
+func (m *MemoryBenchmarkTask) Description() string {
+	return "Measures the memory bandwidth of the node in MB/s for write, read and copy operations."
+}
+
+// Jitter returns the max number of seconds the job can sleep before actual execution.
+func (m *MemoryBenchmarkTask) Jitter() uint32 {
+	return 0
+}
+
+// Run executes the memory benchmark.
+func (m *MemoryBenchmarkTask) Run(ctx context.Context) (interface{}, error) {
+	result := Benchmark()
+
+	client := perf.MustGetZbusClient(ctx)
+	statistics := stubs.NewStatisticsStub(client)
+
+	workloads, err := statistics.Workloads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workloads number: %w", err)
+	}
+
+	result.Workloads = workloads
+	return result, nil
+}
+
+// Benchmark measures the memory bandwidth. The benchmark buffers are
+// released to the OS once done since noded is a long running process
+func Benchmark() MemoryBenchmarkResult {
+	defer debug.FreeOSMemory()
+	return benchmark(bufferSize, rounds)
+}
+
+func benchmark(size, rounds int) MemoryBenchmarkResult {
+	src := make([]uint64, size/8)
+	dst := make([]uint64, size/8)
+
+	var result MemoryBenchmarkResult
+
+	start := time.Now()
+	for r := 0; r < rounds; r++ {
+		for i := range src {
+			src[i] = uint64(i + r)
+		}
+	}
+	result.Write = bandwidth(size, rounds, time.Since(start))
+
+	var sum uint64
+	start = time.Now()
+	for r := 0; r < rounds; r++ {
+		for _, v := range src {
+			sum += v
+		}
+	}
+	result.Read = bandwidth(size, rounds, time.Since(start))
+
+	start = time.Now()
+	for r := 0; r < rounds; r++ {
+		copy(dst, src)
+	}
+	result.Copy = bandwidth(size, rounds, time.Since(start))
+
+	// make sure the read loop is not optimized away
+	if sum == 0 && dst[len(dst)-1] == 1 {
+		result.Read = 0
+	}
+
+	return result
+}
+
+// bandwidth in MB/s of processing a buffer of size bytes for all rounds in the given duration
+func bandwidth(size, rounds int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(size) * float64(rounds) / (1000 * 1000) / elapsed.Seconds()
+}
@@ -0,0 +1,21 @@
+package membench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidth(t *testing.T) {
+	require.Equal(t, float64(0), bandwidth(1000, 1, 0))
+	require.Equal(t, float64(8), bandwidth(1000*1000, 8, time.Second))
+	require.Equal(t, float64(16), bandwidth(1000*1000, 8, 500*time.Millisecond))
+}
+
+func TestBenchmark(t *testing.T) {
+	result := benchmark(1024*1024, 2)
+	require.Greater(t, result.Write, float64(0))
+	require.Greater(t, result.Read, float64(0))
+	require.Greater(t, result.Copy, float64(0))
+}
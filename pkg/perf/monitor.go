@@ -3,6 +3,7 @@ package perf
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
@@ -21,12 +22,26 @@ type PerformanceMonitor struct {
 	pool       *redis.Pool
 	zbusClient zbus.Client
 	tasks      []Task
+	history    *history
+
+	// ctx is the context passed to Run, on demand
+	// tasks are executed with this context
+	ctx context.Context
+
+	// triggered holds the last time a task was triggered on demand
+	triggered map[string]time.Time
+	// running holds the tasks that are currently executed, either
+	// on demand or by the scheduler
+	running map[string]struct{}
+	// m protects ctx, triggered and running
+	m sync.Mutex
 }
 
 var _ pkg.PerformanceMonitor = (*PerformanceMonitor)(nil)
 
-// NewPerformanceMonitor returns PerformanceMonitor instance
-func NewPerformanceMonitor(redisAddr string) (*PerformanceMonitor, error) {
+// NewPerformanceMonitor returns PerformanceMonitor instance, the history
+// of the tasks runs is stored in a database at historyPath
+func NewPerformanceMonitor(redisAddr string, historyPath string) (*PerformanceMonitor, error) {
 	redisPool, err := utils.NewRedisPool(redisAddr)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed creating new redis pool")
@@ -36,6 +51,11 @@ func NewPerformanceMonitor(redisAddr string) (*PerformanceMonitor, error) {
 		return nil, errors.Wrap(err, "failed to connect to zbus")
 	}
 
+	history, err := newHistory(historyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open tasks history")
+	}
+
 	scheduler := gocron.NewScheduler(time.UTC)
 
 	return &PerformanceMonitor{
@@ -43,6 +63,9 @@ func NewPerformanceMonitor(redisAddr string) (*PerformanceMonitor, error) {
 		pool:       redisPool,
 		zbusClient: zbusClient,
 		tasks:      []Task{},
+		history:    history,
+		triggered:  make(map[string]time.Time),
+		running:    make(map[string]struct{}),
 	}, nil
 }
 
@@ -51,34 +74,104 @@ func (pm *PerformanceMonitor) AddTask(task Task) {
 	pm.tasks = append(pm.tasks, task)
 }
 
-// runTask runs the task and store its result
+// task returns the registered task with the given id
+func (pm *PerformanceMonitor) task(id string) (Task, bool) {
+	for _, task := range pm.tasks {
+		if task.ID() == id {
+			return task, true
+		}
+	}
+
+	return nil, false
+}
+
+// begin marks the task as running, it returns false if the
+// task is already running
+func (pm *PerformanceMonitor) begin(id string) bool {
+	pm.m.Lock()
+	defer pm.m.Unlock()
+
+	if _, ok := pm.running[id]; ok {
+		return false
+	}
+
+	pm.running[id] = struct{}{}
+	return true
+}
+
+// end marks the task as not running
+func (pm *PerformanceMonitor) end(id string) {
+	pm.m.Lock()
+	defer pm.m.Unlock()
+
+	delete(pm.running, id)
+}
+
+// runTask runs the task and store its result. The run is skipped
+// if the task is already running (for example triggered on demand)
 func (pm *PerformanceMonitor) runTask(ctx context.Context, task Task) error {
 	if task.Jitter() != 0 {
 		sleepInterval := time.Duration(rand.Int31n(int32(task.Jitter()))) * time.Second
 		time.Sleep(sleepInterval)
 	}
 
+	if !pm.begin(task.ID()) {
+		log.Info().Str("task", task.ID()).Msg("task is already running, skipping scheduled run")
+		return nil
+	}
+	defer pm.end(task.ID())
+
+	_, err := pm.execute(ctx, task)
+	return err
+}
+
+// execute runs the task right away and store its result. The run
+// is recorded in the history whether it succeeded or not
+func (pm *PerformanceMonitor) execute(ctx context.Context, task Task) (pkg.TaskResult, error) {
+	started := time.Now()
 	res, err := task.Run(ctx)
+
+	run := pkg.TaskRun{
+		Name:      task.ID(),
+		Timestamp: uint64(started.Unix()),
+		Duration:  uint64(time.Since(started).Milliseconds()),
+		Result:    res,
+	}
+	if err != nil {
+		run.Result = nil
+		run.Error = err.Error()
+	}
+
+	if err := pm.history.add(started, run); err != nil {
+		log.Error().Err(err).Str("task", task.ID()).Msg("failed to store task run in history")
+	}
+
 	if err != nil {
-		return errors.Wrapf(err, "failed to run task: %s", task.ID())
+		return pkg.TaskResult{}, errors.Wrapf(err, "failed to run task: %s", task.ID())
 	}
 
-	err = pm.setCache(ctx, pkg.TaskResult{
+	result := pkg.TaskResult{
 		Name:        task.ID(),
 		Timestamp:   uint64(time.Now().Unix()),
 		Description: task.Description(),
 		Result:      res,
-	})
-	if err != nil {
-		return errors.Wrap(err, "failed to set cache")
 	}
 
-	return nil
+	if err := pm.setCache(ctx, result); err != nil {
+		return result, errors.Wrap(err, "failed to set cache")
+	}
+
+	return result, nil
 }
 
 // Run adds the tasks to the cron queue and start the scheduler
 func (pm *PerformanceMonitor) Run(ctx context.Context) error {
 	ctx = WithZbusClient(ctx, pm.zbusClient)
+
+	pm.m.Lock()
+	pm.ctx = ctx
+	pm.m.Unlock()
+
 	for _, task := range pm.tasks {
 		task := task
 		if _, err := pm.scheduler.CronWithSeconds(task.Cron()).Do(func() error {
@@ -103,5 +196,14 @@ func (pm *PerformanceMonitor) Run(ctx context.Context) error {
 	}
 
 	pm.scheduler.StartAsync()
+
+	go func() {
+		<-ctx.Done()
+		pm.scheduler.Stop()
+		if err := pm.history.Close(); err != nil {
+			log.Error().Err(err).Msg("failed to close tasks history")
+		}
+	}()
+
 	return nil
 }
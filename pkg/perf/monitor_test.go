@@ -0,0 +1,83 @@
+package perf
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTask struct {
+	runs  int32
+	block chan struct{}
+}
+
+func (t *testTask) ID() string          { return "test-task" }
+func (t *testTask) Cron() string        { return "0 0 */6 * * *" }
+func (t *testTask) Description() string { return "test task" }
+func (t *testTask) Jitter() uint32      { return 0 }
+func (t *testTask) Run(ctx context.Context) (interface{}, error) {
+	atomic.AddInt32(&t.runs, 1)
+	<-t.block
+	return nil, nil
+}
+
+func testMonitor(t *testing.T, tasks ...Task) *PerformanceMonitor {
+	history, err := newHistory(t.TempDir() + "/history.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = history.Close() })
+
+	return &PerformanceMonitor{
+		tasks:     tasks,
+		history:   history,
+		triggered: make(map[string]time.Time),
+		running:   make(map[string]struct{}),
+	}
+}
+
+func TestBeginEnd(t *testing.T) {
+	pm := testMonitor(t)
+
+	require.True(t, pm.begin("task"))
+	require.False(t, pm.begin("task"))
+	require.True(t, pm.begin("other"))
+
+	pm.end("task")
+	require.True(t, pm.begin("task"))
+}
+
+func TestTriggerNotRunning(t *testing.T) {
+	task := &testTask{}
+	pm := testMonitor(t, task)
+
+	_, err := pm.Trigger("unknown")
+	require.Error(t, err)
+
+	_, err = pm.Trigger(task.ID())
+	require.ErrorContains(t, err, "not running")
+}
+
+func TestTriggerWhileScheduled(t *testing.T) {
+	task := &testTask{}
+	pm := testMonitor(t, task)
+	pm.ctx = context.Background()
+
+	// a scheduled run is in progress
+	require.True(t, pm.begin(task.ID()))
+
+	_, err := pm.Trigger(task.ID())
+	require.ErrorContains(t, err, "already running")
+}
+
+func TestRunTaskSkipsRunning(t *testing.T) {
+	task := &testTask{block: make(chan struct{})}
+	pm := testMonitor(t, task)
+
+	// the task is running on demand
+	require.True(t, pm.begin(task.ID()))
+
+	require.NoError(t, pm.runTask(context.Background(), task))
+	require.EqualValues(t, 0, atomic.LoadInt32(&task.runs))
+}
@@ -5,6 +5,15 @@ package pkg
 type PerformanceMonitor interface {
 	Get(taskName string) (TaskResult, error)
 	GetAll() ([]TaskResult, error)
+	// Trigger runs the task on demand and returns the job ID
+	// that can be used to poll the job status
+	Trigger(taskName string) (string, error)
+	// Job returns the status of an on demand task run
+	Job(id string) (TaskJob, error)
+	// History returns the stored runs of a task in the given time
+	// range (unix timestamps, inclusive) with a summary of these runs.
+	// A zero to means up to now.
+	History(taskName string, from, to uint64) (TaskHistory, error)
 }
 
 // TaskResult the result test schema
@@ -14,3 +23,68 @@ type TaskResult struct {
 	Timestamp   uint64      `json:"timestamp"`
 	Result      interface{} `json:"result"`
 }
+
+// JobStatus status of an on demand task run
+type JobStatus string
+
+const (
+	// JobPending job is created but not started yet
+	JobPending JobStatus = "pending"
+	// JobRunning job is running
+	JobRunning JobStatus = "running"
+	// JobDone job finished successfully, result is available
+	JobDone JobStatus = "done"
+	// JobFailed job failed, error is set
+	JobFailed JobStatus = "failed"
+)
+
+// TaskJob is an on demand run of a performance task
+type TaskJob struct {
+	ID       string      `json:"id"`
+	Task     string      `json:"task"`
+	Status   JobStatus   `json:"status"`
+	Created  uint64      `json:"created"`
+	Finished uint64      `json:"finished"`
+	Error    string      `json:"error,omitempty"`
+	Result   *TaskResult `json:"result,omitempty"`
+}
+
+// TaskRun is a single run of a performance task as kept in the history
+type TaskRun struct {
+	Name      string `json:"name"`
+	Timestamp uint64 `json:"timestamp"`
+	// Duration of the run in milliseconds
+	Duration uint64      `json:"duration"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// MetricSummary aggregates a numeric value of the task result
+// over multiple runs
+type MetricSummary struct {
+	Count uint64  `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+}
+
+// HistorySummary is a simple aggregation of task runs
+type HistorySummary struct {
+	Runs     uint64 `json:"runs"`
+	Failures uint64 `json:"failures"`
+	// Duration summary of the runs in milliseconds
+	Duration MetricSummary `json:"duration"`
+	// Metrics summary of all numeric values in the successful runs
+	// results, keyed by the value path in the result, for example
+	// `multi` or `download_speed`
+	Metrics map[string]MetricSummary `json:"metrics"`
+}
+
+// TaskHistory the runs of a task over a time range
+type TaskHistory struct {
+	Name    string         `json:"name"`
+	From    uint64         `json:"from"`
+	To      uint64         `json:"to"`
+	Runs    []TaskRun      `json:"runs"`
+	Summary HistorySummary `json:"summary"`
+}
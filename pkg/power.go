@@ -0,0 +1,106 @@
+package pkg
+
+//go:generate mkdir -p stubs
+//go:generate zbusc -module power -version 0.0.1 -name power -package stubs github.com/threefoldtech/test/pkg+PowerManager stubs/power_stub.go
+
+// ShutdownState is the outcome of a node shutdown request
+type ShutdownState string
+
+const (
+	// ShutdownStateDeferred the shutdown is deferred because of running workloads
+	ShutdownStateDeferred ShutdownState = "deferred"
+	// ShutdownStateCancelled the power target went up again before the node
+	// could shutdown
+	ShutdownStateCancelled ShutdownState = "cancelled"
+	// ShutdownStateDone the node was shutdown
+	ShutdownStateDone ShutdownState = "done"
+)
+
+// ShutdownReport is the report of the last shutdown request of the node. It's
+// persisted so it can be read after the node boots again
+type ShutdownReport struct {
+	State ShutdownState `json:"state"`
+	// Reason why the shutdown was deferred
+	Reason string `json:"reason"`
+	// Requested is when the shutdown was first requested
+	Requested uint64 `json:"requested"`
+	// Updated is when the report was last updated
+	Updated uint64 `json:"updated"`
+	// RentContract of the node at the time of the shutdown
+	RentContract uint64 `json:"rent_contract"`
+	// Blockers are the workloads that prevent the node from shutting down
+	Blockers []ActiveWorkload `json:"blockers"`
+	// Stopped are the virtual machines that were stopped before shutdown
+	Stopped []string `json:"stopped"`
+	// Errors during the shutdown
+	Errors []string `json:"errors"`
+}
+
+// PowerBackendType is the type of the power control backend of a node
+type PowerBackendType string
+
+const (
+	// PowerBackendWoL powers on nodes with wake on lan, this only works for
+	// nodes in the same network segment and can't power cycle nodes
+	PowerBackendWoL PowerBackendType = "wol"
+	// PowerBackendRedfish controls the node power over the redfish api of its BMC
+	PowerBackendRedfish PowerBackendType = "redfish"
+	// PowerBackendIPMI controls the node power over ipmi (lan+) of its BMC
+	PowerBackendIPMI PowerBackendType = "ipmi"
+)
+
+// PowerBackendConfig configures how a node is powered on and off
+type PowerBackendConfig struct {
+	Type PowerBackendType `json:"type"`
+	// Address of the node BMC. The base url (https://host) for redfish, and
+	// host[:port] for ipmi
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// System is the redfish system id, defaults to the first system
+	System string `json:"system"`
+	// Insecure skips the verification of the BMC tls certificate
+	Insecure bool `json:"insecure"`
+}
+
+// FarmPowerConfig is the power control configuration of the farm nodes.
+// Nodes that are not configured are powered on with wake on lan.
+// The config is shared by all the nodes of the farm.
+type FarmPowerConfig struct {
+	Nodes map[uint32]PowerBackendConfig `json:"nodes"`
+	// Updated is when the config was last set, nodes always keep
+	// the most recent config of the farm
+	Updated uint64 `json:"updated"`
+}
+
+// PowerStatus is the power status of a node
+type PowerStatus string
+
+const (
+	PowerStatusOn      PowerStatus = "on"
+	PowerStatusOff     PowerStatus = "off"
+	PowerStatusUnknown PowerStatus = "unknown"
+)
+
+// PowerManager interface (provided by powerd)
+type PowerManager interface {
+	// LastShutdown returns the report of the last shutdown request
+	LastShutdown() (ShutdownReport, error)
+	// ConfigGet returns the farm power configuration, passwords are not returned
+	ConfigGet() (FarmPowerConfig, error)
+	// ConfigExport returns the farm power configuration with the passwords,
+	// it's only shared with the other nodes of the farm
+	ConfigExport() (FarmPowerConfig, error)
+	// ConfigSet sets the farm power configuration, empty passwords keep the
+	// stored password of the node
+	ConfigSet(cfg FarmPowerConfig) error
+	// ConfigSync stores the farm power configuration received from another
+	// node of the farm, if it's more recent than the stored config
+	ConfigSync(cfg FarmPowerConfig) error
+	// PowerOn powers on a node in the same farm
+	PowerOn(node uint32) error
+	// PowerCycle power cycles a node in the same farm
+	PowerCycle(node uint32) error
+	// PowerState queries the power state of a node in the same farm
+	PowerState(node uint32) (PowerStatus, error)
+}
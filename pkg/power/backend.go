@@ -0,0 +1,523 @@
+package power
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	powerConfigFile = "power.json"
+
+	backendTimeout = 30 * time.Second
+
+	// maxClockSkew is how far in the future the updated time of a farm
+	// power config can be, configs from further ahead are rejected so a
+	// single node with a wrong clock can't pin the config of the farm
+	maxClockSkew = uint64(10 * time.Minute / time.Second)
+)
+
+// ErrNotSupported is returned if the power backend of the node does
+// not support the operation
+var ErrNotSupported = fmt.Errorf("operation not supported by power backend")
+
+// Backend controls the power of a single node
+type Backend interface {
+	// PowerOn powers on the node
+	PowerOn(ctx context.Context) error
+	// PowerCycle power cycles the node
+	PowerCycle(ctx context.Context) error
+	// State returns the power state of the node
+	State(ctx context.Context) (pkg.PowerStatus, error)
+}
+
+// wolBackend powers on nodes with wake on lan over the test bridge, the
+// power state is taken from the node power state on chain
+type wolBackend struct {
+	substrateGateway *stubs.SubstrateGatewayStub
+	node             *substrate.Node
+}
+
+func (w *wolBackend) PowerOn(ctx context.Context) error {
+	mac := ""
+	for _, inf := range w.node.Interfaces {
+		if inf.Name == "test" {
+			mac = inf.Mac
+			break
+		}
+	}
+	if mac == "" {
+		return fmt.Errorf("can't find mac address of node '%d'", w.node.ID)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := exec.CommandContext(ctx, "ether-wake", "-i", "test", mac).Run(); err != nil {
+			log.Error().Err(err).Msg("failed to send WOL")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	return nil
+}
+
+func (w *wolBackend) PowerCycle(ctx context.Context) error {
+	return ErrNotSupported
+}
+
+func (w *wolBackend) State(ctx context.Context) (pkg.PowerStatus, error) {
+	power, err := w.substrateGateway.GetPowerTarget(ctx, uint32(w.node.ID))
+	if err != nil {
+		return pkg.PowerStatusUnknown, errors.Wrap(err, "failed to get node power state")
+	}
+
+	if power.State.IsUp {
+		return pkg.PowerStatusOn, nil
+	}
+
+	return pkg.PowerStatusOff, nil
+}
+
+// redfishBackend controls the node power over the redfish api of its BMC
+type redfishBackend struct {
+	cfg    pkg.PowerBackendConfig
+	client *http.Client
+}
+
+func newRedfishBackend(cfg pkg.PowerBackendConfig) *redfishBackend {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &redfishBackend{
+		cfg:    cfg,
+		client: &http.Client{Transport: transport, Timeout: backendTimeout},
+	}
+}
+
+func (r *redfishBackend) do(ctx context.Context, method, path string, input, output interface{}) error {
+	var body bytes.Buffer
+	if input != nil {
+		if err := json.NewEncoder(&body).Encode(input); err != nil {
+			return err
+		}
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(r.cfg.Address, "/")+path, &body)
+	if err != nil {
+		return err
+	}
+
+	request.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	request.Header.Set("Accept", "application/json")
+	if input != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := r.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("redfish request '%s %s' failed: %s", method, path, response.Status)
+	}
+
+	if output == nil {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(output)
+}
+
+// system returns the path of the redfish computer system
+func (r *redfishBackend) system(ctx context.Context) (string, error) {
+	if len(r.cfg.System) != 0 {
+		return fmt.Sprintf("/redfish/v1/Systems/%s", r.cfg.System), nil
+	}
+
+	var systems struct {
+		Members []struct {
+			ID string `json:"@odata.id"`
+		} `json:"Members"`
+	}
+
+	if err := r.do(ctx, http.MethodGet, "/redfish/v1/Systems", nil, &systems); err != nil {
+		return "", errors.Wrap(err, "failed to list redfish systems")
+	}
+
+	if len(systems.Members) == 0 {
+		return "", fmt.Errorf("no redfish systems found")
+	}
+
+	return systems.Members[0].ID, nil
+}
+
+func (r *redfishBackend) reset(ctx context.Context, typ string) error {
+	system, err := r.system(ctx)
+	if err != nil {
+		return err
+	}
+
+	input := struct {
+		ResetType string `json:"ResetType"`
+	}{typ}
+
+	return r.do(ctx, http.MethodPost, system+"/Actions/ComputerSystem.Reset", &input, nil)
+}
+
+func (r *redfishBackend) PowerOn(ctx context.Context) error {
+	return r.reset(ctx, "On")
+}
+
+func (r *redfishBackend) PowerCycle(ctx context.Context) error {
+	return r.reset(ctx, "PowerCycle")
+}
+
+func (r *redfishBackend) State(ctx context.Context) (pkg.PowerStatus, error) {
+	system, err := r.system(ctx)
+	if err != nil {
+		return pkg.PowerStatusUnknown, err
+	}
+
+	var info struct {
+		PowerState string `json:"PowerState"`
+	}
+
+	if err := r.do(ctx, http.MethodGet, system, nil, &info); err != nil {
+		return pkg.PowerStatusUnknown, errors.Wrap(err, "failed to get redfish system")
+	}
+
+	switch strings.ToLower(info.PowerState) {
+	case "on", "poweringon":
+		return pkg.PowerStatusOn, nil
+	case "off", "poweringoff":
+		return pkg.PowerStatusOff, nil
+	default:
+		return pkg.PowerStatusUnknown, nil
+	}
+}
+
+// ipmiBackend controls the node power over ipmi with ipmitool
+type ipmiBackend struct {
+	cfg pkg.PowerBackendConfig
+}
+
+func (i *ipmiBackend) run(ctx context.Context, args ...string) (string, error) {
+	host, port, err := net.SplitHostPort(i.cfg.Address)
+	if err != nil {
+		// no port
+		host, port = i.cfg.Address, ""
+	}
+
+	// the password is passed over the environment (-E) so it does not
+	// show in the process list
+	cmdArgs := []string{"-I", "lanplus", "-H", host, "-U", i.cfg.Username, "-E"}
+	if len(port) != 0 {
+		cmdArgs = append(cmdArgs, "-p", port)
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	ctx, cancel := context.WithTimeout(ctx, backendTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ipmitool", cmdArgs...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("IPMI_PASSWORD=%s", i.cfg.Password))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "ipmitool failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	return string(output), nil
+}
+
+func (i *ipmiBackend) PowerOn(ctx context.Context) error {
+	_, err := i.run(ctx, "chassis", "power", "on")
+	return err
+}
+
+func (i *ipmiBackend) PowerCycle(ctx context.Context) error {
+	_, err := i.run(ctx, "chassis", "power", "cycle")
+	return err
+}
+
+func (i *ipmiBackend) State(ctx context.Context) (pkg.PowerStatus, error) {
+	output, err := i.run(ctx, "chassis", "power", "status")
+	if err != nil {
+		return pkg.PowerStatusUnknown, err
+	}
+
+	return parseIPMIStatus(output), nil
+}
+
+// parseIPMIStatus parses the output of `ipmitool chassis power status`
+// which looks like `Chassis Power is on`
+func parseIPMIStatus(output string) pkg.PowerStatus {
+	output = strings.ToLower(strings.TrimSpace(output))
+	switch {
+	case strings.HasSuffix(output, " on"):
+		return pkg.PowerStatusOn
+	case strings.HasSuffix(output, " off"):
+		return pkg.PowerStatusOff
+	default:
+		return pkg.PowerStatusUnknown
+	}
+}
+
+func validateBackend(cfg pkg.PowerBackendConfig) error {
+	switch cfg.Type {
+	case pkg.PowerBackendWoL:
+		return nil
+	case pkg.PowerBackendRedfish:
+		u, err := url.Parse(cfg.Address)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid redfish address '%s', expecting http(s)://host", cfg.Address)
+		}
+	case pkg.PowerBackendIPMI:
+		if len(cfg.Address) == 0 {
+			return fmt.Errorf("ipmi address is required")
+		}
+	default:
+		return fmt.Errorf("unknown power backend type '%s'", cfg.Type)
+	}
+
+	if len(cfg.Username) == 0 {
+		return fmt.Errorf("%s username is required", cfg.Type)
+	}
+
+	return nil
+}
+
+func (p *PowerServer) configFile() string {
+	return filepath.Join(p.root, powerConfigFile)
+}
+
+func (p *PowerServer) loadConfig() (pkg.FarmPowerConfig, error) {
+	var cfg pkg.FarmPowerConfig
+	data, err := os.ReadFile(p.configFile())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, errors.Wrap(err, "failed to read power config")
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, errors.Wrap(err, "failed to decode power config")
+	}
+
+	return cfg, nil
+}
+
+// ConfigGet returns the farm power configuration, passwords are not returned
+func (p *PowerServer) ConfigGet() (pkg.FarmPowerConfig, error) {
+	cfg, err := p.loadConfig()
+	if err != nil {
+		return cfg, err
+	}
+
+	for id, backend := range cfg.Nodes {
+		backend.Password = ""
+		cfg.Nodes[id] = backend
+	}
+
+	return cfg, nil
+}
+
+func (p *PowerServer) saveConfig(cfg pkg.FarmPowerConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode power config")
+	}
+
+	// the config has the BMC credentials
+	tmp := p.configFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrap(err, "failed to write power config")
+	}
+
+	return os.Rename(tmp, p.configFile())
+}
+
+func validateConfig(cfg pkg.FarmPowerConfig) error {
+	for id, backend := range cfg.Nodes {
+		if err := validateBackend(backend); err != nil {
+			return errors.Wrapf(err, "invalid power backend for node '%d'", id)
+		}
+	}
+
+	return nil
+}
+
+// inFuture returns true if updated is too far ahead of now
+func inFuture(updated, now uint64) bool {
+	return updated > now && updated-now > maxClockSkew
+}
+
+// mergeConfig returns cfg where empty passwords are replaced with the passwords
+// in stored, since ConfigGet never returns the passwords. The updated time is
+// always after the stored one, unless the stored one is too far in the future.
+func mergeConfig(stored, cfg pkg.FarmPowerConfig, now uint64) pkg.FarmPowerConfig {
+	for id, backend := range cfg.Nodes {
+		if len(backend.Password) != 0 {
+			continue
+		}
+
+		if old, ok := stored.Nodes[id]; ok {
+			backend.Password = old.Password
+			cfg.Nodes[id] = backend
+		}
+	}
+
+	cfg.Updated = now
+	if cfg.Updated <= stored.Updated && !inFuture(stored.Updated, now) {
+		cfg.Updated = stored.Updated + 1
+	}
+
+	return cfg
+}
+
+// ConfigExport returns the farm power configuration with the passwords,
+// it's only shared with the other nodes of the farm after the passwords
+// are encrypted to the receiving node
+func (p *PowerServer) ConfigExport() (pkg.FarmPowerConfig, error) {
+	return p.loadConfig()
+}
+
+// ConfigSet sets the farm power configuration, empty passwords keep the
+// stored password of the node
+func (p *PowerServer) ConfigSet(cfg pkg.FarmPowerConfig) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	stored, err := p.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	return p.saveConfig(mergeConfig(stored, cfg, uint64(time.Now().Unix())))
+}
+
+// ConfigSync stores the farm power configuration received from another
+// node of the farm, if it's more recent than the stored config
+func (p *PowerServer) ConfigSync(cfg pkg.FarmPowerConfig) error {
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	now := uint64(time.Now().Unix())
+	if inFuture(cfg.Updated, now) {
+		return fmt.Errorf("farm power config updated time '%d' is in the future", cfg.Updated)
+	}
+
+	stored, err := p.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// a stored config from the future was received before the updated
+	// time was checked, it's replaced by any valid config
+	if cfg.Updated <= stored.Updated && !inFuture(stored.Updated, now) {
+		log.Debug().Uint64("updated", cfg.Updated).Msg("ignoring older farm power config")
+		return nil
+	}
+
+	return p.saveConfig(cfg)
+}
+
+// backend returns the power backend of a node in the farm
+func (p *PowerServer) backend(node *substrate.Node) (Backend, error) {
+	if uint32(node.FarmID) != uint32(p.farm) {
+		return nil, fmt.Errorf("node '%d' is not in farm '%d'", node.ID, p.farm)
+	}
+
+	cfg, err := p.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	backend, ok := cfg.Nodes[uint32(node.ID)]
+	if !ok {
+		backend.Type = pkg.PowerBackendWoL
+	}
+
+	switch backend.Type {
+	case pkg.PowerBackendRedfish:
+		return newRedfishBackend(backend), nil
+	case pkg.PowerBackendIPMI:
+		return &ipmiBackend{cfg: backend}, nil
+	default:
+		return &wolBackend{substrateGateway: p.substrateGateway, node: node}, nil
+	}
+}
+
+func (p *PowerServer) nodeBackend(ctx context.Context, id uint32) (Backend, error) {
+	node, err := p.substrateGateway.GetNode(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get node '%d'", id)
+	}
+
+	return p.backend(&node)
+}
+
+// PowerOn powers on a node in the same farm
+func (p *PowerServer) PowerOn(node uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*backendTimeout)
+	defer cancel()
+
+	backend, err := p.nodeBackend(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Uint32("node", node).Msg("powering on node on request")
+	return backend.PowerOn(ctx)
+}
+
+// PowerCycle power cycles a node in the same farm
+func (p *PowerServer) PowerCycle(node uint32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*backendTimeout)
+	defer cancel()
+
+	backend, err := p.nodeBackend(ctx, node)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Uint32("node", node).Msg("power cycling node on request")
+	return backend.PowerCycle(ctx)
+}
+
+// PowerState queries the power state of a node in the same farm
+func (p *PowerServer) PowerState(node uint32) (pkg.PowerStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*backendTimeout)
+	defer cancel()
+
+	backend, err := p.nodeBackend(ctx, node)
+	if err != nil {
+		return pkg.PowerStatusUnknown, err
+	}
+
+	return backend.State(ctx)
+}
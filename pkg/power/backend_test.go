@@ -0,0 +1,171 @@
+package power
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestParseIPMIStatus(t *testing.T) {
+	require.Equal(t, pkg.PowerStatusOn, parseIPMIStatus("Chassis Power is on\n"))
+	require.Equal(t, pkg.PowerStatusOff, parseIPMIStatus("Chassis Power is off\n"))
+	require.Equal(t, pkg.PowerStatusUnknown, parseIPMIStatus("Error: Unable to establish IPMI v2 / RMCP+ session"))
+}
+
+func TestRedfishBackend(t *testing.T) {
+	require := require.New(t)
+
+	var reset string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/redfish/v1/Systems":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Systems/1"}]}`))
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"PowerState": "Off"}`))
+		case "/redfish/v1/Systems/1/Actions/ComputerSystem.Reset":
+			var input struct {
+				ResetType string
+			}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reset = input.ResetType
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	backend := newRedfishBackend(pkg.PowerBackendConfig{
+		Type:     pkg.PowerBackendRedfish,
+		Address:  server.URL,
+		Username: "admin",
+		Password: "secret",
+	})
+
+	state, err := backend.State(context.Background())
+	require.NoError(err)
+	require.Equal(pkg.PowerStatusOff, state)
+
+	require.NoError(backend.PowerOn(context.Background()))
+	require.Equal("On", reset)
+
+	require.NoError(backend.PowerCycle(context.Background()))
+	require.Equal("PowerCycle", reset)
+
+	backend.cfg.Password = "wrong"
+	_, err = backend.State(context.Background())
+	require.Error(err)
+}
+
+func TestValidateBackend(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendWoL}))
+	require.NoError(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendRedfish, Address: "https://10.0.0.1", Username: "admin"}))
+	require.NoError(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Address: "10.0.0.1:623", Username: "admin"}))
+	require.Error(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendRedfish, Address: "10.0.0.1", Username: "admin"}))
+	require.Error(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Username: "admin"}))
+	require.Error(validateBackend(pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Address: "10.0.0.1"}))
+	require.Error(validateBackend(pkg.PowerBackendConfig{Type: "unknown"}))
+}
+
+func TestConfigSetKeepsPassword(t *testing.T) {
+	require := require.New(t)
+	p := &PowerServer{root: t.TempDir()}
+
+	backend := pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Address: "10.0.0.1", Username: "admin", Password: "secret"}
+	require.NoError(p.ConfigSet(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: backend}}))
+
+	cfg, err := p.ConfigGet()
+	require.NoError(err)
+	require.Empty(cfg.Nodes[1].Password)
+	require.NotZero(cfg.Updated)
+
+	// setting the config as returned by ConfigGet keeps the password
+	cfg.Nodes[1] = pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Address: "10.0.0.2", Username: "admin"}
+	require.NoError(p.ConfigSet(cfg))
+
+	stored, err := p.ConfigExport()
+	require.NoError(err)
+	require.Equal("secret", stored.Nodes[1].Password)
+	require.Equal("10.0.0.2", stored.Nodes[1].Address)
+	require.Greater(stored.Updated, cfg.Updated)
+}
+
+func TestConfigSync(t *testing.T) {
+	require := require.New(t)
+	p := &PowerServer{root: t.TempDir()}
+
+	backend := pkg.PowerBackendConfig{Type: pkg.PowerBackendIPMI, Address: "10.0.0.1", Username: "admin", Password: "secret"}
+	require.NoError(p.ConfigSync(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: backend}, Updated: 10}))
+
+	// older config is ignored
+	old := backend
+	old.Address = "10.0.0.2"
+	require.NoError(p.ConfigSync(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: old}, Updated: 9}))
+
+	stored, err := p.ConfigExport()
+	require.NoError(err)
+	require.Equal("10.0.0.1", stored.Nodes[1].Address)
+	require.EqualValues(10, stored.Updated)
+
+	require.Error(p.ConfigSync(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: {Type: "unknown"}}, Updated: 11}))
+
+	// configs from the future are rejected
+	future := uint64(time.Now().Add(time.Hour).Unix())
+	require.Error(p.ConfigSync(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: old}, Updated: future}))
+
+	// but a stored config from the future is replaced
+	require.NoError(p.saveConfig(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: backend}, Updated: math.MaxUint64}))
+	require.NoError(p.ConfigSync(pkg.FarmPowerConfig{Nodes: map[uint32]pkg.PowerBackendConfig{1: old}, Updated: 11}))
+
+	stored, err = p.ConfigExport()
+	require.NoError(err)
+	require.Equal("10.0.0.2", stored.Nodes[1].Address)
+}
+
+func TestMergeConfig(t *testing.T) {
+	require := require.New(t)
+
+	stored := pkg.FarmPowerConfig{
+		Nodes: map[uint32]pkg.PowerBackendConfig{
+			1: {Password: "one"},
+			2: {Password: "two"},
+		},
+		Updated: 100,
+	}
+
+	cfg := mergeConfig(stored, pkg.FarmPowerConfig{
+		Nodes: map[uint32]pkg.PowerBackendConfig{
+			1: {},
+			2: {Password: "new"},
+			3: {},
+		},
+	}, 50)
+
+	require.Equal("one", cfg.Nodes[1].Password)
+	require.Equal("new", cfg.Nodes[2].Password)
+	require.Empty(cfg.Nodes[3].Password)
+	require.EqualValues(101, cfg.Updated)
+
+	// a stored updated time too far in the future is not followed
+	stored.Updated = math.MaxUint64
+	cfg = mergeConfig(stored, pkg.FarmPowerConfig{}, 50)
+	require.EqualValues(50, cfg.Updated)
+}
@@ -3,13 +3,13 @@ package power
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/events"
 	"github.com/threefoldtech/test/pkg/network/bridge"
@@ -18,8 +18,11 @@ import (
 )
 
 type PowerServer struct {
+	cl               zbus.Client
 	consumer         *events.RedisConsumer
 	substrateGateway *stubs.SubstrateGatewayStub
+	// root is where the shutdown report is kept
+	root string
 
 	// enabled means the node can power off!
 	enabled bool
@@ -27,9 +30,15 @@ type PowerServer struct {
 	node    uint32
 	twin    uint32
 	ut      *Uptime
+
+	// deferred is set if a shutdown was requested but
+	// deferred because of running workloads
+	deferred bool
 }
 
 func NewPowerServer(
+	cl zbus.Client,
+	root string,
 	substrateGateway *stubs.SubstrateGatewayStub,
 	consumer *events.RedisConsumer,
 	enabled bool,
@@ -39,6 +48,8 @@ func NewPowerServer(
 	ut *Uptime) (*PowerServer, error) {
 
 	return &PowerServer{
+		cl:               cl,
+		root:             root,
 		substrateGateway: substrateGateway,
 		consumer:         consumer,
 		enabled:          enabled,
@@ -109,6 +120,7 @@ func (p *PowerServer) syncSelf() error {
 	// if target is down, we make sure state is down, then shutdown
 
 	if power.Target.IsUp {
+		p.cancelShutdown()
 		if err := p.setNodePowerState(true); err != nil {
 			return errors.Wrap(err, "failed to set state to up")
 		}
@@ -118,13 +130,7 @@ func (p *PowerServer) syncSelf() error {
 
 	// now the target must be down.
 	// we need to shutdown
-
-	if err := p.setNodePowerState(false); err != nil {
-		return errors.Wrap(err, "failed to set state to down")
-	}
-
-	// otherwise node need to get back to sleep.
-	if err := p.shutdown(); err != nil {
+	if err := p.powerDown(context.Background()); err != nil {
 		return errors.Wrap(err, "failed to issue shutdown")
 	}
 
@@ -134,25 +140,15 @@ func (p *PowerServer) syncSelf() error {
 func (p *PowerServer) powerUp(node *substrate.Node, reason string) error {
 	log.Info().Uint32("node", uint32(node.ID)).Str("reason", reason).Msg("powering on node")
 
-	mac := ""
-	for _, inf := range node.Interfaces {
-		if inf.Name == "test" {
-			mac = inf.Mac
-			break
-		}
-	}
-	if mac == "" {
-		return fmt.Errorf("can't find mac address of node '%d'", node.ID)
+	backend, err := p.backend(node)
+	if err != nil {
+		return err
 	}
 
-	for i := 0; i < 10; i++ {
-		if err := exec.Command("ether-wake", "-i", "test", mac).Run(); err != nil {
-			log.Error().Err(err).Msg("failed to send WOL")
-		}
-		<-time.After(500 * time.Millisecond)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*backendTimeout)
+	defer cancel()
 
-	return nil
+	return backend.PowerOn(ctx)
 }
 
 func (p *PowerServer) shutdown() error {
@@ -195,15 +191,16 @@ func (p *PowerServer) event(event *pkg.PowerTargetChangeEvent) error {
 
 	if event.NodeID == p.node && event.Target.IsDown {
 		// we need to shutdown!
-		if err := p.setNodePowerState(false); err != nil {
-			return errors.Wrap(err, "failed to set node power state to down")
-		}
-
-		return p.shutdown()
+		return p.powerDown(context.Background())
 	} else if event.Target.IsDown {
 		return nil
 	}
 
+	if event.NodeID == p.node {
+		// our target is up again
+		p.cancelShutdown()
+	}
+
 	if event.Target.IsUp {
 		log.Info().Uint32("target", event.NodeID).Msg("received an event to power up")
 		return p.powerUp(&node, "target is up")
@@ -264,16 +261,33 @@ func (p *PowerServer) recv(ctx context.Context) error {
 		return errors.Wrap(err, "failed to connect to zbus events")
 	}
 
-	for event := range stream {
-		if err := p.event(&event); err != nil {
-			return errors.Wrap(err, "failed to process power event")
+	retry := time.NewTicker(shutdownRetry)
+	defer retry.Stop()
+
+	for {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				// if we reach here it means stream was ended. this can only happen
+				// if and only if the steam was over and that can only be via a ctx
+				// cancel.
+				return nil
+			}
+
+			if err := p.event(&event); err != nil {
+				return errors.Wrap(err, "failed to process power event")
+			}
+		case <-retry.C:
+			if !p.deferred {
+				continue
+			}
+
+			// check again if the node can shutdown now
+			if err := p.syncSelf(); err != nil {
+				return errors.Wrap(err, "failed to synchronize power status")
+			}
 		}
 	}
-
-	// if we reach here it means stream was ended. this can only happen
-	// if and only if the steam was over and that can only be via a ctx
-	// cancel.
-	return nil
 }
 
 // start processing time events.
@@ -0,0 +1,229 @@
+package power
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	shutdownReportFile = "shutdown.json"
+
+	// shutdownRetry is how often a deferred shutdown is checked again
+	shutdownRetry = 10 * time.Minute
+
+	// vmStopTimeout is the max time to stop a single virtual machine
+	vmStopTimeout = 30 * time.Second
+)
+
+var _ pkg.PowerManager = (*PowerServer)(nil)
+
+// restorableTypes are workload types that don't run anything on the node. They
+// are restored as is by the provision engine on the next boot, so they never
+// block a shutdown.
+var restorableTypes = map[gridtypes.WorkloadType]struct{}{
+	test.NetworkType:    {},
+	test.ZMountType:     {},
+	test.VolumeType:     {},
+	test.ZLogsType:      {},
+	test.PublicIPType:   {},
+	test.PublicIPv4Type: {},
+}
+
+// shutdownBlockers returns the workloads that prevent the node from shutting down
+func shutdownBlockers(workloads []pkg.ActiveWorkload) []pkg.ActiveWorkload {
+	var blockers []pkg.ActiveWorkload
+	for _, wl := range workloads {
+		if wl.Pausable {
+			continue
+		}
+
+		if _, ok := restorableTypes[wl.Type]; ok {
+			continue
+		}
+
+		blockers = append(blockers, wl)
+	}
+
+	return blockers
+}
+
+func (p *PowerServer) reportFile() string {
+	return filepath.Join(p.root, shutdownReportFile)
+}
+
+// LastShutdown returns the report of the last shutdown request
+func (p *PowerServer) LastShutdown() (pkg.ShutdownReport, error) {
+	return loadReport(p.reportFile())
+}
+
+func loadReport(path string) (pkg.ShutdownReport, error) {
+	var report pkg.ShutdownReport
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return report, nil
+	} else if err != nil {
+		return report, errors.Wrap(err, "failed to read shutdown report")
+	}
+
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, errors.Wrap(err, "failed to decode shutdown report")
+	}
+
+	return report, nil
+}
+
+func saveReport(path string, report *pkg.ShutdownReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode shutdown report")
+	}
+
+	tmp := fmt.Sprintf("%s.tmp", path)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write shutdown report")
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// powerDown is called when the node power target is down. The node is only
+// shutdown if none of its workloads prevent it, otherwise the shutdown is
+// deferred and checked again every shutdownRetry.
+func (p *PowerServer) powerDown(ctx context.Context) error {
+	if !p.enabled {
+		log.Info().Msg("ignoring shutdown because power-management is not enabled")
+		// this makes sure the state is set to up
+		return p.setNodePowerState(false)
+	}
+
+	now := uint64(time.Now().Unix())
+	report, err := p.LastShutdown()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load last shutdown report")
+	}
+
+	if report.State != pkg.ShutdownStateDeferred {
+		report = pkg.ShutdownReport{Requested: now}
+	}
+
+	report.Updated = now
+	report.Errors = nil
+
+	if reason := p.checkShutdown(ctx, &report); len(reason) != 0 {
+		log.Warn().Str("reason", reason).Msg("node shutdown is deferred")
+
+		p.deferred = true
+		report.State = pkg.ShutdownStateDeferred
+		report.Reason = reason
+		if err := saveReport(p.reportFile(), &report); err != nil {
+			log.Error().Err(err).Msg("failed to save shutdown report")
+		}
+
+		// the node keeps running, so its state must stay up
+		return p.setNodePowerState(true)
+	}
+
+	p.deferred = false
+	if err := p.setNodePowerState(false); err != nil {
+		return errors.Wrap(err, "failed to set node power state to down")
+	}
+
+	p.stopVMs(ctx, &report)
+
+	// flush all file systems before we go down
+	syscall.Sync()
+
+	report.State = pkg.ShutdownStateDone
+	report.Reason = ""
+	report.Updated = uint64(time.Now().Unix())
+	if err := saveReport(p.reportFile(), &report); err != nil {
+		log.Error().Err(err).Msg("failed to save shutdown report")
+	}
+
+	return p.shutdown()
+}
+
+// cancelShutdown marks a deferred shutdown as cancelled, this happens if the
+// power target goes up again before the node could shutdown
+func (p *PowerServer) cancelShutdown() {
+	if !p.deferred {
+		return
+	}
+
+	p.deferred = false
+	report, err := p.LastShutdown()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load last shutdown report")
+		return
+	}
+
+	log.Info().Msg("deferred shutdown is cancelled")
+	report.State = pkg.ShutdownStateCancelled
+	report.Updated = uint64(time.Now().Unix())
+	if err := saveReport(p.reportFile(), &report); err != nil {
+		log.Error().Err(err).Msg("failed to save shutdown report")
+	}
+}
+
+// checkShutdown checks the node rent contract and active workloads and
+// returns why the node can't shutdown. An empty reason means the node
+// can shutdown.
+func (p *PowerServer) checkShutdown(ctx context.Context, report *pkg.ShutdownReport) string {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	activity, err := stubs.NewProvisionStub(p.cl).Activity(ctx)
+	if err != nil {
+		return fmt.Sprintf("failed to check active workloads: %s", err)
+	}
+
+	report.RentContract = activity.RentContract
+	report.Blockers = shutdownBlockers(activity.Workloads)
+
+	if activity.RentContract != 0 {
+		return fmt.Sprintf("node is rented by contract '%d'", activity.RentContract)
+	}
+
+	if len(report.Blockers) != 0 {
+		return fmt.Sprintf("node has %d workloads that can't be paused", len(report.Blockers))
+	}
+
+	return ""
+}
+
+// stopVMs gracefully stops all running virtual machines. The machines are
+// started again by the provision engine on the next boot.
+func (p *PowerServer) stopVMs(ctx context.Context, report *pkg.ShutdownReport) {
+	vmd := stubs.NewVMModuleStub(p.cl)
+	machines, err := vmd.List(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("failed to list virtual machines: %s", err))
+		return
+	}
+
+	for _, name := range machines {
+		log.Info().Str("vm", name).Msg("stopping virtual machine before shutdown")
+		stopCtx, cancel := context.WithTimeout(ctx, vmStopTimeout)
+		err := vmd.Delete(stopCtx, name)
+		cancel()
+
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("failed to stop virtual machine '%s': %s", name, err))
+			continue
+		}
+
+		report.Stopped = append(report.Stopped, name)
+	}
+}
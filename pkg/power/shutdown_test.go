@@ -0,0 +1,49 @@
+package power
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+)
+
+func TestShutdownBlockers(t *testing.T) {
+	workloads := []pkg.ActiveWorkload{
+		{Name: "net", Type: test.NetworkType},
+		{Name: "vm", Type: test.ZMachineType, Pausable: true},
+		{Name: "disk", Type: test.ZMountType},
+		{Name: "db", Type: test.ZDBType, Pausable: true},
+		{Name: "gw", Type: test.GatewayNameProxyType},
+		{Name: "fs", Type: test.QuantumSafeFSType},
+	}
+
+	blockers := shutdownBlockers(workloads)
+	require.Len(t, blockers, 2)
+	require.EqualValues(t, "gw", blockers[0].Name)
+	require.EqualValues(t, "fs", blockers[1].Name)
+
+	require.Empty(t, shutdownBlockers(workloads[:4]))
+}
+
+func TestShutdownReport(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), shutdownReportFile)
+
+	report, err := loadReport(path)
+	require.NoError(err)
+	require.Empty(report.State)
+
+	expected := pkg.ShutdownReport{
+		State:     pkg.ShutdownStateDone,
+		Requested: 10,
+		Updated:   20,
+		Stopped:   []string{"vm"},
+	}
+	require.NoError(saveReport(path, &expected))
+
+	report, err = loadReport(path)
+	require.NoError(err)
+	require.Equal(expected, report)
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/shirou/gopsutil/host"
-	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/test/pkg/app"
 	"github.com/threefoldtech/test/pkg/stubs"
 	"github.com/threefoldtech/test/pkg/utils"
@@ -25,14 +24,12 @@ type Uptime struct {
 	// Mark is set to done after the first uptime is sent
 	Mark utils.Mark
 
-	id               substrate.Identity
 	substrateGateway *stubs.SubstrateGatewayStub
 	m                sync.Mutex
 }
 
-func NewUptime(substrateGateway *stubs.SubstrateGatewayStub, id substrate.Identity) (*Uptime, error) {
+func NewUptime(substrateGateway *stubs.SubstrateGatewayStub) (*Uptime, error) {
 	return &Uptime{
-		id:               id,
 		substrateGateway: substrateGateway,
 		Mark:             utils.NewMark(),
 	}, nil
@@ -98,8 +98,12 @@ func (p *Primitives) containerProvisionImpl(ctx context.Context, wl *gridtypes.W
 		logs = append(logs, logger.Logs{
 			Type: log.Type,
 			Data: logger.LogsRedis{
-				Stdout: log.Data.Stdout,
-				Stderr: log.Data.Stderr,
+				Stdout:   log.Data.Stdout,
+				Stderr:   log.Data.Stderr,
+				Format:   log.Data.Format,
+				CA:       log.Data.CA,
+				Workload: wl.ID.String(),
+				Twin:     deployment.TwinID,
 			},
 		})
 	}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/gridtypes"
 	"github.com/threefoldtech/test/pkg/gridtypes/test"
 	"github.com/threefoldtech/test/pkg/provision"
@@ -18,6 +19,26 @@ var (
 	_ provision.Updater = (*Manager)(nil)
 )
 
+// Result is the qsfs workload result. It extends the qsfs result with the
+// health of the qsfs backends so the owner can tell when the qsfs is degraded
+// and the limits hit by the qsfs container
+type Result struct {
+	test.QuatumSafeFSResult
+	Health    *pkg.QSFSHealth      `json:"health,omitempty"`
+	Resources *pkg.ContainerLimits `json:"resources,omitempty"`
+}
+
+// NewResult builds the workload result from the qsfs info and health. The
+// health is not checked while provisioning since the backends can take long
+// to answer, it's set later by the provisiond qsfs watcher
+func NewResult(info pkg.QSFSInfo, health *pkg.QSFSHealth) Result {
+	var result Result
+	result.Path = info.Path
+	result.MetricsEndpoint = info.MetricsEndpoint
+	result.Health = health
+	return result
+}
+
 type Manager struct {
 	zbus zbus.Client
 }
@@ -27,7 +48,6 @@ func NewManager(zbus zbus.Client) *Manager {
 }
 
 func (p *Manager) Provision(ctx context.Context, wl *gridtypes.WorkloadWithID) (interface{}, error) {
-	var result test.QuatumSafeFSResult
 	var proxy test.QuantumSafeFS
 	if err := json.Unmarshal(wl.Data, &proxy); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal qsfs data from reservation: %w", err)
@@ -37,9 +57,7 @@ func (p *Manager) Provision(ctx context.Context, wl *gridtypes.WorkloadWithID) (
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create qsfs mount")
 	}
-	result.Path = info.Path
-	result.MetricsEndpoint = info.MetricsEndpoint
-	return result, nil
+	return NewResult(info, nil), nil
 }
 
 func (p *Manager) Deprovision(ctx context.Context, wl *gridtypes.WorkloadWithID) error {
@@ -52,7 +70,6 @@ func (p *Manager) Deprovision(ctx context.Context, wl *gridtypes.WorkloadWithID)
 }
 
 func (p *Manager) Update(ctx context.Context, wl *gridtypes.WorkloadWithID) (interface{}, error) {
-	var result test.QuatumSafeFSResult
 	var proxy test.QuantumSafeFS
 	if err := json.Unmarshal(wl.Data, &proxy); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal qsfs data from reservation: %w", err)
@@ -62,7 +79,5 @@ func (p *Manager) Update(ctx context.Context, wl *gridtypes.WorkloadWithID) (int
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to update qsfs mount")
 	}
-	result.Path = info.Path
-	result.MetricsEndpoint = info.MetricsEndpoint
-	return result, nil
+	return NewResult(info, nil), nil
 }
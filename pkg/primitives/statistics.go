@@ -47,6 +47,9 @@ type Statistics struct {
 	reserved Reserved
 	storage  provision.Storage
 	mem      gridtypes.Unit
+	// totalVFs returns the number of virtual functions of each sriov
+	// physical function
+	totalVFs func() (map[string]uint32, error)
 }
 
 // NewStatistics creates a new statistics provisioner interceptor.
@@ -69,6 +72,7 @@ func NewStatistics(total gridtypes.Capacity, storage provision.Storage, reserved
 		reserved: reserved,
 		storage:  storage,
 		mem:      gridtypes.Unit(vm.Total),
+		totalVFs: sriovTotalVFs,
 	}
 }
 
@@ -155,10 +159,99 @@ func (s *Statistics) hasEnoughCapacity(wl *gridtypes.WorkloadWithID) (gridtypes.
 		return used, fmt.Errorf("cannot fulfil required memory size %d bytes out of usable %d bytes", required.MRU, usable)
 	}
 
+	if err := s.hasEnoughVFs(wl); err != nil {
+		return used, err
+	}
+
 	// check other resources as well?
 	return used, nil
 }
 
+// usedVFs returns the number of sriov virtual functions reserved on each
+// physical function by active machines
+func (s *Statistics) usedVFs(exclude ...provision.Exclude) (map[string]uint32, error) {
+	active, err := s.storage.Capacity(exclude...)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]uint32)
+	for _, dl := range active.Deployments {
+		for _, wl := range dl.Workloads {
+			if wl.Type != test.ZMachineType {
+				continue
+			}
+			var vm test.ZMachine
+			if err := json.Unmarshal(wl.Data, &vm); err != nil {
+				return nil, errors.Wrapf(err, "invalid workload data (%d.%s)", dl.ContractID, wl.Name)
+			}
+
+			for _, vf := range vm.SRIOV {
+				used[vf.PF]++
+			}
+		}
+	}
+
+	return used, nil
+}
+
+// hasEnoughVFs makes sure the virtual functions requested by a machine
+// are available on the requested physical functions
+func (s *Statistics) hasEnoughVFs(wl *gridtypes.WorkloadWithID) error {
+	if wl.Type != test.ZMachineType {
+		return nil
+	}
+
+	var vm test.ZMachine
+	if err := json.Unmarshal(wl.Data, &vm); err != nil {
+		return errors.Wrap(err, "failed to decode machine data")
+	}
+
+	if len(vm.SRIOV) == 0 {
+		return nil
+	}
+
+	used, err := s.usedVFs(func(dl_ *gridtypes.Deployment, wl_ *gridtypes.Workload) bool {
+		id, _ := gridtypes.NewWorkloadID(dl_.TwinID, dl_.ContractID, wl_.Name)
+		return id == wl.ID
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to count used virtual functions")
+	}
+
+	total, err := s.totalVFs()
+	if err != nil {
+		return err
+	}
+
+	for _, vf := range vm.SRIOV {
+		used[vf.PF]++
+		if used[vf.PF] > total[vf.PF] {
+			return fmt.Errorf("cannot fulfil required virtual functions on '%s' out of %d", vf.PF, total[vf.PF])
+		}
+	}
+
+	return nil
+}
+
+// sriovTotalVFs returns the number of virtual functions of each sriov
+// physical function of the node
+func sriovTotalVFs() (map[string]uint32, error) {
+	pfs, err := capacity.ListPCI(capacity.SRIOV)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sriov devices")
+	}
+
+	total := make(map[string]uint32)
+	for _, pf := range pfs {
+		if total[pf.ShortID()], err = pf.TotalVFs(); err != nil {
+			return nil, err
+		}
+	}
+
+	return total, nil
+}
+
 // Initialize implements provisioner interface
 func (s *Statistics) Initialize(ctx context.Context) error {
 	return s.inner.Initialize(ctx)
@@ -190,6 +283,11 @@ func (s *Statistics) CanUpdate(ctx context.Context, typ gridtypes.WorkloadType)
 	return s.inner.CanUpdate(ctx, typ)
 }
 
+// CanPause implements the provisioner interface
+func (s *Statistics) CanPause(ctx context.Context, typ gridtypes.WorkloadType) bool {
+	return s.inner.CanPause(ctx, typ)
+}
+
 func (s *Statistics) Pause(ctx context.Context, wl *gridtypes.WorkloadWithID) (gridtypes.Result, error) {
 	return s.inner.Pause(ctx, wl)
 }
@@ -335,6 +433,47 @@ func (s *statsStream) ListGPUs() ([]pkg.GPUInfo, error) {
 	return list, nil
 }
 
+func (s *statsStream) ListSRIOV() ([]pkg.SRIOVInfo, error) {
+	devices, err := capacity.ListPCI(capacity.SRIOV)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sriov devices")
+	}
+
+	used, err := s.stats.usedVFs()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list used virtual functions")
+	}
+
+	list := []pkg.SRIOVInfo{}
+	for _, pciDevice := range devices {
+		id := pciDevice.ShortID()
+		info := pkg.SRIOVInfo{
+			ID:     id,
+			Vendor: "unknown",
+			Device: "unknown",
+			Used:   used[id],
+		}
+
+		if info.Interface, err = pciDevice.Interface(); err != nil {
+			return nil, err
+		}
+
+		if info.Total, err = pciDevice.TotalVFs(); err != nil {
+			return nil, err
+		}
+
+		vendor, device, ok := pciDevice.GetDevice()
+		if ok {
+			info.Vendor = vendor.Name
+			info.Device = device.Name
+		}
+
+		list = append(list, info)
+	}
+
+	return list, nil
+}
+
 func (s *statsStream) openConnectionsCount() (int, error) {
 	cmd := exec.Command("/bin/sh", "-c", "ss -tnH state established | wc -l")
 	out, err := cmd.Output()
@@ -0,0 +1,124 @@
+package primitives
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/provision"
+)
+
+type testStorage struct {
+	provision.Storage
+	deployments []gridtypes.Deployment
+}
+
+func (s *testStorage) Capacity(exclude ...provision.Exclude) (provision.StorageCapacity, error) {
+	var active []gridtypes.Deployment
+	for _, dl := range s.deployments {
+		dl := dl
+		var workloads []gridtypes.Workload
+	next:
+		for _, wl := range dl.Workloads {
+			wl := wl
+			for _, ex := range exclude {
+				if ex(&dl, &wl) {
+					continue next
+				}
+			}
+			workloads = append(workloads, wl)
+		}
+		dl.Workloads = workloads
+		active = append(active, dl)
+	}
+
+	return provision.StorageCapacity{Deployments: active}, nil
+}
+
+func machine(t *testing.T, name gridtypes.Name, pfs ...string) gridtypes.Workload {
+	var vm test.ZMachine
+	for _, pf := range pfs {
+		vm.SRIOV = append(vm.SRIOV, test.MachineSRIOV{PF: pf})
+	}
+
+	data, err := json.Marshal(vm)
+	require.NoError(t, err)
+
+	return gridtypes.Workload{
+		Name: name,
+		Type: test.ZMachineType,
+		Data: data,
+	}
+}
+
+func testStatistics(t *testing.T) *Statistics {
+	return &Statistics{
+		storage: &testStorage{
+			deployments: []gridtypes.Deployment{
+				{
+					TwinID:     1,
+					ContractID: 1,
+					Workloads: []gridtypes.Workload{
+						machine(t, "vm1", "pf1", "pf2"),
+						machine(t, "vm2", "pf1"),
+						{Name: "disk", Type: test.ZMountType, Data: json.RawMessage(`{}`)},
+					},
+				},
+				{
+					TwinID:     2,
+					ContractID: 2,
+					Workloads: []gridtypes.Workload{
+						machine(t, "vm"),
+					},
+				},
+			},
+		},
+		totalVFs: func() (map[string]uint32, error) {
+			return map[string]uint32{"pf1": 3, "pf2": 1}, nil
+		},
+	}
+}
+
+func TestUsedVFs(t *testing.T) {
+	require := require.New(t)
+	stats := testStatistics(t)
+
+	used, err := stats.usedVFs()
+	require.NoError(err)
+	require.Equal(map[string]uint32{"pf1": 2, "pf2": 1}, used)
+
+	used, err = stats.usedVFs(func(dl *gridtypes.Deployment, wl *gridtypes.Workload) bool {
+		return wl.Name == "vm1"
+	})
+	require.NoError(err)
+	require.Equal(map[string]uint32{"pf1": 1}, used)
+}
+
+func TestHasEnoughVFs(t *testing.T) {
+	require := require.New(t)
+	stats := testStatistics(t)
+
+	request := func(twin uint32, contract uint64, wl gridtypes.Workload) *gridtypes.WorkloadWithID {
+		id, err := gridtypes.NewWorkloadID(twin, contract, wl.Name)
+		require.NoError(err)
+		return &gridtypes.WorkloadWithID{Workload: &wl, ID: id}
+	}
+
+	// one vf left on pf1
+	require.NoError(stats.hasEnoughVFs(request(2, 2, machine(t, "new", "pf1"))))
+	require.Error(stats.hasEnoughVFs(request(2, 2, machine(t, "new", "pf1", "pf1"))))
+	// pf2 is fully used
+	require.Error(stats.hasEnoughVFs(request(2, 2, machine(t, "new", "pf2"))))
+	// unknown physical function
+	require.Error(stats.hasEnoughVFs(request(2, 2, machine(t, "new", "pf3"))))
+
+	// the vfs of the workload itself are not counted on update
+	require.NoError(stats.hasEnoughVFs(request(1, 1, machine(t, "vm1", "pf1", "pf1", "pf2"))))
+
+	// machines without vfs and other workloads are always accepted
+	require.NoError(stats.hasEnoughVFs(request(2, 2, machine(t, "new"))))
+	disk := gridtypes.Workload{Name: "disk", Type: test.ZMountType, Data: json.RawMessage(`{}`)}
+	require.NoError(stats.hasEnoughVFs(request(2, 2, disk)))
+}
@@ -90,39 +90,49 @@ func (m *Manager) initGPUs() error {
 		}
 
 		for _, pci := range devices {
-			device := filepath.Join(sysDeviceBase, pci.Slot)
-			driver := filepath.Join(device, "driver")
-			ln, err := os.Readlink(driver)
-			if err != nil && !os.IsNotExist(err) {
-				return errors.Wrap(err, "failed to check device driver")
+			if err := bindVfio(pci); err != nil {
+				return err
 			}
+		}
+	}
 
-			driverName := filepath.Base(ln)
-			//note: Base return `.` if path is empty string
-			if driverName == vfioPCIModue {
-				// correct driver is bind to the device
-				continue
-			} else if driverName != "." {
-				// another driver is bind to this device!
-				// this should not happen but we need to be sure
-				// let's unbind
-
-				if err := os.WriteFile(filepath.Join(driver, "unbind"), []byte(pci.Slot), 0600); err != nil {
-					return errors.Wrapf(err, "failed to unbind gpu '%s' from driver '%s'", pci.ShortID(), driverName)
-				}
-			}
+	return nil
+}
 
-			// we then need to do an override
-			if err := os.WriteFile(filepath.Join(device, "driver_override"), []byte(vfioPCIModue), 0644); err != nil {
-				return errors.Wrapf(err, "failed to override the device '%s' driver", pci.Slot)
-			}
+// bindVfio makes sure the device is bound to the vfio-pci driver so it can
+// be passed through to a VM
+func bindVfio(pci capacity.PCI) error {
+	device := filepath.Join(sysDeviceBase, pci.Slot)
+	driver := filepath.Join(device, "driver")
+	ln, err := os.Readlink(driver)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to check device driver")
+	}
 
-			if err := os.WriteFile("/sys/bus/pci/drivers_probe", []byte(pci.Slot), 0200); err != nil {
-				return errors.Wrapf(err, "failed to bind device '%s' to vfio", pci.Slot)
-			}
+	driverName := filepath.Base(ln)
+	//note: Base return `.` if path is empty string
+	if driverName == vfioPCIModue {
+		// correct driver is bind to the device
+		return nil
+	} else if driverName != "." {
+		// another driver is bind to this device!
+		// this should not happen but we need to be sure
+		// let's unbind
+
+		if err := os.WriteFile(filepath.Join(driver, "unbind"), []byte(pci.Slot), 0600); err != nil {
+			return errors.Wrapf(err, "failed to unbind device '%s' from driver '%s'", pci.ShortID(), driverName)
 		}
 	}
 
+	// we then need to do an override
+	if err := os.WriteFile(filepath.Join(device, "driver_override"), []byte(vfioPCIModue), 0644); err != nil {
+		return errors.Wrapf(err, "failed to override the device '%s' driver", pci.Slot)
+	}
+
+	if err := os.WriteFile("/sys/bus/pci/drivers_probe", []byte(pci.Slot), 0200); err != nil {
+		return errors.Wrapf(err, "failed to bind device '%s' to vfio", pci.Slot)
+	}
+
 	return nil
 }
 
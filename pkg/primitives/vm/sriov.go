@@ -0,0 +1,166 @@
+package vm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg/capacity"
+	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/network/ifaceutil"
+	"github.com/vishvananda/netlink"
+)
+
+// sriovLock makes sure two machines never pick the same free virtual function
+var sriovLock sync.Mutex
+
+// initSRIOV loads the vfio modules if the node has SR-IOV capable devices.
+// Virtual functions are only created when a machine requests one
+func (m *Manager) initSRIOV() error {
+	pfs, err := capacity.ListPCI(capacity.SRIOV)
+	if err != nil {
+		return errors.Wrap(err, "failed to list sriov devices")
+	}
+
+	if len(pfs) == 0 {
+		return nil
+	}
+
+	return m.initGPUVfioModules()
+}
+
+// allocateVFs picks a free virtual function for each of the requested
+// physical functions, sets the mac and vlan of the virtual function then
+// binds it to vfio so it can be passed to the VM.
+func (m *Manager) allocateVFs(wl *gridtypes.WorkloadWithID, requests []test.MachineSRIOV) (vfs []test.MachineVF, err error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+
+	sriovLock.Lock()
+	defer sriovLock.Unlock()
+
+	defer func() {
+		if err != nil {
+			releaseVFs(vfs)
+		}
+	}()
+
+	all, err := capacity.ListPCI(capacity.SRIOV)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sriov devices")
+	}
+
+	pfs := make(map[string]capacity.PCI)
+	for _, pf := range all {
+		pfs[pf.ShortID()] = pf
+	}
+
+	for i, request := range requests {
+		pf, ok := pfs[request.PF]
+		if !ok {
+			return vfs, fmt.Errorf("unknown sriov physical function '%s'", request.PF)
+		}
+
+		vf, err := allocateVF(pf, request.VLAN, wl.ID.Unique(fmt.Sprintf("vf%d", i)))
+		if err != nil {
+			return vfs, errors.Wrapf(err, "failed to allocate virtual function on '%s'", request.PF)
+		}
+
+		vfs = append(vfs, vf)
+	}
+
+	return vfs, nil
+}
+
+func allocateVF(pf capacity.PCI, vlan uint16, name string) (test.MachineVF, error) {
+	if err := pf.EnableVFs(); err != nil {
+		return test.MachineVF{}, err
+	}
+
+	vfs, err := pf.VFs()
+	if err != nil {
+		return test.MachineVF{}, err
+	}
+
+	for _, vf := range vfs {
+		// a virtual function that is bound to vfio is used by another machine
+		if vf.Driver() == vfioPCIModue {
+			continue
+		}
+
+		inf, err := pf.Interface()
+		if err != nil {
+			return test.MachineVF{}, err
+		}
+
+		link, err := netlink.LinkByName(inf)
+		if err != nil {
+			return test.MachineVF{}, errors.Wrapf(err, "failed to get physical function interface '%s'", inf)
+		}
+
+		mac := ifaceutil.HardwareAddrFromInputBytes([]byte(name))
+		if err := netlink.LinkSetVfHardwareAddr(link, vf.Index, mac); err != nil {
+			return test.MachineVF{}, errors.Wrapf(err, "failed to set virtual function '%d' mac", vf.Index)
+		}
+
+		if err := netlink.LinkSetVfVlan(link, vf.Index, int(vlan)); err != nil {
+			return test.MachineVF{}, errors.Wrapf(err, "failed to set virtual function '%d' vlan", vf.Index)
+		}
+
+		// the machine can't spoof the mac address or change the vlan
+		// given to its virtual function
+		if err := netlink.LinkSetVfSpoofchk(link, vf.Index, true); err != nil {
+			return test.MachineVF{}, errors.Wrapf(err, "failed to enable virtual function '%d' spoof check", vf.Index)
+		}
+
+		if err := netlink.LinkSetVfTrust(link, vf.Index, false); err != nil {
+			return test.MachineVF{}, errors.Wrapf(err, "failed to disable virtual function '%d' trust", vf.Index)
+		}
+
+		if err := bindVfio(vf.PCI); err != nil {
+			return test.MachineVF{}, err
+		}
+
+		return test.MachineVF{
+			PF:    pf.ShortID(),
+			Slot:  vf.Slot,
+			Index: vf.Index,
+			MAC:   mac.String(),
+		}, nil
+	}
+
+	return test.MachineVF{}, fmt.Errorf("no free virtual functions")
+}
+
+// releaseVFs gives the virtual functions back to the host driver so they
+// can be picked by other machines
+func releaseVFs(vfs []test.MachineVF) {
+	for _, vf := range vfs {
+		if err := releaseVF(vf.Slot); err != nil {
+			log.Error().Err(err).Str("slot", vf.Slot).Msg("failed to release virtual function")
+		}
+	}
+}
+
+func releaseVF(slot string) error {
+	device := filepath.Join(sysDeviceBase, slot)
+	if err := os.WriteFile(filepath.Join(device, "driver", "unbind"), []byte(slot), 0600); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to unbind virtual function '%s'", slot)
+	}
+
+	// clear the override so the device driver is probed again
+	if err := os.WriteFile(filepath.Join(device, "driver_override"), []byte("\n"), 0644); err != nil {
+		return errors.Wrapf(err, "failed to clear virtual function '%s' driver override", slot)
+	}
+
+	if err := os.WriteFile("/sys/bus/pci/drivers_probe", []byte(slot), 0200); err != nil {
+		return errors.Wrapf(err, "failed to probe virtual function '%s' driver", slot)
+	}
+
+	return nil
+}
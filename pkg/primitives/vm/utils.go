@@ -246,6 +246,10 @@ func (p *Manager) newYggNetworkInterface(ctx context.Context, wl *gridtypes.Work
 }
 
 func (p *Manager) newPrivNetworkInterface(ctx context.Context, dl gridtypes.Deployment, wl *gridtypes.WorkloadWithID, inf test.MachineInterface) (pkg.VMIface, error) {
+	if inf.IP.To4() == nil {
+		return p.newPrivNetworkInterface6(ctx, dl, wl, inf)
+	}
+
 	network := stubs.NewNetworkerStub(p.zbus)
 	netID := test.NetworkID(dl.TwinID, inf.Network)
 
@@ -288,6 +292,11 @@ func (p *Manager) newPrivNetworkInterface(ctx context.Context, dl gridtypes.Depl
 		return pkg.VMIface{}, errors.Wrap(err, "could not convert private ipv4 to ipv6")
 	}
 
+	privNet6, err := network.GetNet6(ctx, netID)
+	if err != nil {
+		return pkg.VMIface{}, errors.Wrapf(err, "could not get network ipv6 range")
+	}
+
 	tapName := wl.ID.Unique(string(inf.Network))
 	iface, err := network.SetupPrivTap(ctx, netID, tapName)
 	if err != nil {
@@ -315,9 +324,73 @@ func (p *Manager) newPrivNetworkInterface(ctx context.Context, dl gridtypes.Depl
 		NetID:             netID,
 	}
 
+	if len(privNet6.IP) != 0 {
+		// dual stack network, other network resources are
+		// reachable through the NR ipv6 gateway
+		out.Routes = append(out.Routes, pkg.Route{Net: privNet6, Gateway: gw6})
+	}
+
 	return out, nil
 }
 
+// newPrivNetworkInterface6 creates the interface of a zmachine on an IPv6 only
+// private network, in that case the machine IP is an IPv6 of the NR /64
+func (p *Manager) newPrivNetworkInterface6(ctx context.Context, dl gridtypes.Deployment, wl *gridtypes.WorkloadWithID, inf test.MachineInterface) (pkg.VMIface, error) {
+	network := stubs.NewNetworkerStub(p.zbus)
+	netID := test.NetworkID(dl.TwinID, inf.Network)
+
+	subnet, err := network.GetSubnet6(ctx, netID)
+	if err != nil {
+		return pkg.VMIface{}, errors.Wrapf(err, "could not get network resource ipv6 subnet")
+	}
+
+	if len(subnet.IP) == 0 {
+		return pkg.VMIface{}, fmt.Errorf("network %s has no ipv6 subnet, an IPv4 must be supplied", inf.Network)
+	}
+
+	if !subnet.Contains(inf.IP) {
+		return pkg.VMIface{}, fmt.Errorf("IP %s is not part of local nr subnet %s", inf.IP.String(), subnet.String())
+	}
+
+	_, gw6, err := network.GetDefaultGwIP(ctx, netID)
+	if err != nil {
+		return pkg.VMIface{}, errors.Wrap(err, "could not get network resource default gateway")
+	}
+
+	// the first address of the subnet is reserved for the gateway
+	if inf.IP.Equal(gw6) || inf.IP.Equal(subnet.IP.Mask(subnet.Mask)) {
+		return pkg.VMIface{}, fmt.Errorf("ip %s is reserved", inf.IP.String())
+	}
+
+	privNet6, err := network.GetNet6(ctx, netID)
+	if err != nil {
+		return pkg.VMIface{}, errors.Wrapf(err, "could not get network ipv6 range")
+	}
+
+	tapName := wl.ID.Unique(string(inf.Network))
+	iface, err := network.SetupPrivTap(ctx, netID, tapName)
+	if err != nil {
+		return pkg.VMIface{}, errors.Wrap(err, "could not set up tap device")
+	}
+
+	mac := ifaceutil.HardwareAddrFromInputBytes([]byte(tapName))
+
+	return pkg.VMIface{
+		Tap: iface,
+		MAC: mac.String(),
+		IPs: []net.IPNet{
+			{IP: inf.IP, Mask: subnet.Mask},
+		},
+		Routes: []pkg.Route{
+			{Net: privNet6, Gateway: gw6},
+		},
+		IP6DefaultGateway: gw6,
+		PublicIPv4:        false,
+		PublicIPv6:        false,
+		NetID:             netID,
+	}, nil
+}
+
 func (p *Manager) newPubNetworkInterface(ctx context.Context, deployment gridtypes.Deployment, cfg ZMachine) (pkg.VMIface, error) {
 	network := stubs.NewNetworkerStub(p.zbus)
 	ipWl, err := deployment.Get(cfg.Network.PublicIP)
@@ -40,7 +40,11 @@ func NewManager(zbus zbus.Client) *Manager {
 }
 
 func (m *Manager) Initialize(ctx context.Context) error {
-	return m.initGPUs()
+	if err := m.initGPUs(); err != nil {
+		return err
+	}
+
+	return m.initSRIOV()
 }
 
 func (p *Manager) Provision(ctx context.Context, wl *gridtypes.WorkloadWithID) (interface{}, error) {
@@ -139,6 +143,11 @@ func (p *Manager) virtualMachineProvisionImpl(ctx context.Context, wl *gridtypes
 		return result, fmt.Errorf("usage of GPU is not allowed unless node is rented")
 	}
 
+	if len(config.SRIOV) != 0 && !provision.IsRentedNode(ctx) {
+		// same as GPU, virtual functions are only available on rented nodes
+		return result, fmt.Errorf("usage of SR-IOV is not allowed unless node is rented")
+	}
+
 	machine := pkg.VM{
 		Name:       wl.ID.String(),
 		CPU:        config.ComputeCapacity.CPU,
@@ -157,6 +166,22 @@ func (p *Manager) virtualMachineProvisionImpl(ctx context.Context, wl *gridtypes
 		machine.Devices = append(machine.Devices, device.Slot)
 	}
 
+	vfs, err := p.allocateVFs(wl, config.SRIOV)
+	if err != nil {
+		return result, errors.Wrap(err, "failed to prepare requested sriov virtual function(s)")
+	}
+
+	defer func() {
+		if err != nil {
+			releaseVFs(vfs)
+		}
+	}()
+
+	for _, vf := range vfs {
+		machine.Devices = append(machine.Devices, vf.Slot)
+	}
+	result.VFs = vfs
+
 	// the config is validated by the engine. we now only support only one
 	// private network
 	if len(config.Network.Interfaces) != 1 {
@@ -332,6 +357,15 @@ func (p *Manager) Deprovision(ctx context.Context, wl *gridtypes.WorkloadWithID)
 		}
 	}
 
+	if len(cfg.SRIOV) != 0 {
+		var result test.ZMachineResult
+		if err := wl.Result.Unmarshal(&result); err != nil {
+			log.Error().Err(err).Msg("failed to decode machine result, virtual functions are not released")
+		} else {
+			releaseVFs(result.VFs)
+		}
+	}
+
 	if err := flist.Unmount(ctx, wl.ID.String()); err != nil {
 		log.Error().Err(err).Msg("failed to unmount machine flist")
 	}
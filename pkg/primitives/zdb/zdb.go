@@ -41,6 +41,14 @@ type ZDB = test.ZDB
 
 type tZDBContainer pkg.Container
 
+// Result is the zdb workload result. It extends the zdb result with the
+// limits hit by the zdb container so the owner can tell when the namespace
+// is served by an under provisioned container
+type Result struct {
+	test.ZDBResult
+	Resources *pkg.ContainerLimits `json:"resources,omitempty"`
+}
+
 type safeError struct {
 	error
 }
@@ -550,9 +558,26 @@ func (p *Manager) zdbDecommissionImpl(ctx context.Context, wl *gridtypes.Workloa
 }
 
 func (p *Manager) findContainer(ctx context.Context, name string) (zdb.Client, error) {
+	_, cl, err := p.lookupContainer(ctx, name)
+	return cl, err
+}
+
+// ContainerOf returns the id of the zdb container that hosts the namespace
+// with the given name. os.ErrNotExist is returned if no container has it
+func (p *Manager) ContainerOf(ctx context.Context, name string) (pkg.ContainerID, error) {
+	id, cl, err := p.lookupContainer(ctx, name)
+	if err != nil {
+		return id, err
+	}
+
+	_ = cl.Close()
+	return id, nil
+}
+
+func (p *Manager) lookupContainer(ctx context.Context, name string) (pkg.ContainerID, zdb.Client, error) {
 	containers, err := p.zdbListContainers(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to list running zdbs")
+		return "", nil, errors.Wrap(err, "failed to list running zdbs")
 	}
 
 	for id := range containers {
@@ -563,13 +588,13 @@ func (p *Manager) findContainer(ctx context.Context, name string) (zdb.Client, e
 		}
 
 		if ok, _ := cl.Exist(name); ok {
-			return cl, nil
+			return id, cl, nil
 		}
 
 		_ = cl.Close()
 	}
 
-	return nil, os.ErrNotExist
+	return "", nil, os.ErrNotExist
 }
 
 func (p *Manager) Pause(ctx context.Context, wl *gridtypes.WorkloadWithID) error {
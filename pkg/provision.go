@@ -2,6 +2,7 @@ package pkg
 
 //go:generate zbusc -module provision -version 0.0.1 -name provision -package stubs github.com/threefoldtech/test/pkg+Provision stubs/provision_stub.go
 //go:generate zbusc -module provision -version 0.0.1 -name statistics -package stubs github.com/threefoldtech/test/pkg+Statistics stubs/statistics_stub.go
+//go:generate zbusc -module provision -version 0.0.1 -name reconciler -package stubs github.com/threefoldtech/test/pkg+Reconciler stubs/reconciler_stub.go
 
 import (
 	"context"
@@ -20,6 +21,80 @@ type Provision interface {
 	Changes(twin uint32, contractID uint64) ([]gridtypes.Workload, error)
 	ListPublicIPs() ([]string, error)
 	ListPrivateIPs(twin uint32, network gridtypes.Name) ([]string, error)
+	// Activity returns the node rent contract and all active workloads
+	Activity() (NodeActivity, error)
+}
+
+// ActiveWorkload is a workload that is currently deployed on the node
+type ActiveWorkload struct {
+	Twin     uint32                 `json:"twin"`
+	Contract uint64                 `json:"contract"`
+	Name     gridtypes.Name         `json:"name"`
+	Type     gridtypes.WorkloadType `json:"type"`
+	// Pausable is true if the workload type supports pause and resume
+	Pausable bool `json:"pausable"`
+}
+
+// NodeActivity is what is currently running on the node
+type NodeActivity struct {
+	// RentContract is the node rent contract, 0 if the node is not rented
+	RentContract uint64 `json:"rent_contract"`
+	// Workloads active workloads on the node
+	Workloads []ActiveWorkload `json:"workloads"`
+}
+
+// DriftKind is the kind of difference between a local deployment and
+// its contract on chain
+type DriftKind string
+
+const (
+	// DriftOrphaned the deployment contract does not exist (or is deleted) on chain
+	DriftOrphaned DriftKind = "orphaned"
+	// DriftLockState the deployment pause state does not match the contract grace period
+	DriftLockState DriftKind = "lock-state"
+	// DriftHash the deployment hash does not match the contract deployment hash
+	DriftHash DriftKind = "hash"
+	// DriftPublicIPs the number of public ips of the deployment does not match the contract
+	DriftPublicIPs DriftKind = "public-ips"
+	// DriftRentContract the node is rented but the deployment is not owned by the renter
+	DriftRentContract DriftKind = "rent-contract"
+)
+
+// Drift is a difference found between a local deployment and its contract
+type Drift struct {
+	Twin     uint32    `json:"twin"`
+	Contract uint64    `json:"contract"`
+	Kind     DriftKind `json:"kind"`
+	// Local is the node side value
+	Local string `json:"local"`
+	// Chain is the chain side value
+	Chain string `json:"chain"`
+	// Fixable is true if the drift can be fixed automatically
+	Fixable bool `json:"fixable"`
+	// Fixed is true if the drift was fixed by the reconciliation
+	Fixed bool   `json:"fixed"`
+	Error string `json:"error,omitempty"`
+}
+
+// DriftReport is the result of a reconciliation of the local deployments
+// against the chain
+type DriftReport struct {
+	// DryRun is true if no drift was fixed
+	DryRun   bool  `json:"dry_run"`
+	Started  int64 `json:"started"`
+	Finished int64 `json:"finished"`
+	// Deployments is the number of checked deployments
+	Deployments int     `json:"deployments"`
+	Drifts      []Drift `json:"drifts"`
+}
+
+// Reconciler compares local deployments with their contracts on chain
+type Reconciler interface {
+	// Reconcile checks all local deployments against the chain. Unless dryRun
+	// is set, fixable drifts are fixed
+	Reconcile(dryRun bool) (DriftReport, error)
+	// LastReport returns the report of the last reconciliation
+	LastReport() (DriftReport, error)
 }
 
 type Statistics interface {
@@ -29,6 +104,7 @@ type Statistics interface {
 	Workloads() (int, error)
 	GetCounters() (Counters, error)
 	ListGPUs() ([]GPUInfo, error)
+	ListSRIOV() ([]SRIOVInfo, error)
 }
 
 type Counters struct {
@@ -60,3 +136,14 @@ type GPUInfo struct {
 	Device   string `json:"device"`
 	Contract uint64 `json:"contract"`
 }
+
+// SRIOVInfo is an SR-IOV capable network card and the number of its
+// virtual functions reserved by machines
+type SRIOVInfo struct {
+	ID        string `json:"id"`
+	Interface string `json:"interface"`
+	Vendor    string `json:"vendor"`
+	Device    string `json:"device"`
+	Total     uint32 `json:"total"`
+	Used      uint32 `json:"used"`
+}
@@ -56,13 +56,18 @@ func ContainerToProvisionType(c workloads.Container) (Container, string, error)
 					Stderr: "",
 				},
 			}
+			continue
 		}
 
+		// explorer reservations have no logs format or ca, they are
+		// published raw with the system certificates
 		container.Logs[i] = logger.Logs{
 			Type: lg.Type,
 			Data: logger.LogsRedis{
-				Stdout: lg.Data.Stdout,
-				Stderr: lg.Data.Stderr,
+				Stdout:   lg.Data.Stdout,
+				Stderr:   lg.Data.Stderr,
+				Format:   logger.FormatRaw,
+				Workload: fmt.Sprint(c.WorkloadId),
 			},
 		}
 	}
@@ -1168,6 +1168,55 @@ func (n *NativeEngine) ListPrivateIPs(twin uint32, network gridtypes.Name) ([]st
 	return ips, nil
 }
 
+// Activity returns the node rent contract and all the active workloads
+// on the node
+func (n *NativeEngine) Activity() (pkg.NodeActivity, error) {
+	var activity pkg.NodeActivity
+	if n.substrateGateway != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		rent, subErr := n.substrateGateway.GetNodeRentContract(ctx, n.nodeID)
+		if subErr.IsError() && !subErr.IsCode(pkg.CodeNotFound) {
+			return activity, fmt.Errorf("failed to check node rent state")
+		}
+
+		if !subErr.IsError() {
+			activity.RentContract = rent
+		}
+	}
+
+	twins, err := n.storage.Twins()
+	if err != nil {
+		return activity, errors.Wrap(err, "failed to list twins")
+	}
+
+	for _, twin := range twins {
+		deployments, err := n.List(twin)
+		if err != nil {
+			return activity, errors.Wrap(err, "failed to list twin deployments")
+		}
+
+		for _, deployment := range deployments {
+			for _, wl := range deployment.Workloads {
+				if !wl.Result.State.IsOkay() {
+					continue
+				}
+
+				activity.Workloads = append(activity.Workloads, pkg.ActiveWorkload{
+					Twin:     twin,
+					Contract: deployment.ContractID,
+					Name:     wl.Name,
+					Type:     wl.Type,
+					Pausable: n.provisioner.CanPause(context.Background(), wl.Type),
+				})
+			}
+		}
+	}
+
+	return activity, nil
+}
+
 func isNotFoundError(err error) bool {
 	if errors.Is(err, ErrWorkloadNotExist) || errors.Is(err, ErrDeploymentNotExists) {
 		return true
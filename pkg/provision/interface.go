@@ -49,6 +49,8 @@ type Provisioner interface {
 	Update(ctx context.Context, wl *gridtypes.WorkloadWithID) (gridtypes.Result, error)
 	// CanUpdate checks if this workload can be updated on the fly
 	CanUpdate(ctx context.Context, typ gridtypes.WorkloadType) bool
+	// CanPause checks if this workload type supports pause and resume
+	CanPause(ctx context.Context, typ gridtypes.WorkloadType) bool
 }
 
 // Filter is filtering function for Purge method
@@ -133,6 +135,10 @@ type Storage interface {
 	Changes(twin uint32, deployment uint64) (changes []gridtypes.Workload, err error)
 	// Current gets last state of a workload by name
 	Current(twin uint32, deployment uint64, name gridtypes.Name) (gridtypes.Workload, error)
+	// UpdateResult changes the result of the last state of a workload, the
+	// state is read and updated atomically. If update returns ErrNoActionNeeded
+	// the workload is not changed
+	UpdateResult(twin uint32, deployment uint64, name gridtypes.Name, update func(wl *gridtypes.Workload) error) error
 	// Twins list twins in storage
 	Twins() ([]uint32, error)
 	// ByTwin return list of deployments for a twin
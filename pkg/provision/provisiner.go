@@ -226,6 +226,16 @@ func (p *mapProvisioner) CanUpdate(ctx context.Context, typ gridtypes.WorkloadTy
 	return ok
 }
 
+func (p *mapProvisioner) CanPause(ctx context.Context, typ gridtypes.WorkloadType) bool {
+	manager, ok := p.managers[typ]
+	if !ok {
+		return false
+	}
+
+	_, ok = manager.(Pauser)
+	return ok
+}
+
 func setState(result *gridtypes.Result, err error) {
 	result.Created = gridtypes.Now()
 	state := gridtypes.StateOk
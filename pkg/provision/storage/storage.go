@@ -561,70 +561,95 @@ func (b *BoltStorage) workloads(twin uint32, deployment uint64) ([]gridtypes.Wor
 	return result, err
 }
 
-func (b *BoltStorage) Current(twin uint32, deployment uint64, name gridtypes.Name) (gridtypes.Workload, error) {
+func (b *BoltStorage) current(tx *bolt.Tx, twinID uint32, dl uint64, name gridtypes.Name) (gridtypes.Workload, error) {
 	var workload gridtypes.Workload
-	err := b.db.View(func(tx *bolt.Tx) error {
-		twin := tx.Bucket(b.u32(twin))
-		if twin == nil {
-			return errors.Wrap(provision.ErrDeploymentNotExists, "twin not found")
-		}
-		deployment := twin.Bucket(b.u64(deployment))
-		if deployment == nil {
-			return errors.Wrap(provision.ErrDeploymentNotExists, "deployment not found")
-		}
+	twin := tx.Bucket(b.u32(twinID))
+	if twin == nil {
+		return workload, errors.Wrap(provision.ErrDeploymentNotExists, "twin not found")
+	}
+	deployment := twin.Bucket(b.u64(dl))
+	if deployment == nil {
+		return workload, errors.Wrap(provision.ErrDeploymentNotExists, "deployment not found")
+	}
 
-		workloads := deployment.Bucket([]byte(keyWorkloads))
-		if workloads == nil {
-			return errors.Wrap(provision.ErrWorkloadNotExist, "deployment has no active workloads")
+	workloads := deployment.Bucket([]byte(keyWorkloads))
+	if workloads == nil {
+		return workload, errors.Wrap(provision.ErrWorkloadNotExist, "deployment has no active workloads")
+	}
+
+	// this checks if this workload is an "active" workload.
+	// if workload is not in this map, then workload might have been
+	// deleted.
+	typRaw := workloads.Get([]byte(name))
+	if typRaw == nil {
+		return workload, errors.Wrap(provision.ErrWorkloadNotExist, "workload does not exist")
+	}
+
+	typ := gridtypes.WorkloadType(typRaw)
+
+	logs := deployment.Bucket([]byte(keyTransactions))
+	if logs == nil {
+		return workload, errors.Wrap(ErrTransactionNotExist, "no transaction logs available")
+	}
+
+	cursor := logs.Cursor()
+
+	for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+		if err := json.Unmarshal(v, &workload); err != nil {
+			return workload, errors.Wrap(err, "error while scanning transcation logs")
 		}
 
-		// this checks if this workload is an "active" workload.
-		// if workload is not in this map, then workload might have been
-		// deleted.
-		typRaw := workloads.Get([]byte(name))
-		if typRaw == nil {
-			return errors.Wrap(provision.ErrWorkloadNotExist, "workload does not exist")
+		if workload.Name != name {
+			continue
 		}
 
-		typ := gridtypes.WorkloadType(typRaw)
+		if workload.Type != typ {
+			return workload, fmt.Errorf("database inconsistency wrong workload type")
+		}
 
-		logs := deployment.Bucket([]byte(keyTransactions))
-		if logs == nil {
-			return errors.Wrap(ErrTransactionNotExist, "no transaction logs available")
+		// otherwise we have a match.
+		if workload.Result.State == gridtypes.StateUnChanged {
+			continue
 		}
 
-		cursor := logs.Cursor()
+		return workload, nil
+	}
 
-		found := false
-		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
-			if err := json.Unmarshal(v, &workload); err != nil {
-				return errors.Wrap(err, "error while scanning transcation logs")
-			}
+	return workload, ErrTransactionNotExist
+}
 
-			if workload.Name != name {
-				continue
-			}
+func (b *BoltStorage) Current(twin uint32, deployment uint64, name gridtypes.Name) (workload gridtypes.Workload, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		workload, err = b.current(tx, twin, deployment, name)
+		return err
+	})
 
-			if workload.Type != typ {
-				return fmt.Errorf("database inconsistency wrong workload type")
-			}
+	return
+}
 
-			// otherwise we have a match.
-			if workload.Result.State == gridtypes.StateUnChanged {
-				continue
-			}
-			found = true
-			break
+// UpdateResult reads the current state of the workload and appends it as a
+// new transaction with the result changed by update. Both happen in the same
+// transaction so a change made to the workload in the meantime is never
+// overwritten. If update returns provision.ErrNoActionNeeded nothing is stored.
+func (b *BoltStorage) UpdateResult(twin uint32, deployment uint64, name gridtypes.Name, update func(wl *gridtypes.Workload) error) error {
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		workload, err := b.current(tx, twin, deployment, name)
+		if err != nil {
+			return err
 		}
 
-		if !found {
-			return ErrTransactionNotExist
+		if err := update(&workload); err != nil {
+			return err
 		}
 
-		return nil
+		return b.transaction(tx, twin, deployment, workload)
 	})
 
-	return workload, err
+	if errors.Is(err, provision.ErrNoActionNeeded) {
+		return nil
+	}
+
+	return err
 }
 
 func (b *BoltStorage) Twins() ([]uint32, error) {
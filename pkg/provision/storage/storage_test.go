@@ -268,6 +268,66 @@ func TestTransactions(t *testing.T) {
 	require.Equal(gridtypes.StateOk, wl.Result.State)
 }
 
+func TestUpdateResult(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(os.TempDir(), fmt.Sprint(rand.Int63()))
+	defer os.RemoveAll(path)
+
+	db, err := New(path)
+	require.NoError(err)
+
+	dl := gridtypes.Deployment{
+		Version:    1,
+		TwinID:     1,
+		ContractID: 10,
+	}
+
+	err = db.Create(dl)
+	require.NoError(err)
+
+	err = db.UpdateResult(1, 10, "vm1", func(wl *gridtypes.Workload) error {
+		return nil
+	})
+	require.ErrorIs(err, provision.ErrWorkloadNotExist)
+
+	err = db.Add(1, 10, gridtypes.Workload{Name: "vm1", Type: testType1})
+	require.NoError(err)
+
+	err = db.Transaction(1, 10, gridtypes.Workload{
+		Type:    testType1,
+		Name:    gridtypes.Name("vm1"),
+		Version: 1,
+		Result: gridtypes.Result{
+			Created: gridtypes.Now(),
+			State:   gridtypes.StateOk,
+			Data:    json.RawMessage(`{"old":true}`),
+		},
+	})
+	require.NoError(err)
+
+	err = db.UpdateResult(1, 10, "vm1", func(wl *gridtypes.Workload) error {
+		require.Equal(gridtypes.StateOk, wl.Result.State)
+		wl.Result.Data = json.RawMessage(`{"new":true}`)
+		return nil
+	})
+	require.NoError(err)
+
+	wl, err := db.Current(1, 10, "vm1")
+	require.NoError(err)
+	require.EqualValues(1, wl.Version)
+	require.Equal(gridtypes.StateOk, wl.Result.State)
+	require.JSONEq(`{"new":true}`, string(wl.Result.Data))
+
+	err = db.UpdateResult(1, 10, "vm1", func(wl *gridtypes.Workload) error {
+		return provision.ErrNoActionNeeded
+	})
+	require.NoError(err)
+
+	changes, err := db.Changes(1, 10)
+	require.NoError(err)
+	require.Len(changes, 3)
+}
+
 func TestTwins(t *testing.T) {
 	require := require.New(t)
 	path := filepath.Join(os.TempDir(), fmt.Sprint(rand.Int63()))
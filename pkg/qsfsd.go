@@ -17,6 +17,60 @@ type QSFSInfo struct {
 	MetricsEndpoint string
 }
 
+// QSFSBackendHealth is the health of a single zdb backend of a qsfs
+type QSFSBackendHealth struct {
+	Address   string `json:"address"`
+	Namespace string `json:"namespace"`
+	// Meta is set if this is a metadata backend, otherwise it's a data backend
+	Meta      bool `json:"meta"`
+	Reachable bool `json:"reachable"`
+	// Used is the used space on the backend namespace in bytes
+	Used  uint64 `json:"used"`
+	Error string `json:"error,omitempty"`
+}
+
+// QSFSRepair is the state of the rebuild queue of a qsfs. A repair rebuilds
+// all the data files stored by zstor so they are spread again over the
+// currently configured backends.
+type QSFSRepair struct {
+	Running bool `json:"running"`
+	// Queued is the number of files still waiting to be rebuilt
+	Queued uint32 `json:"queued"`
+	// Rebuilt is the number of files rebuilt by the last (or current) repair
+	Rebuilt uint32 `json:"rebuilt"`
+	// Failed is the number of files that failed to rebuild by the last (or current) repair
+	Failed   uint32 `json:"failed"`
+	Started  int64  `json:"started"`
+	Finished int64  `json:"finished"`
+	Error    string `json:"error,omitempty"`
+}
+
+// QSFSKeyRotation is the progress of a qsfs encryption key rotation. While
+// the rotation is running all the data files are stored again with the new
+// keys, zstor only switches to the new keys once all files are rotated.
+type QSFSKeyRotation struct {
+	Running bool `json:"running"`
+	// Total is the number of files to rotate
+	Total uint32 `json:"total"`
+	// Rotated is the number of files stored with the new keys
+	Rotated uint32 `json:"rotated"`
+	// Failed is the number of files that failed to rotate, the keys are not
+	// switched if any file failed. Updating the qsfs again retries the rotation
+	Failed   uint32 `json:"failed"`
+	Started  int64  `json:"started"`
+	Finished int64  `json:"finished"`
+	Error    string `json:"error,omitempty"`
+}
+
+// QSFSHealth is the health of a qsfs. It's degraded if any of its backends
+// is not reachable
+type QSFSHealth struct {
+	Degraded bool                `json:"degraded"`
+	Backends []QSFSBackendHealth `json:"backends"`
+	Repair   QSFSRepair          `json:"repair"`
+	Rotation QSFSKeyRotation     `json:"rotation"`
+}
+
 func (q *QSFSMetrics) Nu(wlID string) (result uint64) {
 	if v, ok := q.Consumption[wlID]; ok {
 		result += v.NetRxBytes
@@ -30,4 +84,10 @@ type QSFSD interface {
 	UpdateMount(wlID string, cfg test.QuantumSafeFS) (QSFSInfo, error)
 	SignalDelete(wlID string) error
 	Metrics() (QSFSMetrics, error)
+	// Health checks all the backends of the qsfs and returns the state of
+	// the repair queue
+	Health(wlID string) (QSFSHealth, error)
+	// Repair starts rebuilding the qsfs data over the configured backends,
+	// this is needed after replacing dead backends with UpdateMount
+	Repair(wlID string) error
 }
@@ -160,6 +160,11 @@ func (q *QSFS) Unmount(wlID string) {
 		log.Error().Err(err).Msg("failed to unmount flist")
 	}
 
+	q.m.Lock()
+	delete(q.repairs, wlID)
+	delete(q.rotations, wlID)
+	q.m.Unlock()
+
 	if err := networkd.QSFSDestroy(ctx, wlID); err != nil {
 		if _, ok := err.(ns.NSPathNotExistErr); !ok {
 			// log any error other than that the namespace doesn't exist
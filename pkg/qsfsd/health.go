@@ -0,0 +1,387 @@
+package qsfsd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/network/namespace"
+	"github.com/threefoldtech/test/pkg/stubs"
+	"github.com/threefoldtech/test/pkg/zdb"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	backendTimeout = 5 * time.Second
+	rebuildTimeout = 10 * time.Minute
+	// zstorConfigFile is the zstor config path relative to the qsfs root
+	zstorConfigFile = "data/zstor.toml"
+	// zstorNextConfigFile holds the config with the new keys while a key
+	// rotation is running
+	zstorNextConfigFile = "data/zstor.next.toml"
+	// zstorConfigPath is the zstor config path inside the qsfs container
+	zstorConfigPath = "/" + zstorConfigFile
+	// zstorNextConfigPath is the path of the rotation config inside the qsfs container
+	zstorNextConfigPath = "/" + zstorNextConfigFile
+	zstorBin            = "/bin/zstor"
+)
+
+// rootFS returns the path of the qsfs container root filesystem on the host
+func (q *QSFS) rootFS(ctx context.Context, wlID string) (string, error) {
+	contd := stubs.NewContainerModuleStub(q.cl)
+	cont, err := contd.Inspect(ctx, qsfsContainerNS, pkg.ContainerID(wlID))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to inspect qsfs container")
+	}
+
+	return cont.RootFS, nil
+}
+
+func (q *QSFS) readQSFSConfig(root string) (cfg zstorConfig, err error) {
+	return q.readConfigFile(filepath.Join(root, zstorConfigFile))
+}
+
+func (q *QSFS) readConfigFile(cfgPath string) (cfg zstorConfig, err error) {
+	if _, err := toml.DecodeFile(cfgPath, &cfg); err != nil {
+		return cfg, errors.Wrap(err, "failed to read zstor config")
+	}
+
+	return cfg, nil
+}
+
+// backends returns the health entries of all the backends in the config
+// with only the backend information set
+func backends(cfg *test.QuantumSafeFSConfig) []pkg.QSFSBackendHealth {
+	var result []pkg.QSFSBackendHealth
+	for _, be := range cfg.Meta.Config.Backends {
+		result = append(result, pkg.QSFSBackendHealth{
+			Address:   be.Address,
+			Namespace: be.Namespace,
+			Meta:      true,
+		})
+	}
+
+	for _, group := range cfg.Groups {
+		for _, be := range group.Backends {
+			result = append(result, pkg.QSFSBackendHealth{
+				Address:   be.Address,
+				Namespace: be.Namespace,
+			})
+		}
+	}
+
+	return result
+}
+
+// passwords maps the backends address/namespace to their password
+func passwords(cfg *test.QuantumSafeFSConfig) map[string]string {
+	result := make(map[string]string)
+	add := func(be test.ZdbBackend) {
+		result[be.Address+"/"+be.Namespace] = be.Password
+	}
+
+	for _, be := range cfg.Meta.Config.Backends {
+		add(be)
+	}
+
+	for _, group := range cfg.Groups {
+		for _, be := range group.Backends {
+			add(be)
+		}
+	}
+
+	return result
+}
+
+// checkBackend connects to the backend and reads its namespace information.
+// it must be called from inside the qsfs network namespace
+func checkBackend(be *pkg.QSFSBackendHealth, password string) {
+	con, err := redis.Dial(
+		"tcp", be.Address,
+		redis.DialConnectTimeout(backendTimeout),
+		redis.DialReadTimeout(backendTimeout),
+		redis.DialWriteTimeout(backendTimeout),
+	)
+	if err != nil {
+		be.Error = err.Error()
+		return
+	}
+	defer con.Close()
+
+	args := []interface{}{be.Namespace}
+	if len(password) != 0 {
+		args = append(args, password)
+	}
+
+	if _, err := con.Do("SELECT", args...); err != nil {
+		be.Error = errors.Wrap(err, "failed to select namespace").Error()
+		return
+	}
+
+	be.Reachable = true
+
+	data, err := redis.Bytes(con.Do("NSINFO", be.Namespace))
+	if err != nil {
+		be.Error = errors.Wrap(err, "failed to get namespace info").Error()
+		return
+	}
+
+	var info zdb.Namespace
+	if err := yaml.Unmarshal(data, &info); err != nil {
+		be.Error = errors.Wrap(err, "failed to parse namespace info").Error()
+		return
+	}
+
+	be.Used = uint64(info.DataSize)
+}
+
+// Health implements pkg.QSFSD
+func (q *QSFS) Health(wlID string) (pkg.QSFSHealth, error) {
+	var health pkg.QSFSHealth
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	marked, err := q.isMarkedForDeletion(ctx, wlID)
+	if err != nil {
+		return health, errors.Wrap(err, "failed to check deletion mark")
+	}
+	if marked {
+		return health, errors.New("qsfs marked for deletion")
+	}
+
+	root, err := q.rootFS(ctx, wlID)
+	if err != nil {
+		return health, err
+	}
+
+	cfg, err := q.readQSFSConfig(root)
+	if err != nil {
+		return health, err
+	}
+
+	health.Backends = backends(&cfg.QuantumSafeFSConfig)
+	secrets := passwords(&cfg.QuantumSafeFSConfig)
+
+	networker := stubs.NewNetworkerStub(q.cl)
+	netNs, err := namespace.GetByName(networker.QSFSNamespace(ctx, wlID))
+	if err != nil {
+		return health, errors.Wrap(err, "didn't find qsfs namespace")
+	}
+	defer netNs.Close()
+
+	// the backends are only reachable from the qsfs namespace. They are
+	// checked in parallel so an unreachable backend only costs one timeout,
+	// each check enters the namespace since it's set per thread
+	var wg sync.WaitGroup
+	errs := make([]error, len(health.Backends))
+	for i := range health.Backends {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			be := &health.Backends[i]
+			errs[i] = netNs.Do(func(_ ns.NetNS) error {
+				checkBackend(be, secrets[be.Address+"/"+be.Namespace])
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return health, errors.Wrap(err, "failed to check qsfs backends")
+		}
+	}
+
+	for _, be := range health.Backends {
+		if !be.Reachable {
+			health.Degraded = true
+			break
+		}
+	}
+
+	health.Repair = q.repairState(wlID)
+	health.Rotation = q.rotationState(wlID)
+	return health, nil
+}
+
+func (q *QSFS) repairState(wlID string) pkg.QSFSRepair {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if state, ok := q.repairs[wlID]; ok {
+		return *state
+	}
+
+	return pkg.QSFSRepair{}
+}
+
+var (
+	dataFileName  = regexp.MustCompile(`^d(\d+)$`)
+	indexFileName = regexp.MustCompile(`^i(\d+)$`)
+)
+
+// sequence lists the files in dir named prefix followed by an id from 0 to
+// the largest id found in dir, the returned paths are relative to root
+func sequence(root, dir string, name *regexp.Regexp, prefix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(root, dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	last, width := -1, 0
+	for _, entry := range entries {
+		match := name.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		id, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+
+		if id > last {
+			last, width = id, len(match[1])
+		}
+	}
+
+	var files []string
+	for id := 0; id <= last; id++ {
+		files = append(files, filepath.Join(dir, fmt.Sprintf("%s%0*d", prefix, width, id)))
+	}
+
+	return files, nil
+}
+
+// dataFiles lists the zdb files stored by zstor: the data files, the index
+// files and the namespace file of the zdbfs data namespace. The returned
+// paths are relative to the container root. Old data files are removed
+// locally once they are stored so all the data files up to the last local
+// one are listed.
+func dataFiles(root string) ([]string, error) {
+	files, err := sequence(root, zstorZDBDataDirPath, dataFileName, "d")
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := sequence(root, zstorZDBIndexDirPath, indexFileName, "i")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, index...)
+
+	namespace := filepath.Join(zstorZDBIndexDirPath, zdbNamespaceFile)
+	if _, err := os.Stat(filepath.Join(root, namespace)); err == nil {
+		files = append(files, namespace)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// Repair implements pkg.QSFSD
+func (q *QSFS) Repair(wlID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	marked, err := q.isMarkedForDeletion(ctx, wlID)
+	if err != nil {
+		return errors.Wrap(err, "failed to check deletion mark")
+	}
+	if marked {
+		return errors.New("qsfs marked for deletion")
+	}
+
+	root, err := q.rootFS(ctx, wlID)
+	if err != nil {
+		return err
+	}
+
+	files, err := dataFiles(root)
+	if err != nil {
+		return errors.Wrap(err, "failed to list qsfs data files")
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if state, ok := q.repairs[wlID]; ok && state.Running {
+		return fmt.Errorf("a repair is already running")
+	}
+
+	if state, ok := q.rotations[wlID]; ok && state.Running {
+		return fmt.Errorf("a key rotation is running")
+	}
+
+	state := &pkg.QSFSRepair{
+		Running: true,
+		Queued:  uint32(len(files)),
+		Started: time.Now().Unix(),
+	}
+	q.repairs[wlID] = state
+
+	go q.repair(wlID, files)
+	return nil
+}
+
+// repair rebuilds the files one by one, a file that fails to rebuild
+// doesn't stop the repair.
+func (q *QSFS) repair(wlID string, files []string) {
+	contd := stubs.NewContainerModuleStub(q.cl)
+	log := log.With().Str("id", wlID).Logger()
+	log.Info().Int("files", len(files)).Msg("starting qsfs repair")
+
+	var lastErr error
+	for _, file := range files {
+		marked, _ := q.isMarkedForDeletion(context.Background(), wlID)
+		if marked {
+			lastErr = errors.New("qsfs marked for deletion")
+			break
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), rebuildTimeout+time.Minute)
+		err := contd.Exec(ctx, qsfsContainerNS, wlID, rebuildTimeout, zstorBin, "-c", zstorConfigPath, "rebuild", "-f", file)
+		cancel()
+
+		q.m.Lock()
+		state := q.repairs[wlID]
+		state.Queued--
+		if err != nil {
+			log.Error().Err(err).Str("file", file).Msg("failed to rebuild qsfs file")
+			state.Failed++
+			lastErr = err
+		} else {
+			state.Rebuilt++
+		}
+		q.m.Unlock()
+	}
+
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	state := q.repairs[wlID]
+	state.Running = false
+	state.Finished = time.Now().Unix()
+	if lastErr != nil {
+		state.Error = lastErr.Error()
+	}
+
+	log.Info().Uint32("rebuilt", state.Rebuilt).Uint32("failed", state.Failed).Msg("qsfs repair finished")
+}
@@ -0,0 +1,105 @@
+package qsfsd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+)
+
+func testConfig() test.QuantumSafeFSConfig {
+	var cfg test.QuantumSafeFSConfig
+	cfg.Meta.Config.Backends = []test.ZdbBackend{
+		{Address: "[2a02::1]:9900", Namespace: "meta1", Password: "meta-secret"},
+	}
+	cfg.Groups = []test.ZdbGroup{
+		{Backends: []test.ZdbBackend{
+			{Address: "[2a02::2]:9900", Namespace: "data1", Password: "secret1"},
+			{Address: "[2a02::3]:9900", Namespace: "data2"},
+		}},
+		{Backends: []test.ZdbBackend{
+			{Address: "[2a02::2]:9900", Namespace: "data3", Password: "secret3"},
+		}},
+	}
+
+	return cfg
+}
+
+func TestBackends(t *testing.T) {
+	cfg := testConfig()
+
+	require.Equal(t, []pkg.QSFSBackendHealth{
+		{Address: "[2a02::1]:9900", Namespace: "meta1", Meta: true},
+		{Address: "[2a02::2]:9900", Namespace: "data1"},
+		{Address: "[2a02::3]:9900", Namespace: "data2"},
+		{Address: "[2a02::2]:9900", Namespace: "data3"},
+	}, backends(&cfg))
+
+	require.Empty(t, backends(&test.QuantumSafeFSConfig{}))
+}
+
+func TestPasswords(t *testing.T) {
+	cfg := testConfig()
+
+	require.Equal(t, map[string]string{
+		"[2a02::1]:9900/meta1": "meta-secret",
+		"[2a02::2]:9900/data1": "secret1",
+		"[2a02::3]:9900/data2": "",
+		"[2a02::2]:9900/data3": "secret3",
+	}, passwords(&cfg))
+}
+
+func TestDataFiles(t *testing.T) {
+	require := require.New(t)
+	root := t.TempDir()
+
+	files, err := dataFiles(root)
+	require.NoError(err)
+	require.Empty(files)
+
+	data := filepath.Join(root, zstorZDBDataDirPath)
+	index := filepath.Join(root, zstorZDBIndexDirPath)
+	require.NoError(os.MkdirAll(data, 0755))
+	require.NoError(os.MkdirAll(index, 0755))
+
+	// d0 and d1 were evicted after they were stored
+	for _, name := range []string{"d2", "d3", "other", "d3.tmp"} {
+		require.NoError(os.WriteFile(filepath.Join(data, name), nil, 0644))
+	}
+
+	for _, name := range []string{"i0", "i1", zdbNamespaceFile} {
+		require.NoError(os.WriteFile(filepath.Join(index, name), nil, 0644))
+	}
+
+	files, err = dataFiles(root)
+	require.NoError(err)
+	require.Equal([]string{
+		zstorZDBDataDirPath + "/d0",
+		zstorZDBDataDirPath + "/d1",
+		zstorZDBDataDirPath + "/d2",
+		zstorZDBDataDirPath + "/d3",
+		zstorZDBIndexDirPath + "/i0",
+		zstorZDBIndexDirPath + "/i1",
+		zstorZDBIndexDirPath + "/" + zdbNamespaceFile,
+	}, files)
+}
+
+func TestDataFilesWidth(t *testing.T) {
+	require := require.New(t)
+	root := t.TempDir()
+
+	data := filepath.Join(root, zstorZDBDataDirPath)
+	require.NoError(os.MkdirAll(data, 0755))
+	require.NoError(os.WriteFile(filepath.Join(data, "d00002"), nil, 0644))
+
+	files, err := dataFiles(root)
+	require.NoError(err)
+	require.Equal([]string{
+		zstorZDBDataDirPath + "/d00000",
+		zstorZDBDataDirPath + "/d00001",
+		zstorZDBDataDirPath + "/d00002",
+	}, files)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -28,6 +29,8 @@ const (
 	zstorZDBFSMountPoint  = "/mnt" // hardcoded in the container
 	zstorMetricsPort      = 9100
 	zstorZDBDataDirPath   = "/data/data/zdbfs-data"
+	zstorZDBIndexDirPath  = "/data/index/zdbfs-data"
+	zdbNamespaceFile      = "zdb-namespace"
 	tombstonesDir         = "tombstones"
 )
 
@@ -36,6 +39,10 @@ type QSFS struct {
 
 	mountsPath     string
 	tombstonesPath string
+
+	m         sync.Mutex
+	repairs   map[string]*pkg.QSFSRepair
+	rotations map[string]*pkg.QSFSKeyRotation
 }
 
 type zstorConfig struct {
@@ -62,10 +69,13 @@ func New(ctx context.Context, cl zbus.Client, root string) (pkg.QSFSD, error) {
 		cl:             cl,
 		mountsPath:     mountPath,
 		tombstonesPath: tombstonesPath,
+		repairs:        make(map[string]*pkg.QSFSRepair),
+		rotations:      make(map[string]*pkg.QSFSKeyRotation),
 	}
 	if err := qsfs.migrateTombstones(ctx, cl); err != nil {
 		return nil, err
 	}
+	qsfs.resumeRotations(ctx)
 	go qsfs.periodicCleanup(ctx)
 	return qsfs, nil
 }
@@ -130,6 +140,16 @@ func (q *QSFS) Mount(wlID string, cfg test.QuantumSafeFS) (info pkg.QSFSInfo, er
 		err = errors.Wrap(err, "failed to mount qsfs flist")
 		return
 	}
+	// the flist can still have the config of a previous mount (after a reboot)
+	// if the keys were changed since, the data needs to be rotated first
+	next := zstorConfig
+	rotate := false
+	if current, lerr := q.readQSFSConfig(flistPath); lerr == nil {
+		rotate = keysChanged(&current.QuantumSafeFSConfig, &zstorConfig.QuantumSafeFSConfig)
+		if rotate {
+			zstorConfig = withKeys(zstorConfig, &current.QuantumSafeFSConfig)
+		}
+	}
 	if lerr := q.writeQSFSConfig(flistPath, zstorConfig); lerr != nil {
 		err = errors.Wrap(lerr, "couldn't write qsfs config")
 		return
@@ -173,6 +193,11 @@ func (q *QSFS) Mount(wlID string, cfg test.QuantumSafeFS) (info pkg.QSFSInfo, er
 		return
 	}
 	log.Debug().Str("duration", time.Since(t).String()).Msg("waiting for qsfs deployment took")
+	if rotate {
+		if lerr := q.startRotation(wlID, flistPath, next); lerr != nil {
+			log.Error().Err(lerr).Str("id", wlID).Msg("failed to start key rotation")
+		}
+	}
 	info.Path = mountPath
 	info.MetricsEndpoint = fmt.Sprintf("http://[%s]:%d/metrics", yggIP, zstorMetricsPort)
 
@@ -247,6 +272,18 @@ func (q *QSFS) UpdateMount(wlID string, cfg test.QuantumSafeFS) (pkg.QSFSInfo, e
 	if err != nil {
 		return info, errors.Wrap(err, "failed to get qsfs flist mountpoint")
 	}
+	current, err := q.readQSFSConfig(flistPath)
+	if err != nil {
+		return info, err
+	}
+	if keysChanged(&current.QuantumSafeFSConfig, &zstorConfig.QuantumSafeFSConfig) {
+		// the data is re-encrypted in the background, zstor keeps
+		// using the old keys until all the data is rotated
+		if err := q.startRotation(wlID, flistPath, zstorConfig); err != nil {
+			return info, errors.Wrap(err, "failed to start key rotation")
+		}
+		zstorConfig = withKeys(zstorConfig, &current.QuantumSafeFSConfig)
+	}
 	if err := q.writeQSFSConfig(flistPath, zstorConfig); err != nil {
 		return info, errors.Wrap(err, "couldn't write qsfs config")
 	}
@@ -302,8 +339,11 @@ func (q *QSFS) prepareMountPath(path string) error {
 }
 
 func (q *QSFS) writeQSFSConfig(root string, cfg zstorConfig) error {
-	cfgPath := filepath.Join(root, "data/zstor.toml")
-	f, err := os.OpenFile(cfgPath, os.O_WRONLY|os.O_CREATE, 0644)
+	return q.writeConfigFile(filepath.Join(root, zstorConfigFile), cfg)
+}
+
+func (q *QSFS) writeConfigFile(cfgPath string, cfg zstorConfig) error {
+	f, err := os.OpenFile(cfgPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return errors.Wrap(err, "couldn't open zstor config file")
 	}
@@ -0,0 +1,364 @@
+package qsfsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	// rotationPasses is the max number of times the data files are listed
+	// during a rotation while zdb is still writing. Files that are written
+	// while the rotation is running are stored by zstor with the old keys,
+	// so they need to be rotated again
+	rotationPasses = 3
+	// frozenChecks is the max number of times the zdb writes are frozen to
+	// check that no file is pending before the keys are switched. Files
+	// found pending are rotated after zdb is resumed, then checked again
+	frozenChecks = 3
+	// maxFreeze is the max total time the zdb writes are frozen during a
+	// rotation. Only the listing of the files and the switch of the keys
+	// are done while frozen, so this is never expected to be reached
+	maxFreeze = 30 * time.Second
+	// zdbService is the zinit service of the zdb process in the qsfs container
+	zdbService = "zdb"
+)
+
+// keysChanged checks if the encryption of the data or the metadata changed
+func keysChanged(old, new *test.QuantumSafeFSConfig) bool {
+	return old.Encryption.Algorithm != new.Encryption.Algorithm ||
+		!bytes.Equal(old.Encryption.Key, new.Encryption.Key) ||
+		old.Meta.Config.Encryption.Algorithm != new.Meta.Config.Encryption.Algorithm ||
+		!bytes.Equal(old.Meta.Config.Encryption.Key, new.Meta.Config.Encryption.Key)
+}
+
+// withKeys returns cfg with the encryption of keys
+func withKeys(cfg zstorConfig, keys *test.QuantumSafeFSConfig) zstorConfig {
+	cfg.Encryption = keys.Encryption
+	cfg.Meta.Config.Encryption = keys.Meta.Config.Encryption
+	return cfg
+}
+
+// pendingFiles returns the files that were not rotated yet or that were
+// modified since they were rotated. Files that are not available locally
+// can't be modified.
+func pendingFiles(root string, files []string, rotated map[string]time.Time) []string {
+	var pending []string
+	for _, file := range files {
+		var modified time.Time
+		if stat, err := os.Stat(filepath.Join(root, file)); err == nil {
+			modified = stat.ModTime()
+		}
+
+		if last, ok := rotated[file]; !ok || (!modified.IsZero() && modified.After(last)) {
+			pending = append(pending, file)
+		}
+	}
+
+	return pending
+}
+
+// freeze stops (or resumes) the zdb process of the qsfs so no data is written
+// while the rotation checks that all the files were rotated
+func (q *QSFS) freeze(wlID string, frozen bool) error {
+	signal := "SIGCONT"
+	if frozen {
+		signal = "SIGSTOP"
+	}
+
+	contd := stubs.NewContainerModuleStub(q.cl)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+	return contd.Exec(ctx, qsfsContainerNS, wlID, 10*time.Second, "/sbin/zinit", "kill", zdbService, signal)
+}
+
+func (q *QSFS) rotationState(wlID string) pkg.QSFSKeyRotation {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if state, ok := q.rotations[wlID]; ok {
+		return *state
+	}
+
+	return pkg.QSFSKeyRotation{}
+}
+
+// startRotation starts rotating the qsfs data to the keys in next. If a
+// rotation to the same keys is already running only the rest of the config
+// is updated.
+func (q *QSFS) startRotation(wlID, root string, next zstorConfig) error {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	nextPath := filepath.Join(root, zstorNextConfigFile)
+	if state, ok := q.rotations[wlID]; ok && state.Running {
+		running, err := q.readConfigFile(nextPath)
+		if err != nil {
+			return err
+		}
+
+		if keysChanged(&running.QuantumSafeFSConfig, &next.QuantumSafeFSConfig) {
+			return fmt.Errorf("a key rotation is already running")
+		}
+
+		return q.writeConfigFile(nextPath, next)
+	}
+
+	if state, ok := q.repairs[wlID]; ok && state.Running {
+		return fmt.Errorf("a repair is running")
+	}
+
+	if err := q.writeConfigFile(nextPath, next); err != nil {
+		return errors.Wrap(err, "failed to write rotation config")
+	}
+
+	q.rotations[wlID] = &pkg.QSFSKeyRotation{
+		Running: true,
+		Started: time.Now().Unix(),
+	}
+
+	go q.rotate(wlID, root)
+	return nil
+}
+
+// resumeRotations starts again the rotations that were running when qsfsd
+// was stopped
+func (q *QSFS) resumeRotations(ctx context.Context) {
+	contd := stubs.NewContainerModuleStub(q.cl)
+	containers, err := contd.List(ctx, qsfsContainerNS)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list qsfs containers")
+		return
+	}
+
+	for _, id := range containers {
+		wlID := string(id)
+		root, err := q.rootFS(ctx, wlID)
+		if err != nil {
+			log.Error().Err(err).Str("id", wlID).Msg("failed to get qsfs root")
+			continue
+		}
+
+		next, err := q.readConfigFile(filepath.Join(root, zstorNextConfigFile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			log.Error().Err(err).Str("id", wlID).Msg("failed to read rotation config")
+			continue
+		}
+
+		// qsfsd could have stopped while the zdb writes were frozen
+		if err := q.freeze(wlID, false); err != nil {
+			log.Error().Err(err).Str("id", wlID).Msg("failed to resume qsfs zdb writes")
+		}
+
+		if err := q.startRotation(wlID, root, next); err != nil {
+			log.Error().Err(err).Str("id", wlID).Msg("failed to resume key rotation")
+		}
+	}
+}
+
+// rotateFile stores the file again with the new keys. Files that are not
+// available locally are retrieved first with the current keys.
+func (q *QSFS) rotateFile(wlID, root, file string) error {
+	contd := stubs.NewContainerModuleStub(q.cl)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*rebuildTimeout+time.Minute)
+	defer cancel()
+
+	hostPath := filepath.Join(root, file)
+	if _, err := os.Stat(hostPath); errors.Is(err, os.ErrNotExist) {
+		if err := contd.Exec(ctx, qsfsContainerNS, wlID, rebuildTimeout, zstorBin, "-c", zstorConfigPath, "retrieve", "-f", file); err != nil {
+			return errors.Wrap(err, "failed to retrieve file")
+		}
+		// the file was not local, so we don't keep it
+		defer os.Remove(hostPath)
+	} else if err != nil {
+		return err
+	}
+
+	if err := contd.Exec(ctx, qsfsContainerNS, wlID, rebuildTimeout, zstorBin, "-c", zstorNextConfigPath, "store", "-f", file); err != nil {
+		return errors.Wrap(err, "failed to store file with the new keys")
+	}
+
+	return nil
+}
+
+// rotateFiles stores the pending files with the new keys and records their
+// rotation time in rotated. It returns the number of files that failed
+func (q *QSFS) rotateFiles(wlID, root string, pending []string, rotated map[string]time.Time) (uint32, error) {
+	q.m.Lock()
+	q.rotations[wlID].Total += uint32(len(pending))
+	q.m.Unlock()
+
+	var failed uint32
+	for _, file := range pending {
+		if marked, _ := q.isMarkedForDeletion(context.Background(), wlID); marked {
+			return failed, errors.New("qsfs marked for deletion")
+		}
+
+		rotated[file] = time.Now()
+		err := q.rotateFile(wlID, root, file)
+
+		q.m.Lock()
+		if err != nil {
+			log.Error().Err(err).Str("id", wlID).Str("file", file).Msg("failed to rotate qsfs file")
+			q.rotations[wlID].Failed++
+			failed++
+		} else {
+			q.rotations[wlID].Rotated++
+		}
+		q.m.Unlock()
+	}
+
+	return failed, nil
+}
+
+// switchKeys makes the new keys the current keys of zstor
+func (q *QSFS) switchKeys(ctx context.Context, wlID, root string) error {
+	if err := os.Rename(filepath.Join(root, zstorNextConfigFile), filepath.Join(root, zstorConfigFile)); err != nil {
+		return errors.Wrap(err, "failed to switch zstor config")
+	}
+
+	contd := stubs.NewContainerModuleStub(q.cl)
+	if err := contd.Exec(ctx, qsfsContainerNS, wlID, 10*time.Second, "/sbin/zinit", "kill", "zstor", "SIGINT"); err != nil {
+		return errors.Wrap(err, "failed to restart zstor process")
+	}
+
+	return nil
+}
+
+// checkFrozen freezes the zdb writes and switches the keys if no file is
+// pending. zdb is always resumed before it returns, the pending files are
+// returned to be rotated with zdb running. The freeze is aborted if it
+// lasts longer than budget.
+func (q *QSFS) checkFrozen(wlID, root string, rotated map[string]time.Time, budget time.Duration) (pending []string, frozen time.Duration, err error) {
+	if err := q.freeze(wlID, true); err != nil {
+		// the signal could still have been delivered
+		if err := q.freeze(wlID, false); err != nil {
+			log.Error().Err(err).Str("id", wlID).Msg("failed to resume qsfs zdb writes")
+		}
+		return nil, 0, errors.Wrap(err, "failed to freeze qsfs zdb writes")
+	}
+
+	started := time.Now()
+	defer func() {
+		frozen = time.Since(started)
+		if ferr := q.freeze(wlID, false); ferr != nil && err == nil {
+			err = errors.Wrap(ferr, "failed to resume qsfs zdb writes")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	defer cancel()
+
+	files, err := dataFiles(root)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to list qsfs data files")
+	}
+
+	pending = pendingFiles(root, files, rotated)
+	if len(pending) != 0 {
+		return pending, 0, nil
+	}
+
+	if ctx.Err() != nil {
+		return nil, 0, fmt.Errorf("zdb writes were frozen for more than %s in total, keys were not switched", maxFreeze)
+	}
+
+	return nil, 0, q.switchKeys(ctx, wlID, root)
+}
+
+// rotate stores all the data files with the new keys then switches zstor
+// to the new keys. The keys are only switched once a check done with the
+// zdb writes frozen finds no pending file, so no file is left stored with
+// the old keys. Files are always rotated with zdb running, and zdb is never
+// frozen for more than maxFreeze in total. If any file fails to rotate, or
+// files are still pending, zstor keeps the old keys.
+func (q *QSFS) rotate(wlID, root string) {
+	log := log.With().Str("id", wlID).Logger()
+	log.Info().Msg("starting qsfs key rotation")
+
+	finish := func(err error) {
+		q.m.Lock()
+		defer q.m.Unlock()
+
+		state := q.rotations[wlID]
+		state.Running = false
+		state.Finished = time.Now().Unix()
+		if err != nil {
+			log.Error().Err(err).Msg("qsfs key rotation failed")
+			state.Error = err.Error()
+			return
+		}
+
+		log.Info().Uint32("rotated", state.Rotated).Msg("qsfs key rotation finished")
+	}
+
+	// rotated keeps the modification time of the rotated files so files
+	// that changed during the rotation are rotated again
+	rotated := make(map[string]time.Time)
+	var failed uint32
+	for pass := 0; pass < rotationPasses; pass++ {
+		files, err := dataFiles(root)
+		if err != nil {
+			finish(errors.Wrap(err, "failed to list qsfs data files"))
+			return
+		}
+
+		pending := pendingFiles(root, files, rotated)
+		if len(pending) == 0 {
+			// nothing changed since the last pass
+			break
+		}
+
+		count, err := q.rotateFiles(wlID, root, pending, rotated)
+		failed += count
+		if err != nil {
+			finish(err)
+			return
+		}
+	}
+
+	budget := maxFreeze
+	for check := 0; check < frozenChecks && budget > 0; check++ {
+		if failed != 0 {
+			finish(fmt.Errorf("%d files failed to rotate, keys were not switched", failed))
+			return
+		}
+
+		pending, frozen, err := q.checkFrozen(wlID, root, rotated, budget)
+		budget -= frozen
+		if err != nil {
+			finish(err)
+			return
+		}
+
+		if len(pending) == 0 {
+			finish(nil)
+			return
+		}
+
+		count, err := q.rotateFiles(wlID, root, pending, rotated)
+		failed += count
+		if err != nil {
+			finish(err)
+			return
+		}
+	}
+
+	if failed != 0 {
+		finish(fmt.Errorf("%d files failed to rotate, keys were not switched", failed))
+		return
+	}
+
+	finish(fmt.Errorf("files are still pending after %d checks, keys were not switched", frozenChecks))
+}
@@ -0,0 +1,112 @@
+package qsfsd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
+)
+
+func testKey(b byte) test.EncryptionKey {
+	key := make(test.EncryptionKey, 32)
+	for i := range key {
+		key[i] = b
+	}
+
+	return key
+}
+
+func testKeys(data, meta byte) test.QuantumSafeFSConfig {
+	cfg := testConfig()
+	cfg.Encryption = test.Encryption{Algorithm: "AES", Key: testKey(data)}
+	cfg.Meta.Config.Encryption = test.Encryption{Algorithm: "AES", Key: testKey(meta)}
+	return cfg
+}
+
+func TestKeysChanged(t *testing.T) {
+	current := testKeys(1, 2)
+
+	same := testKeys(1, 2)
+	same.MinimalShards = 5
+	same.Groups = nil
+	require.False(t, keysChanged(&current, &same))
+
+	data := testKeys(3, 2)
+	require.True(t, keysChanged(&current, &data))
+
+	meta := testKeys(1, 3)
+	require.True(t, keysChanged(&current, &meta))
+
+	algorithm := testKeys(1, 2)
+	algorithm.Encryption.Algorithm = "other"
+	require.True(t, keysChanged(&current, &algorithm))
+
+	algorithm = testKeys(1, 2)
+	algorithm.Meta.Config.Encryption.Algorithm = "other"
+	require.True(t, keysChanged(&current, &algorithm))
+}
+
+func TestWithKeys(t *testing.T) {
+	require := require.New(t)
+
+	cfg := zstorConfig{
+		QuantumSafeFSConfig: testKeys(3, 4),
+		Socket:              zstorSocket,
+	}
+	cfg.MinimalShards = 5
+
+	old := testKeys(1, 2)
+	result := withKeys(cfg, &old)
+
+	require.Equal(old.Encryption, result.Encryption)
+	require.Equal(old.Meta.Config.Encryption, result.Meta.Config.Encryption)
+	require.False(keysChanged(&old, &result.QuantumSafeFSConfig))
+
+	// the rest of the config is kept
+	require.EqualValues(5, result.MinimalShards)
+	require.Equal(cfg.Groups, result.Groups)
+	require.Equal(cfg.Meta.Config.Backends, result.Meta.Config.Backends)
+	require.Equal(zstorSocket, result.Socket)
+
+	// cfg is not changed
+	require.Equal(testKey(3), cfg.Encryption.Key)
+}
+
+func TestPendingFiles(t *testing.T) {
+	require := require.New(t)
+	root := t.TempDir()
+
+	data := filepath.Join(root, zstorZDBDataDirPath)
+	require.NoError(os.MkdirAll(data, 0755))
+	require.NoError(os.WriteFile(filepath.Join(data, "d1"), nil, 0644))
+	require.NoError(os.WriteFile(filepath.Join(data, "d2"), nil, 0644))
+
+	files, err := dataFiles(root)
+	require.NoError(err)
+	require.Len(files, 3)
+
+	rotated := make(map[string]time.Time)
+	require.Equal(files, pendingFiles(root, files, rotated))
+
+	now := time.Now()
+	for _, file := range files {
+		rotated[file] = now
+	}
+	require.Empty(pendingFiles(root, files, rotated))
+
+	// d2 is written after it was rotated
+	later := now.Add(time.Minute)
+	d2 := filepath.Join(data, "d2")
+	require.NoError(os.Chtimes(d2, later, later))
+	require.Equal([]string{zstorZDBDataDirPath + "/d2"}, pendingFiles(root, files, rotated))
+
+	// a new file is pending
+	require.NoError(os.WriteFile(filepath.Join(data, "d3"), nil, 0644))
+	files, err = dataFiles(root)
+	require.NoError(err)
+	rotated[zstorZDBDataDirPath+"/d2"] = later
+	require.Equal([]string{zstorZDBDataDirPath + "/d3"}, pendingFiles(root, files, rotated))
+}
@@ -13,6 +13,7 @@ import (
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/crypto"
 	"github.com/threefoldtech/test/pkg/environment"
 	"github.com/threefoldtech/test/pkg/geoip"
 	"github.com/threefoldtech/test/pkg/gridtypes"
@@ -147,13 +148,16 @@ func registerNode(
 	log.Info().Str("id", mgr.NodeID(ctx).Identity()).Msg("start registration of the node")
 	log.Info().Msg("registering node on blockchain")
 
-	sk := ed25519.PrivateKey(mgr.PrivateKey(ctx))
+	pk, err := crypto.KeyFromID(mgr.NodeID(ctx))
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get node public key")
+	}
 
 	if _, err := substrateGateway.EnsureAccount(ctx, env.ActivationURL, tcUrl, tcHash); err != nil {
 		return 0, 0, errors.Wrap(err, "failed to ensure account")
 	}
 
-	twinID, err = ensureTwin(ctx, substrateGateway, sk)
+	twinID, err = ensureTwin(ctx, substrateGateway, pk)
 	if err != nil {
 		return 0, 0, errors.Wrap(err, "failed to ensure twin")
 	}
@@ -217,12 +221,8 @@ func registerNode(
 	return nodeID, twinID, err
 }
 
-func ensureTwin(ctx context.Context, substrateGateway *stubs.SubstrateGatewayStub, sk ed25519.PrivateKey) (uint32, error) {
-	identity, err := substrate.NewIdentityFromEd25519Key(sk)
-	if err != nil {
-		return 0, err
-	}
-	twinID, subErr := substrateGateway.GetTwinByPubKey(ctx, identity.PublicKey())
+func ensureTwin(ctx context.Context, substrateGateway *stubs.SubstrateGatewayStub, pk ed25519.PublicKey) (uint32, error) {
+	twinID, subErr := substrateGateway.GetTwinByPubKey(ctx, pk)
 	if subErr.IsCode(pkg.CodeNotFound) {
 		return substrateGateway.CreateTwin(ctx, "", nil)
 	} else if subErr.IsError() {
@@ -2,17 +2,40 @@ package rotate
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// Usage is the disk usage of a logs directory
+type Usage struct {
+	// Size is the total size of the files in bytes
+	Size uint64 `json:"size"`
+	// Rotated is the size of the rotated tails in bytes
+	Rotated uint64 `json:"rotated"`
+	Files   int    `json:"files"`
+}
+
 // RotateAll will rotate all files in the directory if a set of
 // names is given only named files will be rotated, other unknown
 // files will be deleted
 func (r *Rotator) RotateAll(dir string, names ...string) error {
+	return r.rotateDir(dir, true, names...)
+}
+
+// RotateFiles will only rotate the named files in the directory, other
+// files are not touched
+func (r *Rotator) RotateFiles(dir string, names ...string) error {
+	return r.rotateDir(dir, false, names...)
+}
+
+func (r *Rotator) rotateDir(dir string, prune bool, names ...string) error {
 	files, err := os.ReadDir(dir)
 	if os.IsNotExist(err) {
 		return nil
@@ -26,18 +49,23 @@ func (r *Rotator) RotateAll(dir string, names ...string) error {
 	}
 
 	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
 		name := file.Name()
 		log.Debug().Str("file", name).Msg("checking file for rotation")
 		path := filepath.Join(dir, name)
-		base := strings.TrimSuffix(name, r.suffix)
+		base, isTail := r.base(name)
 		if _, ok := namesMap[base]; !ok {
-			log.Debug().Str("file", name).Msg("log file not tracked, deleting...")
-			_ = os.Remove(path)
+			if prune {
+				log.Debug().Str("file", name).Msg("log file not tracked, deleting...")
+				_ = os.Remove(path)
+			}
 			continue
 		}
 
-		if strings.HasSuffix(name, r.suffix) {
-			// a tail file
+		if isTail {
 			continue
 		}
 
@@ -47,5 +75,134 @@ func (r *Rotator) RotateAll(dir string, names ...string) error {
 		}
 	}
 
+	return r.retain(dir, namesMap)
+}
+
+// base returns the name of the file a tail belongs to, isTail is
+// false if name is not a tail
+func (r *Rotator) base(name string) (base string, isTail bool) {
+	trimmed := strings.TrimSuffix(name, gzSuffix)
+	if strings.HasSuffix(trimmed, r.suffix) {
+		return strings.TrimSuffix(trimmed, r.suffix), true
+	}
+
+	ext := filepath.Ext(trimmed)
+	if len(ext) > 1 {
+		if _, err := strconv.Atoi(ext[1:]); err == nil {
+			return strings.TrimSuffix(trimmed, ext), true
+		}
+	}
+
+	return name, false
+}
+
+// retain deletes the tails of the tracked files that are older than the max
+// age, then the oldest tails until the tracked files and their tails are
+// under the max total size
+func (r *Rotator) retain(dir string, tracked map[string]struct{}) error {
+	if r.maxage == 0 && r.maxtotal == 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list directory '%s' files: %w", dir, err)
+	}
+
+	type tail struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var tails []tail
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		// untracked files are not ours to delete, so they
+		// don't count in the directory size either
+		base, isTail := r.base(entry.Name())
+		if _, ok := tracked[base]; !ok {
+			continue
+		}
+
+		total += info.Size()
+		if !isTail {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		if r.maxage > 0 && time.Since(info.ModTime()) > r.maxage {
+			log.Debug().Str("file", path).Msg("log tail expired, deleting...")
+			if err := os.Remove(path); err == nil {
+				total -= info.Size()
+			}
+			continue
+		}
+
+		tails = append(tails, tail{path: path, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	if r.maxtotal == 0 {
+		return nil
+	}
+
+	sort.Slice(tails, func(i, j int) bool {
+		return tails[i].modTime.Before(tails[j].modTime)
+	})
+
+	for _, tail := range tails {
+		if total <= int64(r.maxtotal) {
+			break
+		}
+
+		log.Debug().Str("file", tail.path).Msg("logs directory is too big, deleting tail...")
+		if err := os.Remove(tail.path); err == nil {
+			total -= tail.size
+		}
+	}
+
 	return nil
 }
+
+// Usage returns the disk usage of dir and its sub directories
+func (r *Rotator) Usage(dir string) (Usage, error) {
+	var usage Usage
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			// file was deleted while walking
+			return nil
+		}
+
+		usage.Files++
+		usage.Size += uint64(info.Size())
+		if _, isTail := r.base(entry.Name()); isTail {
+			usage.Rotated += uint64(info.Size())
+		}
+
+		return nil
+	})
+
+	if os.IsNotExist(err) {
+		return usage, nil
+	}
+
+	return usage, err
+}
@@ -1,20 +1,25 @@
 /*
 *
 rotate package provides a very simple tool to truncate a given file to 0 after it copies
-the last *configurable* part of this file to a new file with suffix .tail
+the last *configurable* part of this file to a new file with suffix .0
 
 The idea is that services need to have their log files (or redirection) be open in append
 mode. So truncation of the log file should be enough.
 
+Older tails are kept as generations (.1, .2, ...) optionally compressed with gzip, and
+directories can be kept under a total size and max age of the rotated files.
+
 There is no grantee that some logs will be lost between the copying of the file tail and the
 truncation of the file.
 */
 package rotate
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
 const (
@@ -23,14 +28,16 @@ const (
 	Megabytes Size = 1024 * Kilobytes
 	Gigabyte  Size = 1024 * Megabytes
 
-	suffix = ".0"
+	suffix   = ".0"
+	gzSuffix = ".gz"
 )
 
 var (
 	defaultRotator = Rotator{
-		maxsize:  20 * Megabytes,
-		tailsize: 10 * Megabytes,
-		suffix:   suffix,
+		maxsize:     20 * Megabytes,
+		tailsize:    10 * Megabytes,
+		suffix:      suffix,
+		generations: 1,
 	}
 )
 
@@ -80,6 +87,51 @@ func Suffix(suffix string) Option {
 	return fn
 }
 
+// Generations sets the number of tails to keep. The most recent tail has
+// the Suffix, older ones are suffixed with their generation number (.1, .2, ...).
+// Default to 1
+func Generations(n int) Option {
+	if n < 1 {
+		panic("generations must be at least 1")
+	}
+
+	var fn optFn = func(cfg *Rotator) {
+		cfg.generations = n
+	}
+
+	return fn
+}
+
+// Compress the tails with gzip, compressed tails have an extra '.gz' suffix
+func Compress() Option {
+	var fn optFn = func(cfg *Rotator) {
+		cfg.compress = true
+	}
+
+	return fn
+}
+
+// MaxTotalSize sets the max size of a rotated directory (files and tails). Once
+// exceeded the oldest tails are deleted, files themselves are never deleted.
+// Default to no limit
+func MaxTotalSize(size Size) Option {
+	var fn optFn = func(cfg *Rotator) {
+		cfg.maxtotal = size
+	}
+
+	return fn
+}
+
+// MaxAge deletes the tails that were not modified for the given duration.
+// Default to no limit
+func MaxAge(age time.Duration) Option {
+	var fn optFn = func(cfg *Rotator) {
+		cfg.maxage = age
+	}
+
+	return fn
+}
+
 type Rotator struct {
 	// maxsize is the max file size. If file size exceeds maxsize rotation is applied
 	// otherwise file is not touched
@@ -90,6 +142,15 @@ type Rotator struct {
 
 	// suffix of the tail chunk, default to suffix
 	suffix string
+	// generations is the number of tails to keep
+	generations int
+	// compress tails with gzip
+	compress bool
+
+	// maxtotal is the max size of a rotated directory, 0 means no limit
+	maxtotal Size
+	// maxage is the max age of a tail, 0 means no limit
+	maxage time.Duration
 }
 
 func NewRotator(opt ...Option) Rotator {
@@ -104,6 +165,32 @@ func NewRotator(opt ...Option) Rotator {
 	return cfg
 }
 
+// tail returns the name of the tail generation of file
+func (r *Rotator) tail(file string, generation int) string {
+	name := file + r.suffix
+	if generation > 0 {
+		name = fmt.Sprintf("%s.%d", file, generation)
+	}
+
+	if r.compress {
+		name += gzSuffix
+	}
+
+	return name
+}
+
+// shift moves all tails of file one generation up, dropping the oldest one
+func (r *Rotator) shift(file string) error {
+	for gen := r.generations - 1; gen > 0; gen-- {
+		err := os.Rename(r.tail(file, gen-1), r.tail(file, gen))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to shift log tail: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (r *Rotator) Rotate(file string) error {
 	fd, err := os.OpenFile(file, os.O_RDWR, 0644)
 	if os.IsNotExist(err) {
@@ -127,15 +214,35 @@ func (r *Rotator) Rotate(file string) error {
 		return fmt.Errorf("failed to seek to truncate position: %w", err)
 	}
 
-	tail := file + r.suffix
+	if err := r.shift(file); err != nil {
+		return err
+	}
+
+	tail := r.tail(file, 0)
 	tailFd, err := os.Create(tail)
 	if err != nil {
 		return fmt.Errorf("failed to create tail file '%s': %w", tail, err)
 	}
+	defer tailFd.Close()
 
-	if _, err := io.Copy(tailFd, fd); err != nil {
+	if err := r.copy(tailFd, fd); err != nil {
 		return fmt.Errorf("failed to copy log tail: %w", err)
 	}
 
 	return fd.Truncate(0)
 }
+
+func (r *Rotator) copy(dst io.Writer, src io.Reader) error {
+	if !r.compress {
+		_, err := io.Copy(dst, src)
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		return err
+	}
+
+	return gz.Close()
+}
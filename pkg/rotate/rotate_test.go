@@ -0,0 +1,143 @@
+package rotate
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func write(t *testing.T, path string, data []byte) {
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "service.log")
+	rotator := NewRotator(MaxSize(10*Bytes), TailSize(5*Bytes))
+
+	write(t, file, []byte("0123456789"))
+	require.NoError(t, rotator.Rotate(file))
+	// not bigger than max size
+	require.NoFileExists(t, file+".0")
+
+	write(t, file, []byte("0123456789abc"))
+	require.NoError(t, rotator.Rotate(file))
+
+	data, err := os.ReadFile(file + ".0")
+	require.NoError(t, err)
+	require.Equal(t, "89abc", string(data))
+
+	stat, err := os.Stat(file)
+	require.NoError(t, err)
+	require.Zero(t, stat.Size())
+}
+
+func TestRotateGenerations(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "service.log")
+	rotator := NewRotator(MaxSize(4*Bytes), TailSize(4*Bytes), Generations(2), Compress())
+
+	for _, content := range []string{"first", "second", "third"} {
+		write(t, file, []byte(content))
+		require.NoError(t, rotator.Rotate(file))
+	}
+
+	read := func(path string) string {
+		fd, err := os.Open(path)
+		require.NoError(t, err)
+		defer fd.Close()
+
+		gz, err := gzip.NewReader(fd)
+		require.NoError(t, err)
+		data, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	require.Equal(t, "hird", read(file+".0.gz"))
+	require.Equal(t, "cond", read(file+".1.gz"))
+	require.NoFileExists(t, file+".2.gz")
+}
+
+func TestRotateAll(t *testing.T) {
+	dir := t.TempDir()
+	rotator := NewRotator(MaxSize(4*Bytes), TailSize(4*Bytes), Generations(3))
+
+	write(t, filepath.Join(dir, "tracked"), []byte("tracked"))
+	write(t, filepath.Join(dir, "tracked.1"), []byte("old"))
+	write(t, filepath.Join(dir, "untracked"), []byte("untracked"))
+	write(t, filepath.Join(dir, "untracked.0"), []byte("untracked"))
+
+	require.NoError(t, rotator.RotateFiles(dir, "tracked"))
+	require.FileExists(t, filepath.Join(dir, "tracked.0"))
+	require.FileExists(t, filepath.Join(dir, "tracked.2"))
+	require.FileExists(t, filepath.Join(dir, "untracked"))
+
+	require.NoError(t, rotator.RotateAll(dir, "tracked"))
+	require.NoFileExists(t, filepath.Join(dir, "untracked"))
+	require.NoFileExists(t, filepath.Join(dir, "untracked.0"))
+	require.FileExists(t, filepath.Join(dir, "tracked.0"))
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	rotator := NewRotator(Generations(4), MaxTotalSize(25*Bytes), MaxAge(time.Hour))
+
+	data := bytes.Repeat([]byte("x"), 10)
+	write(t, filepath.Join(dir, "service.log"), data)
+	for i, age := range []time.Duration{time.Minute, 10 * time.Minute, 20 * time.Minute, 2 * time.Hour} {
+		tail := rotator.tail(filepath.Join(dir, "service.log"), i)
+		write(t, tail, data)
+		mod := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(tail, mod, mod))
+	}
+
+	// untracked files are not counted in the total size
+	write(t, filepath.Join(dir, "other.log"), bytes.Repeat([]byte("x"), 100))
+
+	require.NoError(t, rotator.RotateFiles(dir, "service.log"))
+
+	// the expired tail is deleted, then the oldest ones until the
+	// tracked files are under the total size
+	require.FileExists(t, filepath.Join(dir, "service.log"))
+	require.FileExists(t, filepath.Join(dir, "service.log.0"))
+	require.NoFileExists(t, filepath.Join(dir, "service.log.1"))
+	require.NoFileExists(t, filepath.Join(dir, "service.log.2"))
+	require.NoFileExists(t, filepath.Join(dir, "service.log.3"))
+
+	usage, err := rotator.Usage(dir)
+	require.NoError(t, err)
+	require.Equal(t, Usage{Size: 120, Rotated: 10, Files: 3}, usage)
+}
+
+func TestServiceRun(t *testing.T) {
+	dir := t.TempDir()
+	logs := filepath.Join(dir, "logs")
+	data := filepath.Join(dir, "data")
+	require.NoError(t, os.Mkdir(logs, 0755))
+	require.NoError(t, os.Mkdir(data, 0755))
+
+	write(t, filepath.Join(logs, "service.log"), []byte("0123456789"))
+	write(t, filepath.Join(data, "service.log"), []byte("0123456789"))
+
+	service := NewService(time.Hour,
+		Job{Name: "logs", Files: Files(logs, "service.log"), Options: []Option{MaxSize(5 * Bytes)}},
+		Job{Name: "data", Files: Files(data, "service.log"), Options: []Option{MaxSize(20 * Bytes)}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// the jobs are run once when the service starts, each with its options
+	service.Run(ctx)
+
+	require.FileExists(t, filepath.Join(logs, "service.log.0"))
+	require.NoFileExists(t, filepath.Join(data, "service.log.0"))
+}
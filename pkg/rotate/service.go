@@ -0,0 +1,123 @@
+package rotate
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// logsEvery is how often the logs are rotated by the logs service
+	logsEvery = 10 * time.Minute
+)
+
+// LogsPolicy is the rotation policy of all the node logs (zinit services,
+// vms console and containers). Every directory keeps up to 4 compressed
+// tails per file for a week, and never grows beyond 100MB
+func LogsPolicy() []Option {
+	return []Option{
+		MaxSize(8 * Megabytes),
+		TailSize(8 * Megabytes),
+		Generations(4),
+		Compress(),
+		MaxTotalSize(100 * Megabytes),
+		MaxAge(7 * 24 * time.Hour),
+	}
+}
+
+// Job is a set of directories rotated by the service
+type Job struct {
+	// Name of the job, used in logs
+	Name string
+	// Files returns the directories to rotate, with the names
+	// of the tracked files in each directory
+	Files func() (map[string][]string, error)
+	// Prune deletes the files that are not tracked
+	Prune bool
+	// Options is the rotation policy of the job files
+	Options []Option
+}
+
+// Files returns a static Job files function
+func Files(dir string, names ...string) func() (map[string][]string, error) {
+	return func() (map[string][]string, error) {
+		return map[string][]string{dir: names}, nil
+	}
+}
+
+// Service rotates the files of a set of jobs periodically
+type Service struct {
+	every    time.Duration
+	jobs     []Job
+	rotators []Rotator
+}
+
+// NewLogsService creates a rotation service for logs, the jobs that
+// don't set their own options use the logs policy
+func NewLogsService(jobs ...Job) *Service {
+	for i := range jobs {
+		if len(jobs[i].Options) == 0 {
+			jobs[i].Options = LogsPolicy()
+		}
+	}
+
+	return NewService(logsEvery, jobs...)
+}
+
+// NewService creates a new rotation service that runs the jobs every
+// given duration, each job is rotated with its own options
+func NewService(every time.Duration, jobs ...Job) *Service {
+	rotators := make([]Rotator, 0, len(jobs))
+	for _, job := range jobs {
+		rotators = append(rotators, NewRotator(job.Options...))
+	}
+
+	return &Service{every: every, jobs: jobs, rotators: rotators}
+}
+
+// Run the rotation jobs once, then every service duration until
+// the context is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.every)
+	defer ticker.Stop()
+
+	// files may have grown while the service was not running
+	s.rotate()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.rotate()
+		}
+	}
+}
+
+func (s *Service) rotate() {
+	for i, job := range s.jobs {
+		rotator := s.rotators[i]
+		log.Debug().Str("job", job.Name).Msg("running log rotation")
+
+		dirs, err := job.Files()
+		if err != nil {
+			// without the list of tracked files, pruning
+			// would delete everything
+			log.Error().Err(err).Str("job", job.Name).Msg("failed to list files to rotate")
+			continue
+		}
+
+		for dir, names := range dirs {
+			rotate := rotator.RotateFiles
+			if job.Prune {
+				rotate = rotator.RotateAll
+			}
+
+			if err := rotate(dir, names...); err != nil && !os.IsNotExist(err) {
+				log.Error().Err(err).Str("job", job.Name).Str("dir", dir).Msg("failed to rotate files")
+			}
+		}
+	}
+}
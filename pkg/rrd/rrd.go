@@ -20,6 +20,9 @@ type RRD interface {
 	Slot() (Slot, error)
 	// Counters, return all stored counters since the given time (since) until now.
 	Counters(since time.Time) (map[string]float64, error)
+	// CountersRange, return all stored counters in the slots between from
+	// (inclusive) and to (exclusive).
+	CountersRange(from, to time.Time) (map[string]float64, error)
 	// Last returns the last reported value for a metric given the metric
 	// name
 	Last(key string) (value float64, ok bool, err error)
@@ -181,7 +184,20 @@ func (r *rrdBolt) Last(key string) (value float64, ok bool, err error) {
 // Counters return increase in counter value since the given
 // start time.
 func (r *rrdBolt) Counters(since time.Time) (map[string]float64, error) {
-	ts := uint64(since.Unix())
+	return r.counters(uint64(since.Unix()), math.MaxUint64)
+}
+
+// CountersRange return increase in counter value in the slots
+// between from and to.
+func (r *rrdBolt) CountersRange(from, to time.Time) (map[string]float64, error) {
+	if !to.After(from) {
+		return map[string]float64{}, nil
+	}
+
+	return r.counters(uint64(from.Unix()), uint64(to.Unix()))
+}
+
+func (r *rrdBolt) counters(ts, end uint64) (map[string]float64, error) {
 	ts = (ts / r.window) * r.window
 
 	// we start from the previous slot so we check from the last value.
@@ -199,6 +215,10 @@ func (r *rrdBolt) Counters(since time.Time) (map[string]float64, error) {
 				continue
 			}
 
+			if lu64(k) >= end {
+				break
+			}
+
 			bucket := tx.Bucket(k)
 			values := bucket.Cursor()
 			for k, v := values.First(); k != nil; k, v = values.Next() {
@@ -251,3 +251,36 @@ func TestCountersMultipleReports(t *testing.T) {
 
 	require.EqualValues(24, total)
 }
+
+func TestCountersRange(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(os.TempDir(), fmt.Sprint(rand.Int63()))
+	defer os.RemoveAll(path)
+
+	window := 1 * time.Minute
+	db, err := newRRDBolt(path, window, 10*time.Minute)
+	require.NoError(err)
+
+	// align to the window
+	now := time.Now().Truncate(window).Add(-5 * time.Minute)
+	for i := 0; i < 5; i++ {
+		slot, err := db.slotAt(uint64(now.Add(time.Duration(i) * time.Minute).Unix()))
+		require.NoError(err)
+
+		// counter increases by 10 each slot
+		err = slot.Counter("test-1", float64(i)*10)
+		require.NoError(err)
+	}
+
+	counters, err := db.CountersRange(now.Add(time.Minute), now.Add(3*time.Minute))
+	require.NoError(err)
+	require.EqualValues(20, counters["test-1"])
+
+	counters, err = db.CountersRange(now.Add(3*time.Minute), now.Add(time.Minute))
+	require.NoError(err)
+	require.Len(counters, 0)
+
+	counters, err = db.CountersRange(now.Add(-time.Hour), now.Add(time.Hour))
+	require.NoError(err)
+	require.EqualValues(40, counters["test-1"])
+}
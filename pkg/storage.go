@@ -122,6 +122,13 @@ type StorageModule interface {
 	// VolumeList list all volumes
 	VolumeList() ([]Volume, error)
 
+	// PoolVolumeCreate creates a volume on the given pool, this is
+	// used to run operations (like benchmarks) on a specific pool
+	PoolVolumeCreate(pool string, name string, size gridtypes.Unit) (Volume, error)
+
+	// PoolVolumeDelete deletes a volume created by PoolVolumeCreate
+	PoolVolumeDelete(pool string, name string) error
+
 	// Virtual disk management
 
 	// DiskCreate creates a virtual disk given name and size
@@ -689,6 +689,118 @@ func (s *Module) watchCache(ctx context.Context, cache filesystem.Volume) {
 	}
 }
 
+// poolByName finds a pool (ssd or hdd) by its name, s.mu must be held
+func (s *Module) poolByName(name string) (filesystem.Pool, test.DeviceType, error) {
+	for i, pools := range [][]filesystem.Pool{s.ssds, s.hdds} {
+		typ := test.SSDDevice
+		if i == 1 {
+			typ = test.HDDDevice
+		}
+
+		for _, pool := range pools {
+			if pool.Name() == name {
+				return pool, typ, nil
+			}
+		}
+	}
+
+	return nil, "", errors.Wrapf(os.ErrNotExist, "pool '%s' not found", name)
+}
+
+// PoolVolumeCreate creates a volume with the given name and size on the given pool.
+// Unlike VolumeCreate the pool is not selected by the module, this is used to run
+// operations (like benchmarks) on a specific pool.
+func (s *Module) PoolVolumeCreate(pool string, name string, size gridtypes.Unit) (pkg.Volume, error) {
+	if strings.HasPrefix(name, "zdb") {
+		return pkg.Volume{}, fmt.Errorf("invalid volume name. zdb prefix is reserved")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, typ, err := s.poolByName(pool)
+	if err != nil {
+		return pkg.Volume{}, err
+	}
+
+	if _, err := p.Mounted(); err != nil {
+		log.Debug().Msgf("Mounting pool %s...", p.Name())
+		if _, err := p.Mount(); err != nil {
+			return pkg.Volume{}, errors.Wrapf(err, "failed to mount pool %s", p.Name())
+		}
+	}
+
+	usage, err := p.Usage()
+	if err != nil {
+		return pkg.Volume{}, errors.Wrapf(err, "failed to get usage of pool %s", p.Name())
+	}
+
+	if usage.Used+uint64(size) > usage.Size {
+		return pkg.Volume{}, pkg.ErrNotEnoughSpace{DeviceType: typ}
+	}
+
+	volume, err := p.AddVolume(name)
+	if err != nil {
+		return pkg.Volume{}, errors.Wrapf(err, "failed to create volume on pool %s", p.Name())
+	}
+
+	if err := volume.Limit(uint64(size)); err != nil {
+		return pkg.Volume{}, errors.Wrap(err, "failed to set volume size limit")
+	}
+
+	return pkg.Volume{
+		Name: volume.Name(),
+		Path: volume.Path(),
+		Usage: pkg.Usage{
+			Size: size,
+		},
+	}, nil
+}
+
+// PoolVolumeDelete deletes a volume created with PoolVolumeCreate. If the
+// pool has no more volumes, it's unmounted and shutdown.
+func (s *Module) PoolVolumeDelete(pool string, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, _, err := s.poolByName(pool)
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.Mounted(); err != nil {
+		return nil
+	}
+
+	volumes, err := p.Volumes()
+	if err != nil {
+		return err
+	}
+
+	for _, vol := range volumes {
+		if vol.Name() != name {
+			continue
+		}
+
+		if err := p.RemoveVolume(vol.Name()); err != nil {
+			return errors.Wrapf(err, "failed to remove volume %s", vol.Name())
+		}
+
+		if len(volumes) == 1 {
+			if err := p.UnMount(); err != nil {
+				return errors.Wrapf(err, "failed to unmount pool %s", p.Name())
+			}
+			if err := p.Shutdown(); err != nil {
+				return errors.Wrapf(err, "failed to shutdown pool %s", p.Name())
+			}
+		}
+
+		return nil
+	}
+
+	return nil
+}
+
 // createSubvolWithQuota creates a subvolume with the given name and limits it to the given size
 // if the requested disk type does not have a storage pool with enough free size available, an error is returned
 // this methods does set a quota limit equal to size on the created volume
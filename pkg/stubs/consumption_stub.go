@@ -0,0 +1,45 @@
+// GENERATED CODE
+// --------------
+// please do not edit manually instead use the "zbusc" to regenerate
+
+package stubs
+
+import (
+	"context"
+	zbus "github.com/threefoldtech/zbus"
+	pkg "github.com/threefoldtech/test/pkg"
+)
+
+type ConsumptionStub struct {
+	client zbus.Client
+	module string
+	object zbus.ObjectID
+}
+
+func NewConsumptionStub(client zbus.Client) *ConsumptionStub {
+	return &ConsumptionStub{
+		client: client,
+		module: "provision",
+		object: zbus.ObjectID{
+			Name:    "consumption",
+			Version: "0.0.1",
+		},
+	}
+}
+
+func (s *ConsumptionStub) Consumption(ctx context.Context, arg0 uint32, arg1 int64, arg2 int64) (ret0 pkg.ConsumptionReport, ret1 error) {
+	args := []interface{}{arg0, arg1, arg2}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Consumption", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
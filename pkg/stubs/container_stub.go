@@ -61,6 +61,23 @@ func (s *ContainerModuleStub) Exec(ctx context.Context, arg0 string, arg1 string
 	return
 }
 
+func (s *ContainerModuleStub) ExecStart(ctx context.Context, arg0 string, arg1 pkg.ContainerID, arg2 pkg.ExecRequest) (ret0 string, ret1 error) {
+	args := []interface{}{arg0, arg1, arg2}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "ExecStart", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
 func (s *ContainerModuleStub) Inspect(ctx context.Context, arg0 string, arg1 pkg.ContainerID) (ret0 pkg.Container, ret1 error) {
 	args := []interface{}{arg0, arg1}
 	result, err := s.client.RequestContext(ctx, s.module, s.object, "Inspect", args...)
@@ -129,6 +146,42 @@ func (s *ContainerModuleStub) Logs(ctx context.Context, arg0 string, arg1 string
 	return
 }
 
+func (s *ContainerModuleStub) LogsFollow(ctx context.Context, arg0 string, arg1 pkg.ContainerID, arg2 int) (ret0 string, ret1 error) {
+	args := []interface{}{arg0, arg1, arg2}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "LogsFollow", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *ContainerModuleStub) Output(ctx context.Context) (<-chan pkg.ContainerOutput, error) {
+	ch := make(chan pkg.ContainerOutput)
+	recv, err := s.client.Stream(ctx, s.module, s.object, "Output")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(ch)
+		for event := range recv {
+			var obj pkg.ContainerOutput
+			if err := event.Unmarshal(&obj); err != nil {
+				panic(err)
+			}
+			ch <- obj
+		}
+	}()
+	return ch, nil
+}
+
 func (s *ContainerModuleStub) Run(ctx context.Context, arg0 string, arg1 pkg.Container) (ret0 pkg.ContainerID, ret1 error) {
 	args := []interface{}{arg0, arg1}
 	result, err := s.client.RequestContext(ctx, s.module, s.object, "Run", args...)
@@ -146,6 +199,51 @@ func (s *ContainerModuleStub) Run(ctx context.Context, arg0 string, arg1 pkg.Con
 	return
 }
 
+func (s *ContainerModuleStub) SessionClose(ctx context.Context, arg0 string) (ret0 error) {
+	args := []interface{}{arg0}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "SessionClose", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret0 = result.CallError()
+	loader := zbus.Loader{}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *ContainerModuleStub) SessionInput(ctx context.Context, arg0 string, arg1 []byte) (ret0 error) {
+	args := []interface{}{arg0, arg1}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "SessionInput", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret0 = result.CallError()
+	loader := zbus.Loader{}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *ContainerModuleStub) SessionResize(ctx context.Context, arg0 string, arg1 uint16, arg2 uint16) (ret0 error) {
+	args := []interface{}{arg0, arg1, arg2}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "SessionResize", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret0 = result.CallError()
+	loader := zbus.Loader{}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
 func (s *ContainerModuleStub) SignalDelete(ctx context.Context, arg0 string, arg1 pkg.ContainerID) (ret0 error) {
 	args := []interface{}{arg0, arg1}
 	result, err := s.client.RequestContext(ctx, s.module, s.object, "SignalDelete", args...)
@@ -160,3 +258,20 @@ func (s *ContainerModuleStub) SignalDelete(ctx context.Context, arg0 string, arg
 	}
 	return
 }
+
+func (s *ContainerModuleStub) Stats(ctx context.Context, arg0 string, arg1 pkg.ContainerID) (ret0 pkg.ContainerStats, ret1 error) {
+	args := []interface{}{arg0, arg1}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Stats", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
@@ -0,0 +1,79 @@
+// GENERATED CODE
+// --------------
+// please do not edit manually instead use the "zbusc" to regenerate
+
+package stubs
+
+import (
+	"context"
+	zbus "github.com/threefoldtech/zbus"
+	pkg "github.com/threefoldtech/test/pkg"
+)
+
+type EventLogStub struct {
+	client zbus.Client
+	module string
+	object zbus.ObjectID
+}
+
+func NewEventLogStub(client zbus.Client) *EventLogStub {
+	return &EventLogStub{
+		client: client,
+		module: "node",
+		object: zbus.ObjectID{
+			Name:    "events",
+			Version: "0.0.1",
+		},
+	}
+}
+
+func (s *EventLogStub) Last(ctx context.Context) (ret0 uint64, ret1 error) {
+	args := []interface{}{}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Last", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *EventLogStub) List(ctx context.Context, arg0 uint64, arg1 uint32, arg2 pkg.EventKind) (ret0 []pkg.ChainEvent, ret1 error) {
+	args := []interface{}{arg0, arg1, arg2}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "List", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *EventLogStub) Replay(ctx context.Context, arg0 string, arg1 uint64) (ret0 uint32, ret1 error) {
+	args := []interface{}{arg0, arg1}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Replay", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
@@ -0,0 +1,62 @@
+// GENERATED CODE
+// --------------
+// please do not edit manually instead use the "zbusc" to regenerate
+
+package stubs
+
+import (
+	"context"
+	zbus "github.com/threefoldtech/zbus"
+	pkg "github.com/threefoldtech/test/pkg"
+)
+
+type HardwareManagerStub struct {
+	client zbus.Client
+	module string
+	object zbus.ObjectID
+}
+
+func NewHardwareManagerStub(client zbus.Client) *HardwareManagerStub {
+	return &HardwareManagerStub{
+		client: client,
+		module: "node",
+		object: zbus.ObjectID{
+			Name:    "hardware",
+			Version: "0.0.1",
+		},
+	}
+}
+
+func (s *HardwareManagerStub) Events(ctx context.Context, arg0 uint32) (ret0 []pkg.HardwareEvent, ret1 error) {
+	args := []interface{}{arg0}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Events", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *HardwareManagerStub) Inventory(ctx context.Context) (ret0 pkg.HardwareInventory, ret1 error) {
+	args := []interface{}{}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Inventory", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
@@ -28,6 +28,23 @@ func NewQSFSDStub(client zbus.Client) *QSFSDStub {
 	}
 }
 
+func (s *QSFSDStub) Health(ctx context.Context, arg0 string) (ret0 pkg.QSFSHealth, ret1 error) {
+	args := []interface{}{arg0}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Health", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
 func (s *QSFSDStub) Metrics(ctx context.Context) (ret0 pkg.QSFSMetrics, ret1 error) {
 	args := []interface{}{}
 	result, err := s.client.RequestContext(ctx, s.module, s.object, "Metrics", args...)
@@ -62,6 +79,21 @@ func (s *QSFSDStub) Mount(ctx context.Context, arg0 string, arg1 test.QuantumSaf
 	return
 }
 
+func (s *QSFSDStub) Repair(ctx context.Context, arg0 string) (ret0 error) {
+	args := []interface{}{arg0}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Repair", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret0 = result.CallError()
+	loader := zbus.Loader{}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
 func (s *QSFSDStub) SignalDelete(ctx context.Context, arg0 string) (ret0 error) {
 	args := []interface{}{arg0}
 	result, err := s.client.RequestContext(ctx, s.module, s.object, "SignalDelete", args...)
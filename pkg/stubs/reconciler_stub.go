@@ -0,0 +1,62 @@
+// GENERATED CODE
+// --------------
+// please do not edit manually instead use the "zbusc" to regenerate
+
+package stubs
+
+import (
+	"context"
+	zbus "github.com/threefoldtech/zbus"
+	pkg "github.com/threefoldtech/test/pkg"
+)
+
+type ReconcilerStub struct {
+	client zbus.Client
+	module string
+	object zbus.ObjectID
+}
+
+func NewReconcilerStub(client zbus.Client) *ReconcilerStub {
+	return &ReconcilerStub{
+		client: client,
+		module: "provision",
+		object: zbus.ObjectID{
+			Name:    "reconciler",
+			Version: "0.0.1",
+		},
+	}
+}
+
+func (s *ReconcilerStub) LastReport(ctx context.Context) (ret0 pkg.DriftReport, ret1 error) {
+	args := []interface{}{}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "LastReport", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
+func (s *ReconcilerStub) Reconcile(ctx context.Context, arg0 bool) (ret0 pkg.DriftReport, ret1 error) {
+	args := []interface{}{arg0}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "Reconcile", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	semver "github.com/blang/semver"
 	zbus "github.com/threefoldtech/zbus"
+	pkg "github.com/threefoldtech/test/pkg"
 )
 
 type VersionMonitorStub struct {
@@ -43,6 +44,23 @@ func (s *VersionMonitorStub) GetVersion(ctx context.Context) (ret0 semver.Versio
 	return
 }
 
+func (s *VersionMonitorStub) LastUpgrade(ctx context.Context) (ret0 pkg.UpgradeReport, ret1 error) {
+	args := []interface{}{}
+	result, err := s.client.RequestContext(ctx, s.module, s.object, "LastUpgrade", args...)
+	if err != nil {
+		panic(err)
+	}
+	result.PanicOnError()
+	ret1 = result.CallError()
+	loader := zbus.Loader{
+		&ret0,
+	}
+	if err := result.Unmarshal(&loader); err != nil {
+		panic(err)
+	}
+	return
+}
+
 func (s *VersionMonitorStub) Version(ctx context.Context) (<-chan semver.Version, error) {
 	ch := make(chan semver.Version, 1)
 	recv, err := s.client.Stream(ctx, s.module, s.object, "Version")
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+
+	"github.com/threefoldtech/test/pkg"
 )
 
 func (g *ZosAPI) adminInterfacesHandler(ctx context.Context, payload []byte) (interface{}, error) {
@@ -45,3 +47,93 @@ func (g *ZosAPI) adminSetPublicNICHandler(ctx context.Context, payload []byte) (
 	}
 	return nil, g.networkerStub.SetPublicExitDevice(ctx, iface)
 }
+
+func (g *ZosAPI) adminGetMaintenanceHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.upgraderStub.MaintenanceGet(ctx)
+}
+
+func (g *ZosAPI) adminSetMaintenanceHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var cfg pkg.MaintenanceConfig
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting maintenance config: %w", err)
+	}
+	return nil, g.upgraderStub.MaintenanceSet(ctx, cfg)
+}
+
+func (g *ZosAPI) adminUpgradeNowHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return nil, g.upgraderStub.ApplyNow(ctx)
+}
+
+func (g *ZosAPI) adminGetPowerConfigHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.powerStub.ConfigGet(ctx)
+}
+
+func (g *ZosAPI) adminSetPowerConfigHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var cfg pkg.FarmPowerConfig
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting power config: %w", err)
+	}
+	if err := g.powerStub.ConfigSet(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	// the config is shared by all the nodes of the farm
+	stored, err := g.powerStub.ConfigExport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	g.syncPowerConfig(ctx, stored)
+	return nil, nil
+}
+
+func (g *ZosAPI) adminPowerOnHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var node uint32
+	if err := json.Unmarshal(payload, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting node id: %w", err)
+	}
+	return nil, g.powerStub.PowerOn(ctx, node)
+}
+
+func (g *ZosAPI) adminPowerCycleHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var node uint32
+	if err := json.Unmarshal(payload, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting node id: %w", err)
+	}
+	return nil, g.powerStub.PowerCycle(ctx, node)
+}
+
+func (g *ZosAPI) adminPowerStateHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var node uint32
+	if err := json.Unmarshal(payload, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting node id: %w", err)
+	}
+	return g.powerStub.PowerState(ctx, node)
+}
+
+func (g *ZosAPI) adminEventsReplayHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var input struct {
+		Consumer string `json:"consumer"`
+		From     uint64 `json:"from"`
+	}
+	if err := json.Unmarshal(payload, &input); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting consumer and event offset: %w", err)
+	}
+	return g.eventLogStub.Replay(ctx, input.Consumer, input.From)
+}
+
+func (g *ZosAPI) adminReconcileHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var dryRun bool
+	if err := json.Unmarshal(payload, &dryRun); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting dry run flag: %w", err)
+	}
+	return g.reconcilerStub.Reconcile(ctx, dryRun)
+}
+
+func (g *ZosAPI) adminDriftHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.reconcilerStub.LastReport(ctx)
+}
+
+func (g *ZosAPI) adminConsumptionHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.consumption(ctx, 0, payload)
+}
@@ -0,0 +1,127 @@
+package testapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg/environment"
+)
+
+const (
+	// configTTL is how long the organization config is cached before it's
+	// fetched again
+	configTTL = 10 * time.Minute
+)
+
+// EffectiveConfig is the configuration the node is running with after
+// applying kernel params and the local override
+type EffectiveConfig struct {
+	// Override is set if a local override is applied
+	Override bool `json:"override"`
+	// OverrideError is set if the local override exists but is ignored
+	OverrideError string `json:"override_error,omitempty"`
+
+	SubstrateURL    []string `json:"substrate"`
+	RelayURL        []string `json:"relay"`
+	GraphQL         []string `json:"graphql"`
+	FlistURL        string   `json:"flist"`
+	YggdrasilPeers  []string `json:"yggdrasil_peers"`
+	MyceliumPeers   []string `json:"mycelium_peers"`
+	AuthorizedUsers []string `json:"authorized_users"`
+	// ConfigError is set if the organization config can't be fetched
+	ConfigError string `json:"config_error,omitempty"`
+	// ConfigUpdated is when the organization config was last fetched
+	ConfigUpdated time.Time `json:"config_updated"`
+}
+
+// configCache keeps the last organization config so requests never wait
+// for it to be fetched. It's refreshed in the background once it expires.
+type configCache struct {
+	m          sync.Mutex
+	config     environment.Config
+	loaded     bool
+	err        error
+	updated    time.Time
+	refreshing bool
+}
+
+func newConfigCache() *configCache {
+	c := &configCache{refreshing: true}
+	go c.refresh()
+	return c
+}
+
+func (c *configCache) refresh() {
+	config, err := environment.GetConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to fetch organization config")
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// keep the last fetched config if it can't be fetched again
+	if err == nil {
+		c.config = config
+		c.loaded = true
+	}
+	c.err = err
+	c.updated = time.Now()
+	c.refreshing = false
+}
+
+// get returns the cached config, and when it was fetched
+func (c *configCache) get() (environment.Config, time.Time, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if !c.refreshing && time.Since(c.updated) > configTTL {
+		c.refreshing = true
+		go c.refresh()
+	}
+
+	if !c.loaded {
+		if c.err != nil {
+			return environment.Config{}, c.updated, c.err
+		}
+
+		return environment.Config{}, c.updated, fmt.Errorf("config is not fetched yet")
+	}
+
+	return c.config, c.updated, c.err
+}
+
+func (g *ZosAPI) effectiveConfig() EffectiveConfig {
+	var cfg EffectiveConfig
+
+	override, err := environment.GetOverride()
+	if err != nil {
+		cfg.OverrideError = err.Error()
+	}
+	cfg.Override = override != nil
+
+	if env, err := environment.Get(); err == nil {
+		cfg.SubstrateURL = env.SubstrateURL
+		cfg.RelayURL = env.RelayURL
+		cfg.GraphQL = env.GraphQL
+		cfg.FlistURL = env.FlistURL
+	}
+
+	config, updated, err := g.config.get()
+	if err != nil {
+		cfg.ConfigError = err.Error()
+	}
+	cfg.YggdrasilPeers = config.Yggdrasil.Peers
+	cfg.MyceliumPeers = config.Mycelium.Peers
+	cfg.AuthorizedUsers = config.Users.Authorized
+	cfg.ConfigUpdated = updated
+
+	return cfg
+}
+
+func (g *ZosAPI) adminConfigHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.effectiveConfig(), nil
+}
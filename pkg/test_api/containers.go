@@ -0,0 +1,203 @@
+package testapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/threefoldtech/test/pkg"
+)
+
+// execAllowed lists the system containers exec can run in, by containerd
+// namespace. A nil list allows all the containers of the namespace.
+// All the containers running on the node now are workload containers,
+// system containers are added here once they exist.
+var execAllowed = map[string][]pkg.ContainerID{}
+
+// isWorkloadNamespace reports if ns holds the containers of workloads.
+// They hold tenant data and keys (zstor config of qsfs, zdb namespaces
+// passwords) so the farmer can't exec in them even if they are allowed.
+func isWorkloadNamespace(ns string) bool {
+	// tenant containers run in the ns<twin> namespace
+	return ns == "zdb" || ns == "qsfs" || strings.HasPrefix(ns, "ns")
+}
+
+// canExec returns an error if exec is not allowed in the container
+func canExec(ns string, id pkg.ContainerID) error {
+	if isWorkloadNamespace(ns) {
+		return fmt.Errorf("exec is not allowed in workload namespace '%s'", ns)
+	}
+
+	allowed, ok := execAllowed[ns]
+	if !ok {
+		return fmt.Errorf("exec is not allowed in namespace '%s'", ns)
+	}
+
+	if allowed == nil {
+		return nil
+	}
+
+	for _, container := range allowed {
+		if container == id {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("exec is not allowed in container '%s'", id)
+}
+
+// ContainerStats is the stats of a container in a namespace
+type ContainerStats struct {
+	Namespace string              `json:"namespace"`
+	ID        pkg.ContainerID     `json:"id"`
+	Stats     pkg.ContainerStats  `json:"stats"`
+	Limits    pkg.ContainerLimits `json:"limits"`
+	Error     string              `json:"error,omitempty"`
+}
+
+func (g *ZosAPI) adminContainersHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	namespaces, err := g.containerStub.ListNS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output := []ContainerStats{}
+	for _, ns := range namespaces {
+		ids, err := g.containerStub.List(ctx, ns)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			info := ContainerStats{Namespace: ns, ID: id}
+			// a container without a running task has no stats
+			if info.Stats, err = g.containerStub.Stats(ctx, ns, id); err != nil {
+				info.Error = err.Error()
+			}
+			info.Limits = info.Stats.Limits()
+			output = append(output, info)
+		}
+	}
+
+	return output, nil
+}
+
+func (g *ZosAPI) adminContainerStatsHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Namespace string          `json:"namespace"`
+		ID        pkg.ContainerID `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting container namespace and id: %w", err)
+	}
+
+	return g.containerStub.Stats(ctx, args.Namespace, args.ID)
+}
+
+func (g *ZosAPI) adminContainerExecHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Namespace string          `json:"namespace"`
+		ID        pkg.ContainerID `json:"id"`
+		Args      []string        `json:"args"`
+		Env       []string        `json:"env"`
+		TTY       bool            `json:"tty"`
+		Cols      uint16          `json:"cols"`
+		Rows      uint16          `json:"rows"`
+		// Timeout in seconds
+		Timeout uint64 `json:"timeout"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting container namespace, id and command: %w", err)
+	}
+
+	if err := canExec(args.Namespace, args.ID); err != nil {
+		return nil, err
+	}
+
+	if err := g.sessions.subscribe(); err != nil {
+		return nil, err
+	}
+
+	session, err := g.containerStub.ExecStart(ctx, args.Namespace, args.ID, pkg.ExecRequest{
+		Args:    args.Args,
+		Env:     args.Env,
+		TTY:     args.TTY,
+		Cols:    args.Cols,
+		Rows:    args.Rows,
+		Timeout: time.Duration(args.Timeout) * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g.sessions.add(session)
+	return session, nil
+}
+
+func (g *ZosAPI) adminContainerLogsHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Namespace string          `json:"namespace"`
+		ID        pkg.ContainerID `json:"id"`
+		Lines     int             `json:"lines"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting container namespace and id: %w", err)
+	}
+
+	if err := g.sessions.subscribe(); err != nil {
+		return nil, err
+	}
+
+	session, err := g.containerStub.LogsFollow(ctx, args.Namespace, args.ID, args.Lines)
+	if err != nil {
+		return nil, err
+	}
+
+	g.sessions.add(session)
+	return session, nil
+}
+
+func (g *ZosAPI) adminSessionReadHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var session string
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting session id: %w", err)
+	}
+
+	return g.sessions.read(ctx, session)
+}
+
+func (g *ZosAPI) adminSessionInputHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Session string `json:"session"`
+		Data    []byte `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting session id and data: %w", err)
+	}
+
+	return nil, g.containerStub.SessionInput(ctx, args.Session, args.Data)
+}
+
+func (g *ZosAPI) adminSessionResizeHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Session string `json:"session"`
+		Cols    uint16 `json:"cols"`
+		Rows    uint16 `json:"rows"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting session id and terminal size: %w", err)
+	}
+
+	return nil, g.containerStub.SessionResize(ctx, args.Session, args.Cols, args.Rows)
+}
+
+func (g *ZosAPI) adminSessionCloseHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var session string
+	if err := json.Unmarshal(payload, &session); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting session id: %w", err)
+	}
+
+	return nil, g.containerStub.SessionClose(ctx, session)
+}
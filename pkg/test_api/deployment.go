@@ -1,12 +1,14 @@
 package testapi
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/rmb-sdk-go/peer"
 	"github.com/threefoldtech/test/pkg/gridtypes"
+	"github.com/threefoldtech/test/pkg/gridtypes/test"
 )
 
 func (g *ZosAPI) deploymentDeployHandler(ctx context.Context, payload []byte) (interface{}, error) {
@@ -58,3 +60,78 @@ func (g *ZosAPI) deploymentChangesHandler(ctx context.Context, payload []byte) (
 	}
 	return g.provisionStub.Changes(ctx, peer.GetTwinID(ctx), args.ContractID)
 }
+
+// qsfsWorkload returns the id of the qsfs workload with the name given in
+// the payload, the workload must be owned by the caller twin
+func (g *ZosAPI) qsfsWorkload(ctx context.Context, payload []byte) (string, error) {
+	var args struct {
+		ContractID uint64         `json:"contract_id"`
+		Name       gridtypes.Name `json:"name"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return "", err
+	}
+
+	deployment, err := g.provisionStub.Get(ctx, peer.GetTwinID(ctx), args.ContractID)
+	if err != nil {
+		return "", err
+	}
+
+	wl, err := deployment.GetType(args.Name, test.QuantumSafeFSType)
+	if err != nil {
+		return "", err
+	}
+
+	return wl.ID.String(), nil
+}
+
+func (g *ZosAPI) deploymentQSFSHealthHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	id, err := g.qsfsWorkload(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return g.qsfsdStub.Health(ctx, id)
+}
+
+func (g *ZosAPI) deploymentQSFSRepairHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	id, err := g.qsfsWorkload(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return nil, g.qsfsdStub.Repair(ctx, id)
+}
+
+func (g *ZosAPI) deploymentConsumptionHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.consumption(ctx, peer.GetTwinID(ctx), payload)
+}
+
+// consumption returns the consumption report of the contracts of twin (all
+// contracts if twin is 0) as json or csv
+func (g *ZosAPI) consumption(ctx context.Context, twin uint32, payload []byte) (interface{}, error) {
+	var args struct {
+		From   int64  `json:"from"`
+		To     int64  `json:"to"`
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting consumption query: %w", err)
+	}
+
+	report, err := g.consumptionStub.Consumption(ctx, twin, args.From, args.To)
+	if err != nil {
+		return nil, err
+	}
+
+	switch args.Format {
+	case "", "json":
+		return report, nil
+	case "csv":
+		var buf bytes.Buffer
+		if err := report.WriteCSV(&buf); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	default:
+		return nil, fmt.Errorf("unknown format '%s', expecting json or csv", args.Format)
+	}
+}
@@ -7,3 +7,7 @@ import (
 func (g *ZosAPI) gpuListHandler(ctx context.Context, payload []byte) (interface{}, error) {
 	return g.statisticsStub.ListGPUs(ctx)
 }
+
+func (g *ZosAPI) sriovListHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.statisticsStub.ListSRIOV(ctx)
+}
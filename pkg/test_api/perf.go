@@ -21,3 +21,32 @@ func (g *ZosAPI) perfGetHandler(ctx context.Context, payload []byte) (interface{
 func (g *ZosAPI) perfGetAllHandler(ctx context.Context, payload []byte) (interface{}, error) {
 	return g.performanceMonitorStub.GetAll(ctx)
 }
+
+func (g *ZosAPI) perfHistoryHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	type Payload struct {
+		Name string `json:"name"`
+		From uint64 `json:"from"`
+		To   uint64 `json:"to"`
+	}
+	var request Payload
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload %v: %w", payload, err)
+	}
+	return g.performanceMonitorStub.History(ctx, request.Name, request.From, request.To)
+}
+
+func (g *ZosAPI) perfJobHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var id string
+	if err := json.Unmarshal(payload, &id); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting string: %w", err)
+	}
+	return g.performanceMonitorStub.Job(ctx, id)
+}
+
+func (g *ZosAPI) adminPerfRunHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var name string
+	if err := json.Unmarshal(payload, &name); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting string: %w", err)
+	}
+	return g.performanceMonitorStub.Trigger(ctx, name)
+}
@@ -0,0 +1,220 @@
+package testapi
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/tfgrid-sdk-go/rmb-sdk-go/peer"
+)
+
+const (
+	powerSyncTimeout = 30 * time.Second
+	// powerSyncCommand is called on the other nodes of the farm
+	// when the farm power config is set
+	powerSyncCommand = "test.power.sync_config"
+	// powerConfigCommand is called on the other nodes of the farm
+	// to get the farm power config on start
+	powerConfigCommand = "test.power.get_config"
+)
+
+// farmTwins returns the twins of the other nodes of the farm
+func (g *ZosAPI) farmTwins(ctx context.Context) ([]uint32, error) {
+	self, err := g.registrarStub.TwinID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node twin: %w", err)
+	}
+
+	nodes, err := g.substrateGateway.GetNodes(ctx, g.farmID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list farm nodes: %w", err)
+	}
+
+	var twins []uint32
+	for _, id := range nodes {
+		node, err := g.substrateGateway.GetNode(ctx, id)
+		if err != nil {
+			log.Error().Err(err).Uint32("node", id).Msg("failed to get farm node")
+			continue
+		}
+
+		if uint32(node.TwinID) != self {
+			twins = append(twins, uint32(node.TwinID))
+		}
+	}
+
+	return twins, nil
+}
+
+// twinKey returns the public key of a twin
+func (g *ZosAPI) twinKey(ctx context.Context, twin uint32) ([]byte, error) {
+	info, err := g.substrateGateway.GetTwin(ctx, twin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get twin '%d': %w", twin, err)
+	}
+
+	return info.Account.PublicKey(), nil
+}
+
+// sealPowerConfig returns a copy of cfg where the BMC passwords are encrypted
+// to the node of twin, so the passwords never leave the node in clear text.
+// Only the node of twin can decrypt them with openPowerConfig
+func (g *ZosAPI) sealPowerConfig(ctx context.Context, cfg pkg.FarmPowerConfig, twin uint32) (pkg.FarmPowerConfig, error) {
+	pk, err := g.twinKey(ctx, twin)
+	if err != nil {
+		return pkg.FarmPowerConfig{}, err
+	}
+
+	sealed := pkg.FarmPowerConfig{Nodes: make(map[uint32]pkg.PowerBackendConfig), Updated: cfg.Updated}
+	for id, backend := range cfg.Nodes {
+		if len(backend.Password) != 0 {
+			encrypted, err := g.identityStub.EncryptECDH(ctx, []byte(backend.Password), pk)
+			if err != nil {
+				return pkg.FarmPowerConfig{}, fmt.Errorf("failed to encrypt password of node '%d': %w", id, err)
+			}
+			backend.Password = hex.EncodeToString(encrypted)
+		}
+		sealed.Nodes[id] = backend
+	}
+
+	return sealed, nil
+}
+
+// openPowerConfig decrypts the BMC passwords of a config sealed by the node of twin
+func (g *ZosAPI) openPowerConfig(ctx context.Context, cfg pkg.FarmPowerConfig, twin uint32) (pkg.FarmPowerConfig, error) {
+	pk, err := g.twinKey(ctx, twin)
+	if err != nil {
+		return pkg.FarmPowerConfig{}, err
+	}
+
+	opened := pkg.FarmPowerConfig{Nodes: make(map[uint32]pkg.PowerBackendConfig), Updated: cfg.Updated}
+	for id, backend := range cfg.Nodes {
+		if len(backend.Password) != 0 {
+			encrypted, err := hex.DecodeString(backend.Password)
+			if err != nil {
+				return pkg.FarmPowerConfig{}, fmt.Errorf("invalid password of node '%d': %w", id, err)
+			}
+			password, err := g.identityStub.DecryptECDH(ctx, encrypted, pk)
+			if err != nil {
+				return pkg.FarmPowerConfig{}, fmt.Errorf("failed to decrypt password of node '%d': %w", id, err)
+			}
+			backend.Password = string(password)
+		}
+		opened.Nodes[id] = backend
+	}
+
+	return opened, nil
+}
+
+// syncPowerConfig sends the farm power config to all the other nodes of the
+// farm. Nodes that are not reachable get the config when they start.
+func (g *ZosAPI) syncPowerConfig(ctx context.Context, cfg pkg.FarmPowerConfig) {
+	twins, err := g.farmTwins(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to share farm power config")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, twin := range twins {
+		wg.Add(1)
+		go func(twin uint32) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, powerSyncTimeout)
+			defer cancel()
+
+			sealed, err := g.sealPowerConfig(ctx, cfg, twin)
+			if err != nil {
+				log.Error().Err(err).Uint32("twin", twin).Msg("failed to share farm power config")
+				return
+			}
+
+			if err := g.rpc.Call(ctx, twin, powerSyncCommand, sealed, nil); err != nil {
+				log.Error().Err(err).Uint32("twin", twin).Msg("failed to share farm power config")
+			}
+		}(twin)
+	}
+
+	wg.Wait()
+}
+
+// PowerConfigPull gets the most recent farm power config from the other nodes
+// of the farm, so a node that was off when the config was set gets it too
+func (g *ZosAPI) PowerConfigPull(ctx context.Context) error {
+	twins, err := g.farmTwins(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, twin := range twins {
+		var cfg pkg.FarmPowerConfig
+		callCtx, cancel := context.WithTimeout(ctx, powerSyncTimeout)
+		err := g.rpc.Call(callCtx, twin, powerConfigCommand, nil, &cfg)
+		cancel()
+		if err != nil {
+			log.Debug().Err(err).Uint32("twin", twin).Msg("failed to get farm power config")
+			continue
+		}
+
+		cfg, err = g.openPowerConfig(ctx, cfg, twin)
+		if err != nil {
+			log.Error().Err(err).Uint32("twin", twin).Msg("invalid farm power config")
+			continue
+		}
+
+		// only stored if more recent
+		if err := g.powerStub.ConfigSync(ctx, cfg); err != nil {
+			log.Error().Err(err).Uint32("twin", twin).Msg("invalid farm power config")
+		}
+	}
+
+	return nil
+}
+
+// farmNode only allows calls from the other nodes of the same farm
+func (g *ZosAPI) farmNode(ctx context.Context, _ []byte) (context.Context, error) {
+	twin := peer.GetTwinID(ctx)
+	id, subErr := g.substrateGateway.GetNodeByTwinID(ctx, twin)
+	if subErr.IsError() {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	node, err := g.substrateGateway.GetNode(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node '%d': %w", id, err)
+	}
+
+	if uint32(node.FarmID) != g.farmID {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	return ctx, nil
+}
+
+func (g *ZosAPI) powerGetConfigHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	cfg, err := g.powerStub.ConfigExport(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.sealPowerConfig(ctx, cfg, peer.GetTwinID(ctx))
+}
+
+func (g *ZosAPI) powerSyncConfigHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var cfg pkg.FarmPowerConfig
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode input, expecting power config: %w", err)
+	}
+
+	cfg, err := g.openPowerConfig(ctx, cfg, peer.GetTwinID(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, g.powerStub.ConfigSync(ctx, cfg)
+}
@@ -13,14 +13,25 @@ func (g *ZosAPI) SetupRoutes(router *peer.Router) {
 	system.WithHandler("dmi", g.systemDMIHandler)
 	system.WithHandler("hypervisor", g.systemHypervisorHandler)
 	system.WithHandler("diagnostics", g.systemDiagnosticsHandler)
+	system.WithHandler("upgrade", g.systemUpgradeHandler)
+	system.WithHandler("upgrade_pending", g.systemUpgradePendingHandler)
+	system.WithHandler("shutdown", g.systemShutdownHandler)
+	system.WithHandler("events", g.systemEventsHandler)
+	system.WithHandler("hardware", g.systemHardwareHandler)
+	system.WithHandler("hardware_events", g.systemHardwareEventsHandler)
 
 	perf := root.SubRoute("perf")
 	perf.WithHandler("get", g.perfGetHandler)
 	perf.WithHandler("get_all", g.perfGetAllHandler)
+	perf.WithHandler("job", g.perfJobHandler)
+	perf.WithHandler("history", g.perfHistoryHandler)
 
 	gpu := root.SubRoute("gpu")
 	gpu.WithHandler("list", g.gpuListHandler)
 
+	sriov := root.SubRoute("sriov")
+	sriov.WithHandler("list", g.sriovListHandler)
+
 	storage := root.SubRoute("storage")
 	storage.WithHandler("pools", g.storagePoolsHandler)
 
@@ -42,10 +53,40 @@ func (g *ZosAPI) SetupRoutes(router *peer.Router) {
 	deployment.WithHandler("get", g.deploymentGetHandler)
 	deployment.WithHandler("list", g.deploymentListHandler)
 	deployment.WithHandler("changes", g.deploymentChangesHandler)
+	deployment.WithHandler("consumption", g.deploymentConsumptionHandler)
+	deployment.WithHandler("qsfs_health", g.deploymentQSFSHealthHandler)
+	deployment.WithHandler("qsfs_repair", g.deploymentQSFSRepairHandler)
+
+	power := root.SubRoute("power")
+	power.Use(g.farmNode)
+	power.WithHandler("get_config", g.powerGetConfigHandler)
+	power.WithHandler("sync_config", g.powerSyncConfigHandler)
 
 	admin := root.SubRoute("admin")
 	admin.Use(g.authorized)
 	admin.WithHandler("interfaces", g.adminInterfacesHandler)
+	admin.WithHandler("config", g.adminConfigHandler)
 	admin.WithHandler("set_public_nic", g.adminSetPublicNICHandler)
 	admin.WithHandler("get_public_nic", g.adminGetPublicNICHandler)
+	admin.WithHandler("perf_run", g.adminPerfRunHandler)
+	admin.WithHandler("get_maintenance", g.adminGetMaintenanceHandler)
+	admin.WithHandler("set_maintenance", g.adminSetMaintenanceHandler)
+	admin.WithHandler("upgrade_now", g.adminUpgradeNowHandler)
+	admin.WithHandler("get_power_config", g.adminGetPowerConfigHandler)
+	admin.WithHandler("set_power_config", g.adminSetPowerConfigHandler)
+	admin.WithHandler("power_on", g.adminPowerOnHandler)
+	admin.WithHandler("power_cycle", g.adminPowerCycleHandler)
+	admin.WithHandler("power_state", g.adminPowerStateHandler)
+	admin.WithHandler("events_replay", g.adminEventsReplayHandler)
+	admin.WithHandler("reconcile", g.adminReconcileHandler)
+	admin.WithHandler("drift", g.adminDriftHandler)
+	admin.WithHandler("consumption", g.adminConsumptionHandler)
+	admin.WithHandler("containers", g.adminContainersHandler)
+	admin.WithHandler("container_stats", g.adminContainerStatsHandler)
+	admin.WithHandler("container_exec", g.adminContainerExecHandler)
+	admin.WithHandler("container_logs", g.adminContainerLogsHandler)
+	admin.WithHandler("session_read", g.adminSessionReadHandler)
+	admin.WithHandler("session_input", g.adminSessionInputHandler)
+	admin.WithHandler("session_resize", g.adminSessionResizeHandler)
+	admin.WithHandler("session_close", g.adminSessionCloseHandler)
 }
@@ -0,0 +1,197 @@
+package testapi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/stubs"
+)
+
+const (
+	// sessionTTL is how long the output of a session is kept if nobody reads it
+	sessionTTL = 10 * time.Minute
+	// sessionReadWait is how long a read waits for new output
+	sessionReadWait = 5 * time.Second
+	// sessionBufferSize max size of the output kept for a session, older
+	// output is dropped once the buffer is full
+	sessionBufferSize = 1024 * 1024
+)
+
+// SessionOutput is the output of a container session since the last read
+type SessionOutput struct {
+	Stdout []byte `json:"stdout,omitempty"`
+	Stderr []byte `json:"stderr,omitempty"`
+	Logs   []byte `json:"logs,omitempty"`
+	// Truncated is set if some output was dropped because it was not
+	// read fast enough
+	Truncated bool   `json:"truncated,omitempty"`
+	Exited    bool   `json:"exited,omitempty"`
+	ExitCode  int    `json:"exit_code,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type sessionBuffer struct {
+	output SessionOutput
+	size   int
+	// known is set once the session was started through this api, output
+	// of a session can be received before its start call returns
+	known   bool
+	touched time.Time
+	notify  chan struct{}
+}
+
+func (b *sessionBuffer) push(out pkg.ContainerOutput) {
+	switch out.Stream {
+	case pkg.OutputStderr:
+		b.output.Stderr = append(b.output.Stderr, out.Data...)
+	case pkg.OutputLogs:
+		b.output.Logs = append(b.output.Logs, out.Data...)
+	default:
+		b.output.Stdout = append(b.output.Stdout, out.Data...)
+	}
+
+	b.size += len(out.Data)
+	for _, stream := range []*[]byte{&b.output.Stdout, &b.output.Stderr, &b.output.Logs} {
+		if b.size <= sessionBufferSize {
+			break
+		}
+
+		drop := b.size - sessionBufferSize
+		if drop > len(*stream) {
+			drop = len(*stream)
+		}
+		*stream = (*stream)[drop:]
+		b.size -= drop
+		b.output.Truncated = true
+	}
+
+	if out.Exited {
+		b.output.Exited = true
+		b.output.ExitCode = out.ExitCode
+		b.output.Error = out.Error
+	}
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// containerSessions buffers the output of the container sessions until
+// it's read by the operator
+type containerSessions struct {
+	stub       *stubs.ContainerModuleStub
+	buffers    map[string]*sessionBuffer
+	subscribed bool
+	mu         sync.Mutex
+}
+
+func newContainerSessions(stub *stubs.ContainerModuleStub) *containerSessions {
+	return &containerSessions{
+		stub:    stub,
+		buffers: make(map[string]*sessionBuffer),
+	}
+}
+
+// subscribe makes sure we are listening to the sessions output, it must
+// be called before a session is started so no output is missed
+func (s *containerSessions) subscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribed {
+		return nil
+	}
+
+	ch, err := s.stub.Output(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to listen to containers output: %w", err)
+	}
+	s.subscribed = true
+
+	go func() {
+		for out := range ch {
+			s.push(out)
+		}
+
+		log.Warn().Msg("containers output stream closed")
+		s.mu.Lock()
+		s.subscribed = false
+		s.mu.Unlock()
+	}()
+
+	return nil
+}
+
+func (s *containerSessions) buffer(id string) *sessionBuffer {
+	buffer, ok := s.buffers[id]
+	if !ok {
+		buffer = &sessionBuffer{notify: make(chan struct{})}
+		s.buffers[id] = buffer
+	}
+	buffer.touched = time.Now()
+	return buffer
+}
+
+func (s *containerSessions) push(out pkg.ContainerOutput) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer(out.Session).push(out)
+	s.purge()
+}
+
+// purge drops the output of the sessions that were not read for sessionTTL
+func (s *containerSessions) purge() {
+	for id, buffer := range s.buffers {
+		if time.Since(buffer.touched) > sessionTTL {
+			delete(s.buffers, id)
+		}
+	}
+}
+
+// add marks the session as started
+func (s *containerSessions) add(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buffer(id).known = true
+}
+
+// read returns the session output since the last read, it waits up
+// to sessionReadWait for output if there is none
+func (s *containerSessions) read(ctx context.Context, id string) (SessionOutput, error) {
+	s.mu.Lock()
+	buffer, ok := s.buffers[id]
+	if !ok || !buffer.known {
+		s.mu.Unlock()
+		return SessionOutput{}, fmt.Errorf("unknown session '%s'", id)
+	}
+
+	if buffer.size == 0 && !buffer.output.Exited {
+		notify := buffer.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-time.After(sessionReadWait):
+		case <-ctx.Done():
+			return SessionOutput{}, ctx.Err()
+		}
+
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+
+	output := buffer.output
+	buffer.output = SessionOutput{}
+	buffer.size = 0
+	buffer.touched = time.Now()
+	if output.Exited {
+		delete(s.buffers, id)
+	}
+
+	return output, nil
+}
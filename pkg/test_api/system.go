@@ -2,8 +2,13 @@ package testapi
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/environment"
 )
 
 func (g *ZosAPI) systemVersionHandler(ctx context.Context, payload []byte) (interface{}, error) {
@@ -18,11 +23,21 @@ func (g *ZosAPI) systemVersionHandler(ctx context.Context, payload []byte) (inte
 	version := struct {
 		ZOS   string `json:"test"`
 		ZInit string `json:"zinit"`
+		// Override lists the fields set by the local config override
+		Override      []string `json:"override"`
+		OverrideError string   `json:"override_error,omitempty"`
 	}{
 		ZOS:   g.versionMonitorStub.GetVersion(ctx).String(),
 		ZInit: zInitVer,
 	}
 
+	override, err := environment.GetOverride()
+	if err != nil {
+		version.OverrideError = err.Error()
+	} else if override != nil {
+		version.Override = override.Fields()
+	}
+
 	return version, nil
 }
 
@@ -37,3 +52,49 @@ func (g *ZosAPI) systemHypervisorHandler(ctx context.Context, payload []byte) (i
 func (g *ZosAPI) systemDiagnosticsHandler(ctx context.Context, payload []byte) (interface{}, error) {
 	return g.diagnosticsManager.GetSystemDiagnostics(ctx)
 }
+
+func (g *ZosAPI) systemUpgradeHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.versionMonitorStub.LastUpgrade(ctx)
+}
+
+func (g *ZosAPI) systemUpgradePendingHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.upgraderStub.Pending(ctx)
+}
+
+func (g *ZosAPI) systemShutdownHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.powerStub.LastShutdown(ctx)
+}
+
+func (g *ZosAPI) systemEventsHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		From  uint64        `json:"from"`
+		Count uint32        `json:"count"`
+		Kind  pkg.EventKind `json:"kind"`
+	}
+
+	if len(payload) != 0 {
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return nil, fmt.Errorf("failed to decode input, expecting events query: %w", err)
+		}
+	}
+
+	return g.eventLogStub.List(ctx, args.From, args.Count, args.Kind)
+}
+
+func (g *ZosAPI) systemHardwareHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	return g.hardwareStub.Inventory(ctx)
+}
+
+func (g *ZosAPI) systemHardwareEventsHandler(ctx context.Context, payload []byte) (interface{}, error) {
+	var args struct {
+		Count uint32 `json:"count"`
+	}
+
+	if len(payload) != 0 {
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return nil, fmt.Errorf("failed to decode input, expecting hardware events query: %w", err)
+		}
+	}
+
+	return g.hardwareStub.Events(ctx, args.Count)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	substrate "github.com/threefoldtech/tfchain/clients/tfchain-client-go"
+	"github.com/threefoldtech/tfgrid-sdk-go/rmb-sdk-go"
 	"github.com/threefoldtech/zbus"
 	"github.com/threefoldtech/test/pkg/capacity"
 	"github.com/threefoldtech/test/pkg/diagnostics"
@@ -14,16 +15,32 @@ import (
 type ZosAPI struct {
 	oracle                 *capacity.ResourceOracle
 	versionMonitorStub     *stubs.VersionMonitorStub
+	upgraderStub           *stubs.UpgraderStub
+	powerStub              *stubs.PowerManagerStub
+	identityStub           *stubs.IdentityManagerStub
+	registrarStub          *stubs.RegistrarStub
+	substrateGateway       *stubs.SubstrateGatewayStub
 	provisionStub          *stubs.ProvisionStub
 	networkerStub          *stubs.NetworkerStub
 	statisticsStub         *stubs.StatisticsStub
 	storageStub            *stubs.StorageModuleStub
 	performanceMonitorStub *stubs.PerformanceMonitorStub
+	eventLogStub           *stubs.EventLogStub
+	hardwareStub           *stubs.HardwareManagerStub
+	reconcilerStub         *stubs.ReconcilerStub
+	consumptionStub        *stubs.ConsumptionStub
+	qsfsdStub              *stubs.QSFSDStub
+	containerStub          *stubs.ContainerModuleStub
+	sessions               *containerSessions
+	config                 *configCache
 	diagnosticsManager     *diagnostics.DiagnosticsManager
-	farmerID               uint32
+	// rpc is used to call the other nodes of the farm
+	rpc      rmb.Client
+	farmerID uint32
+	farmID   uint32
 }
 
-func NewZosAPI(manager substrate.Manager, client zbus.Client, msgBrokerCon string) (ZosAPI, error) {
+func NewZosAPI(manager substrate.Manager, client zbus.Client, msgBrokerCon string, rpc rmb.Client) (ZosAPI, error) {
 	sub, err := manager.Substrate()
 	if err != nil {
 		return ZosAPI{}, err
@@ -34,15 +51,30 @@ func NewZosAPI(manager substrate.Manager, client zbus.Client, msgBrokerCon strin
 		return ZosAPI{}, err
 	}
 	storageModuleStub := stubs.NewStorageModuleStub(client)
+	containerStub := stubs.NewContainerModuleStub(client)
 	api := ZosAPI{
 		oracle:                 capacity.NewResourceOracle(storageModuleStub),
 		versionMonitorStub:     stubs.NewVersionMonitorStub(client),
+		upgraderStub:           stubs.NewUpgraderStub(client),
+		powerStub:              stubs.NewPowerManagerStub(client),
+		identityStub:           stubs.NewIdentityManagerStub(client),
+		registrarStub:          stubs.NewRegistrarStub(client),
+		substrateGateway:       stubs.NewSubstrateGatewayStub(client),
 		provisionStub:          stubs.NewProvisionStub(client),
 		networkerStub:          stubs.NewNetworkerStub(client),
 		statisticsStub:         stubs.NewStatisticsStub(client),
 		storageStub:            storageModuleStub,
 		performanceMonitorStub: stubs.NewPerformanceMonitorStub(client),
+		eventLogStub:           stubs.NewEventLogStub(client),
+		hardwareStub:           stubs.NewHardwareManagerStub(client),
+		reconcilerStub:         stubs.NewReconcilerStub(client),
+		consumptionStub:        stubs.NewConsumptionStub(client),
+		qsfsdStub:              stubs.NewQSFSDStub(client),
+		containerStub:          containerStub,
+		sessions:               newContainerSessions(containerStub),
+		config:                 newConfigCache(),
 		diagnosticsManager:     diagnosticsManager,
+		rpc:                    rpc,
 	}
 	farm, err := sub.GetFarm(uint32(environment.MustGet().FarmID))
 	if err != nil {
@@ -54,5 +86,6 @@ func NewZosAPI(manager substrate.Manager, client zbus.Client, msgBrokerCon strin
 		return ZosAPI{}, err
 	}
 	api.farmerID = uint32(farmer.ID)
+	api.farmID = uint32(farm.ID)
 	return api, nil
 }
@@ -0,0 +1,220 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// backupDir holds the files of the previous release that were
+	// replaced by the last upgrade
+	backupDir      = "rollback"
+	backupFiles    = "files"
+	backupManifest = "manifest.json"
+)
+
+// backupState is the persisted list of changes done by an upgrade
+// other than replaced files (which are copied under the backup files dir)
+type backupState struct {
+	// Created are the files that did not exist before the upgrade
+	Created []string `json:"created"`
+	// Links are the replaced symlinks and their old targets
+	Links map[string]string `json:"links"`
+	// Services restarted by the upgrade
+	Services []string `json:"services"`
+}
+
+// backup keeps the files of the previous release that are replaced
+// during an upgrade, so the node can be rolled back to it locally
+// without downloading the previous release again.
+type backup struct {
+	dir   string
+	state backupState
+}
+
+func (u *Upgrader) backupDir() string {
+	return filepath.Join(u.root, backupDir)
+}
+
+// newBackup starts a new empty backup, dropping the previous one
+func newBackup(dir string) (*backup, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrap(err, "failed to clean up old backup")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, backupFiles), 0755); err != nil {
+		return nil, errors.Wrap(err, "failed to create backup directory")
+	}
+
+	b := &backup{dir: dir, state: backupState{Links: make(map[string]string)}}
+	return b, b.persist()
+}
+
+// loadBackup loads the backup stored in dir
+func loadBackup(dir string) (*backup, error) {
+	data, err := os.ReadFile(filepath.Join(dir, backupManifest))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read backup manifest")
+	}
+
+	b := &backup{dir: dir}
+	if err := json.Unmarshal(data, &b.state); err != nil {
+		return nil, errors.Wrap(err, "failed to decode backup manifest")
+	}
+
+	return b, nil
+}
+
+func (b *backup) persist() error {
+	data, err := json.Marshal(b.state)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode backup manifest")
+	}
+
+	tmp := filepath.Join(b.dir, backupManifest+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write backup manifest")
+	}
+
+	return os.Rename(tmp, filepath.Join(b.dir, backupManifest))
+}
+
+func (b *backup) file(path string) string {
+	return filepath.Join(b.dir, backupFiles, path)
+}
+
+// has returns true if the original of path is already part of the backup
+func (b *backup) has(path string) bool {
+	if slices.Contains(b.state.Created, path) {
+		return true
+	}
+
+	if _, ok := b.state.Links[path]; ok {
+		return true
+	}
+
+	_, err := os.Lstat(b.file(path))
+	return err == nil
+}
+
+// save backs up path before it's replaced. If the same path is
+// replaced more than once only the original is kept.
+func (b *backup) save(path string) error {
+	if b.has(path) {
+		return nil
+	}
+
+	stat, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		b.state.Created = append(b.state.Created, path)
+		return b.persist()
+	} else if err != nil {
+		return err
+	}
+
+	if stat.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		b.state.Links[path] = target
+		return b.persist()
+	}
+
+	return copyPath(b.file(path), path, stat.Mode())
+}
+
+// restarted records the services restarted by the upgrade
+func (b *backup) restarted(services ...string) error {
+	for _, service := range services {
+		if !slices.Contains(b.state.Services, service) {
+			b.state.Services = append(b.state.Services, service)
+		}
+	}
+
+	return b.persist()
+}
+
+// restore puts back all the backed up files, and removes the files created by
+// the upgrade. It returns the services that need to be restarted.
+func (b *backup) restore() ([]string, error) {
+	root := filepath.Join(b.dir, backupFiles)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		dst := filepath.Join(string(filepath.Separator), rel)
+		// write to a temp file first so a binary is never half written
+		tmp := dst + ".rollback"
+		if err := copyPath(tmp, path, info.Mode()); err != nil {
+			return errors.Wrapf(err, "failed to restore '%s'", dst)
+		}
+
+		return os.Rename(tmp, dst)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for path, target := range b.state.Links {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to remove link '%s'", path)
+		}
+
+		if err := os.Symlink(target, path); err != nil {
+			return nil, errors.Wrapf(err, "failed to restore link '%s'", path)
+		}
+	}
+
+	for _, path := range b.state.Created {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Error().Err(err).Str("file", path).Msg("failed to remove file installed by the upgrade")
+		}
+	}
+
+	return b.state.Services, nil
+}
+
+// copyPath copies src to dst with the given mode, creating the
+// parent directories of dst if needed
+func copyPath(dst, src string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_SYNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,65 @@
+package upgrade
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupRestore(t *testing.T) {
+	require := require.New(t)
+	root := t.TempDir()
+
+	replaced := filepath.Join(root, "bin", "zos")
+	created := filepath.Join(root, "bin", "new")
+	link := filepath.Join(root, "bin", "link")
+
+	require.NoError(os.MkdirAll(filepath.Dir(replaced), 0755))
+	require.NoError(os.WriteFile(replaced, []byte("old"), 0755))
+	require.NoError(os.Symlink("zos", link))
+
+	b, err := newBackup(filepath.Join(root, backupDir))
+	require.NoError(err)
+
+	require.NoError(b.save(replaced))
+	require.NoError(b.save(created))
+	require.NoError(b.save(link))
+	require.NoError(b.restarted("noded", "noded", "networkd"))
+
+	// install the new release
+	require.NoError(os.WriteFile(replaced, []byte("new"), 0755))
+	// saving again keeps the original
+	require.NoError(b.save(replaced))
+	require.NoError(os.WriteFile(created, []byte("new"), 0644))
+	require.NoError(os.Remove(link))
+	require.NoError(os.Symlink("new", link))
+
+	loaded, err := loadBackup(filepath.Join(root, backupDir))
+	require.NoError(err)
+
+	services, err := loaded.restore()
+	require.NoError(err)
+	require.Equal([]string{"noded", "networkd"}, services)
+
+	data, err := os.ReadFile(replaced)
+	require.NoError(err)
+	require.Equal("old", string(data))
+
+	stat, err := os.Stat(replaced)
+	require.NoError(err)
+	require.Equal(os.FileMode(0755), stat.Mode().Perm())
+
+	_, err = os.Stat(created)
+	require.True(os.IsNotExist(err))
+
+	target, err := os.Readlink(link)
+	require.NoError(err)
+	require.Equal("zos", target)
+}
+
+func TestBackupMissing(t *testing.T) {
+	_, err := loadBackup(filepath.Join(t.TempDir(), backupDir))
+	require.Error(t, err)
+}
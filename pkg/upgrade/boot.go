@@ -46,7 +46,18 @@ func (b BootMethod) IsBootstrapped() bool {
 }
 
 // Boot struct
-type Boot struct{}
+type Boot struct {
+	// tagFile overrides TagFile, used in tests
+	tagFile string
+}
+
+func (b *Boot) file() string {
+	if len(b.tagFile) != 0 {
+		return b.tagFile
+	}
+
+	return TagFile
+}
 
 // DetectBootMethod tries to detect the boot method
 // of the node
@@ -60,7 +71,7 @@ func (b Boot) DetectBootMethod() BootMethod {
 		return BootMethodBootstrap
 	}
 
-	if _, err := os.Stat(TagFile); err != nil {
+	if _, err := os.Stat(b.file()); err != nil {
 		return BootMethodOther
 	}
 
@@ -101,7 +112,7 @@ func (b *Boot) Version() semver.Version {
 
 // Current returns current flist information
 func (b *Boot) Current() (flist hub.TagLink, err error) {
-	f, err := os.Open(TagFile)
+	f, err := os.Open(b.file())
 	if os.IsNotExist(err) {
 		return flist, ErrNotBootstrapped
 	} else if err != nil {
@@ -125,7 +136,7 @@ func (b *Boot) Current() (flist hub.TagLink, err error) {
 
 // Set updates the stored flist info
 func (b *Boot) Set(c hub.TagLink) error {
-	f, err := os.Create(TagFile)
+	f, err := os.Create(b.file())
 	if err != nil {
 		return err
 	}
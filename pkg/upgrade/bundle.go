@@ -0,0 +1,366 @@
+package upgrade
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/0-fs/meta"
+	"github.com/threefoldtech/test/pkg/crypto"
+	"github.com/threefoldtech/test/pkg/upgrade/hub"
+)
+
+// An offline upgrade bundle is a directory (or the same layout served over
+// http) with the following structure
+//
+//	manifest.json      the bundle manifest (see BundleManifest)
+//	manifest.json.sig  hex encoded ed25519 signature of manifest.json
+//	flists/<name>      the flists (metadata archives) of the release packages
+//	blocks/<key>       the data blocks of the flists files, named by the hex
+//	                   encoded block key
+const (
+	bundleManifest  = "manifest.json"
+	bundleSignature = "manifest.json.sig"
+	bundleFlists    = "flists"
+	bundleBlocks    = "blocks"
+
+	// maxManifestSize is the max size of the manifest and signature files
+	maxManifestSize = 1024 * 1024
+
+	bundleHTTPTimeout = 60 * time.Second
+)
+
+var (
+	// ErrInvalidBundleSignature is returned if the bundle manifest is not
+	// signed by one of the trusted keys
+	ErrInvalidBundleSignature = fmt.Errorf("invalid bundle signature")
+
+	packageNameRegex = regexp.MustCompile(`^[\w.:-]+\.flist$`)
+	sha256Regex      = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// BundleManifest describes the release in an offline upgrade bundle
+type BundleManifest struct {
+	// Release is the release target as set in the hub taglink
+	// for example `tf-test/tags/v3.12.0`
+	Release string `json:"release"`
+	// Packages of the release
+	Packages []BundlePackage `json:"packages"`
+}
+
+// BundlePackage is a package (flist) of the release
+type BundlePackage struct {
+	// Name of the flist file under flists/
+	Name string `json:"name"`
+	// Hash is the hex encoded sha256 of the flist file
+	Hash string `json:"sha256"`
+}
+
+// Valid validates the manifest
+func (m *BundleManifest) Valid() error {
+	if len(m.Release) == 0 {
+		return fmt.Errorf("bundle release is not set")
+	}
+
+	if len(m.Packages) == 0 {
+		return fmt.Errorf("bundle has no packages")
+	}
+
+	for _, pkg := range m.Packages {
+		if !packageNameRegex.MatchString(pkg.Name) {
+			return fmt.Errorf("invalid package name '%s'", pkg.Name)
+		}
+
+		if !sha256Regex.MatchString(pkg.Hash) {
+			return fmt.Errorf("invalid hash for package '%s'", pkg.Name)
+		}
+	}
+
+	return nil
+}
+
+// bundleSource gives access to the bundle files
+type bundleSource interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// dirSource is a bundle in a local directory
+type dirSource string
+
+func (d dirSource) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+// httpSource is a bundle served by a local http mirror
+type httpSource struct {
+	base   string
+	client *http.Client
+}
+
+func (h *httpSource) Open(name string) (io.ReadCloser, error) {
+	response, err := h.client.Get(fmt.Sprintf("%s/%s", h.base, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("failed to get '%s' from mirror: %s", name, response.Status)
+	}
+
+	return response.Body, nil
+}
+
+func newBundleSource(location string) bundleSource {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return &httpSource{
+			base:   strings.TrimSuffix(location, "/"),
+			client: &http.Client{Timeout: bundleHTTPTimeout},
+		}
+	}
+
+	return dirSource(location)
+}
+
+// bundleStorage implements the 0-fs storage on top of the bundle blocks
+type bundleStorage struct {
+	source bundleSource
+}
+
+func (b *bundleStorage) Get(key []byte) (io.ReadCloser, error) {
+	return b.source.Open(path.Join(bundleBlocks, hex.EncodeToString(key)))
+}
+
+type bundle struct {
+	source   bundleSource
+	manifest BundleManifest
+}
+
+func readAll(source bundleSource, name string) ([]byte, error) {
+	reader, err := source.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(io.LimitReader(reader, maxManifestSize))
+}
+
+// openBundle loads the bundle manifest and verifies that it's signed
+// by one of the trusted keys
+func openBundle(location string, keys []ed25519.PublicKey) (*bundle, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no trusted keys configured for upgrade bundles")
+	}
+
+	source := newBundleSource(location)
+	data, err := readAll(source, bundleManifest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bundle manifest")
+	}
+
+	encoded, err := readAll(source, bundleSignature)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bundle signature")
+	}
+
+	if err := crypto.VerifyAnyHex(keys, data, encoded); err != nil {
+		return nil, ErrInvalidBundleSignature
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to decode bundle manifest")
+	}
+
+	if err := manifest.Valid(); err != nil {
+		return nil, errors.Wrap(err, "invalid bundle manifest")
+	}
+
+	return &bundle{source: source, manifest: manifest}, nil
+}
+
+// storage returns the 0-fs storage of the bundle blocks
+func (b *bundle) storage() *bundleStorage {
+	return &bundleStorage{source: b.source}
+}
+
+// store extracts the flist of the package in the flist cache after
+// verifying its hash, and returns its meta store
+func (b *bundle) store(pkg BundlePackage, cache cache) (meta.Walker, error) {
+	extracted := filepath.Join(cache.flistCache(), fmt.Sprintf("%s.d", pkg.Hash))
+	if !exists(filepath.Join(extracted, "flistdb.sqlite3")) {
+		if err := b.extract(pkg, extracted); err != nil {
+			return nil, err
+		}
+	}
+
+	store, err := meta.NewStore(extracted)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load flist db")
+	}
+
+	walker, ok := store.(meta.Walker)
+	if !ok {
+		store.Close()
+		return nil, fmt.Errorf("flist database of unsupported type")
+	}
+
+	return walker, nil
+}
+
+func (b *bundle) extract(pkg BundlePackage, extracted string) error {
+	reader, err := b.source.Open(path.Join(bundleFlists, pkg.Name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to open package '%s'", pkg.Name)
+	}
+	defer reader.Close()
+
+	// the flist is first downloaded to a temp file so the hash
+	// is verified before it's extracted
+	tmp, err := os.CreateTemp(filepath.Dir(extracted), "bundle-*.flist")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temp file")
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), reader); err != nil {
+		return errors.Wrapf(err, "failed to read package '%s'", pkg.Name)
+	}
+
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != pkg.Hash {
+		return fmt.Errorf("package '%s' hash mismatch, expected '%s' got '%s'", pkg.Name, pkg.Hash, sum)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := meta.Unpack(tmp, extracted); err != nil {
+		os.RemoveAll(extracted)
+		return errors.Wrapf(err, "failed to extract package '%s'", pkg.Name)
+	}
+
+	return nil
+}
+
+// updateFromBundle upgrades the node to the release in the offline bundle
+// at location. It follows the same steps of a hub upgrade (chain version,
+// maintenance windows, health gates) but packages and their files are taken from the bundle.
+// The chain version check is skipped if the chain is not reachable.
+func (u *Upgrader) updateFromBundle(ctx context.Context, location string, trusted []string) error {
+	current, err := u.boot.Current()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get info about current version, update anyway")
+	}
+
+	keys, err := crypto.KeysFromHex(trusted)
+	if err != nil {
+		return errors.Wrap(err, "invalid upgrade keys")
+	}
+
+	b, err := openBundle(location, keys)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open upgrade bundle '%s'", location)
+	}
+
+	if b.manifest.Release == current.Target {
+		// nothing to do!
+		u.clearPending()
+		return nil
+	}
+
+	link := hub.TagLink{FList: hub.FList{
+		Name:   u.boot.RunMode().String(),
+		Type:   hub.TypeTagLink,
+		Target: b.manifest.Release,
+	}}
+
+	if u.isRolledBack(link) {
+		log.Debug().Str("release", link.Target).Msg("release was rolled back, skipping")
+		return nil
+	}
+
+	// the bundle release must still be the release set on the chain. Nodes
+	// upgraded from a bundle are often air-gapped, so if the chain can't be
+	// reached the signed bundle is applied without the rollout check
+	ok, err := u.rolledOut(ctx, link)
+	if err != nil {
+		log.Warn().Err(err).Str("release", link.Target).Msg("failed to check release rollout, applying bundle anyway")
+	} else if !ok {
+		return nil
+	}
+
+	if !u.canApply(ctx, link) {
+		return nil
+	}
+
+	log.Info().Str("running version", u.Version().String()).Str("updating to version", filepath.Base(link.Target)).Msg("updating system from bundle...")
+	return u.apply(current, link, func() error {
+		if err := u.installBundle(b); err != nil {
+			return errors.Wrapf(err, "failed to install bundle release '%s'", link.Target)
+		}
+
+		return nil
+	})
+}
+
+// installBundle installs all the packages of the bundle, the test package
+// is installed last and only if u.noZosUpgrade is not set
+func (u *Upgrader) installBundle(b *bundle) error {
+	var later []BundlePackage
+	for _, pkg := range b.manifest.Packages {
+		if pkg.Name == ZosPackage {
+			later = append(later, pkg)
+			continue
+		}
+
+		if err := u.installBundlePackage(b, pkg); err != nil {
+			return err
+		}
+	}
+
+	if u.noZosUpgrade {
+		return nil
+	}
+
+	for _, pkg := range later {
+		if err := u.installBundlePackage(b, pkg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *Upgrader) installBundlePackage(b *bundle, pkg BundlePackage) error {
+	log.Info().Str("package", pkg.Name).Msg("start installing package from bundle")
+	store, err := b.store(pkg, u)
+	if err != nil {
+		return errors.Wrapf(err, "failed to process package '%s'", pkg.Name)
+	}
+	defer store.Close()
+
+	if err := u.installStore(store, b.storage(), u); err != nil {
+		return errors.Wrapf(err, "failed to install package '%s'", pkg.Name)
+	}
+
+	return nil
+}
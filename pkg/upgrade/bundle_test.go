@@ -0,0 +1,105 @@
+package upgrade
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeBundle(t *testing.T, dir string, manifest BundleManifest, sk ed25519.PrivateKey) {
+	t.Helper()
+
+	data, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, bundleManifest), data, 0644))
+	sig := hex.EncodeToString(ed25519.Sign(sk, data))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, bundleSignature), []byte(sig), 0644))
+}
+
+func TestOpenBundle(t *testing.T) {
+	require := require.New(t)
+
+	pk, sk, err := ed25519.GenerateKey(nil)
+	require.NoError(err)
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(err)
+
+	dir := t.TempDir()
+	manifest := BundleManifest{
+		Release: "tf-test/tags/v3.12.0",
+		Packages: []BundlePackage{
+			{Name: "test.flist", Hash: strings.Repeat("a", 64)},
+		},
+	}
+	writeBundle(t, dir, manifest, sk)
+
+	b, err := openBundle(dir, []ed25519.PublicKey{other, pk})
+	require.NoError(err)
+	require.Equal(manifest, b.manifest)
+
+	_, err = openBundle(dir, []ed25519.PublicKey{other})
+	require.ErrorIs(err, ErrInvalidBundleSignature)
+
+	_, err = openBundle(dir, nil)
+	require.Error(err)
+
+	// tampered manifest
+	manifest.Release = "tf-test/tags/v3.13.0"
+	data, err := json.Marshal(manifest)
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(dir, bundleManifest), data, 0644))
+
+	_, err = openBundle(dir, []ed25519.PublicKey{pk})
+	require.ErrorIs(err, ErrInvalidBundleSignature)
+}
+
+func TestBundleManifestValid(t *testing.T) {
+	require := require.New(t)
+
+	hash := strings.Repeat("0", 64)
+	require.NoError((&BundleManifest{Release: "r", Packages: []BundlePackage{{Name: "test.flist", Hash: hash}}}).Valid())
+	require.Error((&BundleManifest{Packages: []BundlePackage{{Name: "test.flist", Hash: hash}}}).Valid())
+	require.Error((&BundleManifest{Release: "r"}).Valid())
+	require.Error((&BundleManifest{Release: "r", Packages: []BundlePackage{{Name: "../test.flist", Hash: hash}}}).Valid())
+	require.Error((&BundleManifest{Release: "r", Packages: []BundlePackage{{Name: "test.flist", Hash: "abc"}}}).Valid())
+}
+
+func TestBundleStorage(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	key := []byte{0xde, 0xad, 0xbe, 0xef}
+	require.NoError(os.MkdirAll(filepath.Join(dir, bundleBlocks), 0755))
+	require.NoError(os.WriteFile(filepath.Join(dir, bundleBlocks, "deadbeef"), []byte("block"), 0644))
+
+	b := bundle{source: dirSource(dir)}
+	reader, err := b.storage().Get(key)
+	require.NoError(err)
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	require.NoError(err)
+	require.Equal("block", string(data))
+}
+
+func TestBundleHashMismatch(t *testing.T) {
+	require := require.New(t)
+
+	dir := t.TempDir()
+	require.NoError(os.MkdirAll(filepath.Join(dir, bundleFlists), 0755))
+	require.NoError(os.WriteFile(filepath.Join(dir, bundleFlists, "test.flist"), []byte("not an flist"), 0644))
+
+	cache := &inMemoryCache{flist: t.TempDir(), file: t.TempDir()}
+	b := bundle{source: dirSource(dir)}
+	_, err := b.store(BundlePackage{Name: "test.flist", Hash: strings.Repeat("0", 64)}, cache)
+	require.Error(err)
+	require.Contains(err.Error(), "hash mismatch")
+}
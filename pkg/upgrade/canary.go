@@ -0,0 +1,341 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/upgrade/hub"
+)
+
+const (
+	// upgradeFile holds the state of the last upgrade
+	upgradeFile = "upgrade.json"
+
+	defaultHealthWindow = 10 * time.Minute
+	// gateRetries is how many times in a row a gate must fail
+	// before the upgrade is rolled back. This gives services
+	// some time to start after an upgrade.
+	gateRetries = 4
+)
+
+var (
+	// gateInterval is the interval between two checks of the health gates
+	gateInterval = 30 * time.Second
+)
+
+// upgradeState is the persisted state of the last upgrade. The previous
+// taglink is kept so the node can be rolled back to it.
+type upgradeState struct {
+	pkg.UpgradeReport
+	Previous hub.TagLink `json:"previous"`
+	Target   hub.TagLink `json:"target"`
+	// Ignore are the services that were already failing before the upgrade
+	Ignore []string `json:"ignore"`
+}
+
+func (u *Upgrader) upgradeFile() string {
+	return filepath.Join(u.root, upgradeFile)
+}
+
+func (u *Upgrader) loadState() (state upgradeState, ok bool, err error) {
+	data, err := os.ReadFile(u.upgradeFile())
+	if os.IsNotExist(err) {
+		return state, false, nil
+	} else if err != nil {
+		return state, false, errors.Wrap(err, "failed to read upgrade state")
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, false, errors.Wrap(err, "failed to decode upgrade state")
+	}
+
+	return state, true, nil
+}
+
+func (u *Upgrader) saveState(state upgradeState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode upgrade state")
+	}
+
+	tmp := u.upgradeFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write upgrade state")
+	}
+
+	return os.Rename(tmp, u.upgradeFile())
+}
+
+// LastUpgrade returns the report of the last upgrade
+func (u *Upgrader) LastUpgrade() (pkg.UpgradeReport, error) {
+	state, ok, err := u.loadState()
+	if err != nil {
+		return pkg.UpgradeReport{}, err
+	} else if !ok {
+		return pkg.UpgradeReport{}, fmt.Errorf("no upgrade was done on this node")
+	}
+
+	return state.UpgradeReport, nil
+}
+
+// isRolledBack returns true if the given target was rolled back before,
+// in that case the node is not upgraded to it again.
+func (u *Upgrader) isRolledBack(target hub.TagLink) bool {
+	state, ok, err := u.loadState()
+	if err != nil || !ok {
+		return false
+	}
+
+	return (state.State == pkg.UpgradeRolledBack || state.State == pkg.UpgradeRollbackFailed) &&
+		state.Target.Target == target.Target
+}
+
+// apply installs the target release with the install function. If the current
+// release is known, the files replaced by the upgrade are backed up so the node
+// can be rolled back to it locally, and the health gates are checked after the
+// restart by checkUpgrade
+func (u *Upgrader) apply(current, target hub.TagLink, install func() error) error {
+	if len(current.Target) == 0 {
+		// we can only roll back if we know the current release
+		if err := install(); err != nil {
+			return err
+		}
+
+		if err := u.boot.Set(target); err != nil {
+			return err
+		}
+
+		return ErrRestartNeeded
+	}
+
+	if err := u.startUpgrade(current, target); err != nil {
+		return errors.Wrap(err, "failed to record upgrade state")
+	}
+
+	err := install()
+	if err == nil {
+		err = u.boot.Set(target)
+	}
+
+	if err != nil {
+		if err := u.abortUpgrade(err); err != nil {
+			log.Error().Err(err).Msg("failed to restore the previous release")
+		}
+
+		return err
+	}
+
+	if err := u.upgraded(); err != nil {
+		return errors.Wrap(err, "failed to record upgrade state")
+	}
+
+	return ErrRestartNeeded
+}
+
+// startUpgrade records an upgrade from current to target, and starts
+// backing up the files of the current release. The upgrade is started
+// (and the health window opened) only once installed, see upgraded.
+func (u *Upgrader) startUpgrade(current, target hub.TagLink) error {
+	var ignore []string
+	if u.zinit != nil {
+		var err error
+		ignore, err = failingServices(u.zinit)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to list failing services before upgrade")
+		}
+	}
+
+	snapshot, err := newBackup(u.backupDir())
+	if err != nil {
+		return err
+	}
+
+	err = u.saveState(upgradeState{
+		UpgradeReport: pkg.UpgradeReport{
+			From:  current.Target,
+			To:    target.Target,
+			State: pkg.UpgradePending,
+		},
+		Previous: current,
+		Target:   target,
+		Ignore:   ignore,
+	})
+	if err != nil {
+		return err
+	}
+
+	u.snapshot = snapshot
+	return nil
+}
+
+// upgraded records that the new release is installed, the health
+// window starts now
+func (u *Upgrader) upgraded() error {
+	u.snapshot = nil
+
+	state, ok, err := u.loadState()
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("no upgrade in progress")
+	}
+
+	state.Started = uint64(time.Now().Unix())
+	return u.saveState(state)
+}
+
+// abortUpgrade restores the previous release after the new release
+// failed to install
+func (u *Upgrader) abortUpgrade(cause error) error {
+	u.snapshot = nil
+
+	state, ok, err := u.loadState()
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("no upgrade in progress")
+	}
+
+	state.Finished = uint64(time.Now().Unix())
+	state.State = pkg.UpgradeFailed
+	state.Reason = fmt.Sprintf("installation failed: %s", cause)
+
+	rollbackErr := u.rollback(state.Previous)
+	if rollbackErr != nil {
+		state.State = pkg.UpgradeRollbackFailed
+		state.Reason = fmt.Sprintf("%s, roll back failed: %s", state.Reason, rollbackErr)
+	}
+
+	if err := u.saveState(state); err != nil {
+		return err
+	}
+
+	return rollbackErr
+}
+
+// rollback restores the previous release from the local backup and
+// restarts the services that were restarted by the upgrade
+func (u *Upgrader) rollback(previous hub.TagLink) error {
+	snapshot, err := loadBackup(u.backupDir())
+	if err != nil {
+		return err
+	}
+
+	services, err := snapshot.restore()
+	if err != nil {
+		return errors.Wrap(err, "failed to restore previous release files")
+	}
+
+	if err := u.boot.Set(previous); err != nil {
+		return errors.Wrap(err, "failed to set boot taglink")
+	}
+
+	return u.ensureRestarted(services...)
+}
+
+// checkUpgrade runs the health gates if the last upgrade is still pending
+// until the health window is over. If a gate keeps failing the node
+// is rolled back to the previous release and ErrRestartNeeded is returned.
+func (u *Upgrader) checkUpgrade(ctx context.Context) error {
+	state, ok, err := u.loadState()
+	if err != nil {
+		return err
+	} else if !ok || state.State != pkg.UpgradePending {
+		return nil
+	}
+
+	var reason string
+	if state.Started == 0 {
+		// the upgrader was stopped before the new release was
+		// completely installed
+		reason = "installation was interrupted"
+	} else {
+		gates := u.gates
+		if u.zinit != nil {
+			gates = append([]HealthGate{NewZinitGate(u.zinit, state.Ignore...)}, gates...)
+		}
+
+		log.Info().
+			Str("from", state.From).
+			Str("to", state.To).
+			Stringer("window", u.healthWindow).
+			Msg("checking health of the new release")
+
+		reason, err = u.watchGates(ctx, time.Unix(int64(state.Started), 0), gates)
+		if err != nil {
+			return err
+		}
+	}
+
+	state.Finished = uint64(time.Now().Unix())
+	if len(reason) == 0 {
+		log.Info().Str("release", state.To).Msg("new release is healthy")
+		state.State = pkg.UpgradeHealthy
+		if err := os.RemoveAll(u.backupDir()); err != nil {
+			log.Error().Err(err).Msg("failed to remove previous release backup")
+		}
+		return u.saveState(state)
+	}
+
+	log.Error().Str("reason", reason).Str("to", state.From).Msg("new release is not healthy, rolling back")
+	state.Reason = reason
+	if err := u.rollback(state.Previous); err != nil {
+		// this is final, the node is not upgraded again to the same
+		// release and the report shows why the roll back failed
+		state.State = pkg.UpgradeRollbackFailed
+		state.Reason = fmt.Sprintf("%s, roll back failed: %s", reason, err)
+		if err := u.saveState(state); err != nil {
+			log.Error().Err(err).Msg("failed to save upgrade state")
+		}
+
+		return errors.Wrapf(err, "failed to roll back to '%s'", state.From)
+	}
+
+	state.State = pkg.UpgradeRolledBack
+	if err := u.saveState(state); err != nil {
+		return err
+	}
+
+	return ErrRestartNeeded
+}
+
+// watchGates checks the gates every gateInterval until the health window
+// is over. It returns the failure reason if a gate failed gateRetries
+// times in a row, or an empty reason if the release is healthy.
+func (u *Upgrader) watchGates(ctx context.Context, started time.Time, gates []HealthGate) (string, error) {
+	failures := make(map[string]int)
+	deadline := started.Add(u.healthWindow)
+
+	for {
+		for _, gate := range gates {
+			err := gate.Check(ctx, started)
+			if err == nil {
+				failures[gate.Name()] = 0
+				continue
+			}
+
+			failures[gate.Name()]++
+			log.Warn().Err(err).Str("gate", gate.Name()).Int("failures", failures[gate.Name()]).Msg("health gate failed")
+			if failures[gate.Name()] >= gateRetries {
+				return fmt.Sprintf("%s: %s", gate.Name(), err), nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return "", nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(gateInterval):
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/upgrade/hub"
+)
+
+type testGate struct {
+	err error
+}
+
+func (g *testGate) Name() string {
+	return "test"
+}
+
+func (g *testGate) Check(ctx context.Context, since time.Time) error {
+	return g.err
+}
+
+func TestUpgradeState(t *testing.T) {
+	require := require.New(t)
+	u := &Upgrader{root: t.TempDir()}
+
+	_, err := u.LastUpgrade()
+	require.Error(err)
+
+	previous := hub.TagLink{FList: hub.FList{Name: "production", Type: hub.TypeTagLink, Target: "tf-test/tags/v3.1.0"}}
+	target := hub.TagLink{FList: hub.FList{Name: "production", Type: hub.TypeTagLink, Target: "tf-test/tags/v3.2.0"}}
+
+	state := upgradeState{
+		UpgradeReport: pkg.UpgradeReport{
+			From:  previous.Target,
+			To:    target.Target,
+			State: pkg.UpgradePending,
+		},
+		Previous: previous,
+		Target:   target,
+	}
+	require.NoError(u.saveState(state))
+	require.False(u.isRolledBack(target))
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradePending, report.State)
+	require.Equal(previous.Target, report.From)
+
+	state.State = pkg.UpgradeRolledBack
+	state.Reason = "zinit: services are failing: noded"
+	require.NoError(u.saveState(state))
+
+	require.True(u.isRolledBack(target))
+	require.False(u.isRolledBack(previous))
+
+	loaded, ok, err := u.loadState()
+	require.NoError(err)
+	require.True(ok)
+	require.Equal(previous, loaded.Previous)
+	require.Equal(state.Reason, loaded.Reason)
+}
+
+func TestWatchGatesHealthy(t *testing.T) {
+	require := require.New(t)
+	u := &Upgrader{healthWindow: 0}
+
+	reason, err := u.watchGates(context.Background(), time.Now(), []HealthGate{&testGate{}})
+	require.NoError(err)
+	require.Empty(reason)
+}
+
+func TestWatchGatesCanceled(t *testing.T) {
+	require := require.New(t)
+	u := &Upgrader{healthWindow: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reason, err := u.watchGates(ctx, time.Now(), []HealthGate{&testGate{err: fmt.Errorf("not ready")}})
+	require.ErrorIs(err, context.Canceled)
+	require.Empty(reason)
+}
+
+// testUpgrader returns an upgrader that installs the file at path, and
+// a function to install a new release of it
+func testUpgrader(t *testing.T, path string, gates ...HealthGate) (*Upgrader, hub.TagLink, hub.TagLink, func(string) func() error) {
+	t.Helper()
+	root := t.TempDir()
+	u := &Upgrader{
+		root:  root,
+		boot:  Boot{tagFile: filepath.Join(root, "tag.info")},
+		gates: gates,
+	}
+
+	previous := hub.TagLink{FList: hub.FList{Name: "production", Type: hub.TypeTagLink, Target: "tf-test/tags/v3.1.0"}}
+	target := hub.TagLink{FList: hub.FList{Name: "production", Type: hub.TypeTagLink, Target: "tf-test/tags/v3.2.0"}}
+
+	require.NoError(t, os.WriteFile(path, []byte("v3.1.0"), 0644))
+	require.NoError(t, u.boot.Set(previous))
+
+	install := func(content string) func() error {
+		return func() error {
+			if err := u.snapshot.save(path); err != nil {
+				return err
+			}
+
+			return os.WriteFile(path, []byte(content), 0644)
+		}
+	}
+
+	return u, previous, target, install
+}
+
+func requireContent(t *testing.T, path, content string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+}
+
+func TestApplyFailed(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "zos")
+	u, previous, target, install := testUpgrader(t, path)
+
+	err := u.apply(previous, target, func() error {
+		if err := install("v3.2.0")(); err != nil {
+			return err
+		}
+
+		return fmt.Errorf("download failed")
+	})
+	require.Error(err)
+	require.Nil(u.snapshot)
+
+	requireContent(t, path, "v3.1.0")
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeFailed, report.State)
+	require.Zero(report.Started)
+	require.NotZero(report.Finished)
+
+	current, err := u.boot.Current()
+	require.NoError(err)
+	require.Equal(previous, current)
+}
+
+func TestCheckUpgradeHealthy(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "zos")
+	u, previous, target, install := testUpgrader(t, path, &testGate{})
+
+	err := u.apply(previous, target, install("v3.2.0"))
+	require.ErrorIs(err, ErrRestartNeeded)
+	requireContent(t, path, "v3.2.0")
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradePending, report.State)
+	require.NotZero(report.Started)
+
+	require.NoError(u.checkUpgrade(context.Background()))
+
+	report, err = u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeHealthy, report.State)
+
+	_, err = os.Stat(u.backupDir())
+	require.True(os.IsNotExist(err))
+	requireContent(t, path, "v3.2.0")
+}
+
+func TestCheckUpgradeRollback(t *testing.T) {
+	require := require.New(t)
+	gateInterval = time.Millisecond
+	defer func() { gateInterval = 30 * time.Second }()
+
+	path := filepath.Join(t.TempDir(), "zos")
+	u, previous, target, install := testUpgrader(t, path, &testGate{err: fmt.Errorf("not healthy")})
+	u.healthWindow = time.Hour
+
+	err := u.apply(previous, target, install("v3.2.0"))
+	require.ErrorIs(err, ErrRestartNeeded)
+
+	err = u.checkUpgrade(context.Background())
+	require.ErrorIs(err, ErrRestartNeeded)
+	requireContent(t, path, "v3.1.0")
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeRolledBack, report.State)
+	require.Contains(report.Reason, "not healthy")
+	require.True(u.isRolledBack(target))
+
+	current, err := u.boot.Current()
+	require.NoError(err)
+	require.Equal(previous, current)
+
+	// nothing to do anymore
+	require.NoError(u.checkUpgrade(context.Background()))
+}
+
+func TestCheckUpgradeInterrupted(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "zos")
+	u, previous, target, install := testUpgrader(t, path, &testGate{})
+
+	// upgrader stopped in the middle of the installation
+	require.NoError(u.startUpgrade(previous, target))
+	require.NoError(install("v3.2.0")())
+
+	err := u.checkUpgrade(context.Background())
+	require.ErrorIs(err, ErrRestartNeeded)
+	requireContent(t, path, "v3.1.0")
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeRolledBack, report.State)
+	require.Equal("installation was interrupted", report.Reason)
+}
+
+func TestCheckUpgradeRollbackFailed(t *testing.T) {
+	require := require.New(t)
+	path := filepath.Join(t.TempDir(), "zos")
+	u, previous, target, install := testUpgrader(t, path, &testGate{})
+
+	require.NoError(u.startUpgrade(previous, target))
+	require.NoError(install("v3.2.0")())
+	require.NoError(os.RemoveAll(u.backupDir()))
+
+	err := u.checkUpgrade(context.Background())
+	require.Error(err)
+	require.NotErrorIs(err, ErrRestartNeeded)
+
+	report, err := u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeRollbackFailed, report.State)
+	require.Contains(report.Reason, "roll back failed")
+	require.True(u.isRolledBack(target))
+
+	// the failure is final
+	require.NoError(u.checkUpgrade(context.Background()))
+	report, err = u.LastUpgrade()
+	require.NoError(err)
+	require.Equal(pkg.UpgradeRollbackFailed, report.State)
+}
@@ -0,0 +1,188 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/diagnostics"
+	"github.com/threefoldtech/test/pkg/stubs"
+	"github.com/threefoldtech/test/pkg/zinit"
+	"github.com/threefoldtech/zbus"
+)
+
+const (
+	healthcheckTask = "healthcheck"
+	// resultNotFound is the message of perf.ErrResultNotFound, the perf
+	// package is not imported to keep the upgrader dependencies small
+	resultNotFound = "result not found"
+)
+
+// HealthGate is a check that runs after an upgrade to make sure
+// the new release is working correctly. If a gate keeps failing
+// during the health window the node is rolled back to the previous
+// release.
+type HealthGate interface {
+	// Name of the gate, used in the rollback reason
+	Name() string
+	// Check returns an error if the gate failed. since is the time the
+	// new release was installed.
+	Check(ctx context.Context, since time.Time) error
+}
+
+// zinitGate fails if any of the zinit services is not running
+type zinitGate struct {
+	zinit *zinit.Client
+	// ignore services that were already failing before the upgrade
+	ignore []string
+}
+
+// NewZinitGate creates a gate that checks that zinit services are not
+// failing. The ignored services are not checked.
+func NewZinitGate(cl *zinit.Client, ignore ...string) HealthGate {
+	return &zinitGate{zinit: cl, ignore: ignore}
+}
+
+func (g *zinitGate) Name() string {
+	return "zinit"
+}
+
+func (g *zinitGate) Check(ctx context.Context, since time.Time) error {
+	failing, err := failingServices(g.zinit)
+	if err != nil {
+		return err
+	}
+
+	var services []string
+	for _, name := range failing {
+		if isIn(name, g.ignore) {
+			continue
+		}
+		services = append(services, name)
+	}
+
+	if len(services) != 0 {
+		return fmt.Errorf("services are failing: %s", strings.Join(services, ", "))
+	}
+
+	return nil
+}
+
+// failingServices returns the sorted names of the zinit services
+// that are in error or failure state
+func failingServices(cl *zinit.Client) ([]string, error) {
+	services, err := cl.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list zinit services")
+	}
+
+	var failing []string
+	for name, state := range services {
+		if state.Any(zinit.ServiceStateError, zinit.ServiceStateFailure) {
+			failing = append(failing, name)
+		}
+	}
+
+	sort.Strings(failing)
+	return failing, nil
+}
+
+type diagnosticsGate struct {
+	manager *diagnostics.DiagnosticsManager
+}
+
+// NewDiagnosticsGate creates a gate that fails if any of the
+// zbus modules is not responding
+func NewDiagnosticsGate(manager *diagnostics.DiagnosticsManager) HealthGate {
+	return &diagnosticsGate{manager: manager}
+}
+
+func (g *diagnosticsGate) Name() string {
+	return "diagnostics"
+}
+
+func (g *diagnosticsGate) Check(ctx context.Context, since time.Time) error {
+	report, err := g.manager.GetSystemDiagnostics(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get system diagnostics")
+	}
+
+	if report.SystemStatusOk {
+		return nil
+	}
+
+	var modules []string
+	for name, status := range report.ZosModules {
+		if status.Err != nil {
+			modules = append(modules, name)
+		}
+	}
+	sort.Strings(modules)
+
+	return fmt.Errorf("modules are not responding: %s", strings.Join(modules, ", "))
+}
+
+// taskResults gets the last result of a perf task, it's implemented
+// by the performance monitor stub
+type taskResults interface {
+	Get(ctx context.Context, task string) (pkg.TaskResult, error)
+}
+
+type healthcheckGate struct {
+	perf taskResults
+}
+
+// NewHealthcheckGate creates a gate that fails if the perf health check
+// that ran after the upgrade reported errors. Results of health checks
+// that ran before the upgrade are ignored.
+func NewHealthcheckGate(cl zbus.Client) HealthGate {
+	return &healthcheckGate{perf: stubs.NewPerformanceMonitorStub(cl)}
+}
+
+func (g *healthcheckGate) Name() string {
+	return "healthcheck"
+}
+
+func (g *healthcheckGate) Check(ctx context.Context, since time.Time) error {
+	result, err := g.perf.Get(ctx, healthcheckTask)
+	if isResultNotFound(err) {
+		// health check did not run yet since the node booted
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "failed to get health check result")
+	}
+
+	if result.Timestamp < uint64(since.Unix()) {
+		// health check did not run yet on the new release
+		return nil
+	}
+
+	checks, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected health check result type '%T'", result.Result)
+	}
+
+	var failed []string
+	for label, errs := range checks {
+		if list, ok := errs.([]interface{}); ok && len(list) != 0 {
+			failed = append(failed, fmt.Sprintf("%s: %v", label, list))
+		}
+	}
+	sort.Strings(failed)
+
+	if len(failed) != 0 {
+		return fmt.Errorf("health check failed (%s)", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// isResultNotFound checks if the error is perf.ErrResultNotFound, errors
+// are only passed as strings over zbus
+func isResultNotFound(err error) bool {
+	return err != nil && err.Error() == resultNotFound
+}
@@ -0,0 +1,48 @@
+package upgrade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/zbus"
+)
+
+type testResults struct {
+	result pkg.TaskResult
+	err    error
+}
+
+func (r *testResults) Get(ctx context.Context, task string) (pkg.TaskResult, error) {
+	return r.result, r.err
+}
+
+func TestHealthcheckGate(t *testing.T) {
+	require := require.New(t)
+	since := time.Now()
+	results := &testResults{}
+	gate := &healthcheckGate{perf: results}
+
+	// the health check did not run yet after the reboot
+	results.err = &zbus.CallError{Message: "result not found"}
+	require.NoError(gate.Check(context.Background(), since))
+
+	results.err = &zbus.CallError{Message: "failed to get the result"}
+	require.Error(gate.Check(context.Background(), since))
+
+	// results from before the upgrade are ignored
+	results.err = nil
+	results.result = pkg.TaskResult{
+		Timestamp: uint64(since.Add(-time.Minute).Unix()),
+		Result:    map[string]interface{}{"network": []interface{}{"unreachable"}},
+	}
+	require.NoError(gate.Check(context.Background(), since))
+
+	results.result.Timestamp = uint64(since.Add(time.Minute).Unix())
+	require.Error(gate.Check(context.Background(), since))
+
+	results.result.Result = map[string]interface{}{"network": []interface{}{}}
+	require.NoError(gate.Check(context.Background(), since))
+}
@@ -0,0 +1,268 @@
+package upgrade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/threefoldtech/test/pkg"
+	"github.com/threefoldtech/test/pkg/stubs"
+	"github.com/threefoldtech/test/pkg/upgrade/hub"
+)
+
+const (
+	// maintenanceFile holds the maintenance configuration
+	maintenanceFile = "maintenance.json"
+
+	// checkPendingEvery is how often the upgrader checks if a pending
+	// upgrade can be applied
+	checkPendingEvery = 5 * time.Minute
+)
+
+var _ pkg.Upgrader = (*Upgrader)(nil)
+
+// ErrNoPendingUpgrade is returned by ApplyNow if there is no pending upgrade
+var ErrNoPendingUpgrade = fmt.Errorf("no pending upgrade")
+
+func (u *Upgrader) maintenanceFile() string {
+	return filepath.Join(u.root, maintenanceFile)
+}
+
+// MaintenanceGet returns the current maintenance configuration
+func (u *Upgrader) MaintenanceGet() (pkg.MaintenanceConfig, error) {
+	var cfg pkg.MaintenanceConfig
+	data, err := os.ReadFile(u.maintenanceFile())
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, errors.Wrap(err, "failed to read maintenance config")
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, errors.Wrap(err, "failed to decode maintenance config")
+	}
+
+	return cfg, nil
+}
+
+// MaintenanceSet sets the maintenance configuration
+func (u *Upgrader) MaintenanceSet(cfg pkg.MaintenanceConfig) error {
+	if err := validateMaintenance(cfg); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode maintenance config")
+	}
+
+	if err := os.WriteFile(u.maintenanceFile(), data, 0644); err != nil {
+		return errors.Wrap(err, "failed to write maintenance config")
+	}
+
+	// the pending upgrade might be allowed now
+	u.notify()
+	return nil
+}
+
+// Pending returns the pending upgrade, the returned release is empty
+// if there is no pending upgrade
+func (u *Upgrader) Pending() (pkg.PendingUpgrade, error) {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return u.pending, nil
+}
+
+// ApplyNow applies the pending upgrade regardless of the maintenance windows
+func (u *Upgrader) ApplyNow() error {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if len(u.pending.Release) == 0 {
+		return ErrNoPendingUpgrade
+	}
+
+	log.Info().Str("release", u.pending.Release).Msg("applying pending upgrade on request")
+	u.force = u.pending.Release
+	u.notify()
+
+	return nil
+}
+
+// notify wakes up the upgrader to check for updates
+func (u *Upgrader) notify() {
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (u *Upgrader) hasPending() bool {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	return len(u.pending.Release) != 0
+}
+
+func (u *Upgrader) clearPending() {
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	u.pending = pkg.PendingUpgrade{}
+}
+
+// canApply checks if the release can be applied now. If not, the release
+// is marked as pending.
+func (u *Upgrader) canApply(ctx context.Context, release hub.TagLink) bool {
+	u.m.Lock()
+	forced := u.force == release.Target
+	u.m.Unlock()
+
+	reason := ""
+	if !forced {
+		reason = u.maintenanceReason(ctx, time.Now())
+	}
+
+	u.m.Lock()
+	defer u.m.Unlock()
+
+	if len(reason) == 0 {
+		u.force = ""
+		u.pending = pkg.PendingUpgrade{}
+		return true
+	}
+
+	if u.pending.Release != release.Target {
+		u.pending = pkg.PendingUpgrade{
+			Release: release.Target,
+			Since:   uint64(time.Now().Unix()),
+		}
+	}
+	u.pending.Reason = reason
+
+	log.Info().Str("release", release.Target).Str("reason", reason).Msg("upgrade is pending")
+	return false
+}
+
+// maintenanceReason returns why an upgrade can't be applied at the given
+// time. An empty reason means the upgrade is allowed.
+func (u *Upgrader) maintenanceReason(ctx context.Context, now time.Time) string {
+	cfg, err := u.MaintenanceGet()
+	if err != nil {
+		// a broken config should not block upgrades forever
+		log.Error().Err(err).Msg("failed to load maintenance config, ignoring")
+		return ""
+	}
+
+	if len(cfg.Windows) == 0 && !cfg.Idle {
+		return ""
+	}
+
+	if inWindows(cfg.Windows, now) {
+		return ""
+	}
+
+	if cfg.Idle {
+		idle, err := u.isIdle(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to check active workloads")
+		} else if idle {
+			return ""
+		}
+	}
+
+	switch {
+	case len(cfg.Windows) == 0:
+		return "node has active workloads"
+	case cfg.Idle:
+		return "outside of maintenance windows and node has active workloads"
+	default:
+		return "outside of maintenance windows"
+	}
+}
+
+// isIdle returns true if there are no active workloads on the node
+func (u *Upgrader) isIdle(ctx context.Context) (bool, error) {
+	if u.zcl == nil {
+		return false, fmt.Errorf("no zbus client configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	count, err := stubs.NewStatisticsStub(u.zcl).Workloads(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+// inWindows checks if t is inside any of the windows
+func inWindows(windows []pkg.MaintenanceWindow, t time.Time) bool {
+	t = t.UTC()
+	for _, w := range windows {
+		if inWindow(w, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inWindow(w pkg.MaintenanceWindow, t time.Time) bool {
+	hour := uint8(t.Hour())
+	day := t.Weekday()
+	if w.End <= w.Start && hour < w.End {
+		// we are in the part of the window after midnight
+		// so the window actually started the day before
+		day = (day + 6) % 7
+	}
+
+	if len(w.Days) != 0 && !containsDay(w.Days, day) {
+		return false
+	}
+
+	if w.Start < w.End {
+		return hour >= w.Start && hour < w.End
+	}
+
+	return hour >= w.Start || hour < w.End
+}
+
+func containsDay(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateMaintenance(cfg pkg.MaintenanceConfig) error {
+	for i, w := range cfg.Windows {
+		if w.Start > 23 {
+			return fmt.Errorf("window %d: invalid start hour '%d'", i, w.Start)
+		}
+		if w.End > 24 {
+			return fmt.Errorf("window %d: invalid end hour '%d'", i, w.End)
+		}
+		if w.Start == w.End {
+			return fmt.Errorf("window %d: start and end hours must be different", i)
+		}
+		for _, d := range w.Days {
+			if d < time.Sunday || d > time.Saturday {
+				return fmt.Errorf("window %d: invalid day '%d'", i, d)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package upgrade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/threefoldtech/test/pkg"
+)
+
+func TestInWindow(t *testing.T) {
+	// 2024-01-01 is a monday
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 1, day, hour, 30, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name   string
+		window pkg.MaintenanceWindow
+		time   time.Time
+		in     bool
+	}{
+		{"every day inside", pkg.MaintenanceWindow{Start: 2, End: 4}, at(1, 3), true},
+		{"every day before", pkg.MaintenanceWindow{Start: 2, End: 4}, at(1, 1), false},
+		{"every day end is exclusive", pkg.MaintenanceWindow{Start: 2, End: 4}, at(1, 4), false},
+		{"end of day", pkg.MaintenanceWindow{Start: 22, End: 24}, at(1, 23), true},
+		{"day inside", pkg.MaintenanceWindow{Days: []time.Weekday{time.Monday}, Start: 2, End: 4}, at(1, 3), true},
+		{"other day", pkg.MaintenanceWindow{Days: []time.Weekday{time.Tuesday}, Start: 2, End: 4}, at(1, 3), false},
+		{"over midnight before", pkg.MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 22, End: 2}, at(7, 23), true},
+		// monday 1am is part of the window that started on sunday
+		{"over midnight after", pkg.MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 22, End: 2}, at(8, 1), true},
+		{"over midnight wrong day", pkg.MaintenanceWindow{Days: []time.Weekday{time.Sunday}, Start: 22, End: 2}, at(7, 1), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			require.Equal(t, c.in, inWindow(c.window, c.time))
+		})
+	}
+}
+
+func TestValidateMaintenance(t *testing.T) {
+	require := require.New(t)
+
+	require.NoError(validateMaintenance(pkg.MaintenanceConfig{}))
+	require.NoError(validateMaintenance(pkg.MaintenanceConfig{
+		Windows: []pkg.MaintenanceWindow{{Start: 22, End: 2}, {Start: 0, End: 24}},
+	}))
+	require.Error(validateMaintenance(pkg.MaintenanceConfig{
+		Windows: []pkg.MaintenanceWindow{{Start: 24, End: 2}},
+	}))
+	require.Error(validateMaintenance(pkg.MaintenanceConfig{
+		Windows: []pkg.MaintenanceWindow{{Start: 2, End: 2}},
+	}))
+	require.Error(validateMaintenance(pkg.MaintenanceConfig{
+		Windows: []pkg.MaintenanceWindow{{Days: []time.Weekday{7}, Start: 2, End: 4}},
+	}))
+}
+
+func TestMaintenanceReason(t *testing.T) {
+	require := require.New(t)
+	u := &Upgrader{root: t.TempDir(), wake: make(chan struct{}, 1)}
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	// no config, always allowed
+	require.Empty(u.maintenanceReason(ctx, now))
+
+	require.NoError(u.MaintenanceSet(pkg.MaintenanceConfig{
+		Windows: []pkg.MaintenanceWindow{{Start: 2, End: 4}},
+	}))
+	require.NotEmpty(u.maintenanceReason(ctx, now))
+	require.Empty(u.maintenanceReason(ctx, now.Add(-7*time.Hour)))
+
+	cfg, err := u.MaintenanceGet()
+	require.NoError(err)
+	require.Len(cfg.Windows, 1)
+}
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/threefoldtech/0-fs/rofs"
 	"github.com/threefoldtech/0-fs/storage"
 	"github.com/threefoldtech/zbus"
+	"github.com/threefoldtech/test/pkg"
 	"github.com/threefoldtech/test/pkg/app"
 	"github.com/threefoldtech/test/pkg/environment"
 	"github.com/threefoldtech/test/pkg/stubs"
@@ -91,6 +93,20 @@ type Upgrader struct {
 	noZosUpgrade bool
 	hub          *hub.HubClient
 	storage      storage.Storage
+	healthWindow time.Duration
+	gates        []HealthGate
+	// snapshot backs up the files of the current release
+	// while a new release is installed
+	snapshot *backup
+
+	// pending is the upgrade waiting for a maintenance window
+	pending pkg.PendingUpgrade
+	// force is a release that must be applied regardless
+	// of the maintenance windows
+	force string
+	// wake triggers an update check
+	wake chan struct{}
+	m    sync.Mutex
 }
 
 // UpgraderOption interface
@@ -138,12 +154,32 @@ func Zinit(socket string) UpgraderOption {
 	}
 }
 
+// HealthWindow option sets how long the health gates are checked
+// after an upgrade before the new release is considered healthy
+func HealthWindow(d time.Duration) UpgraderOption {
+	return func(u *Upgrader) error {
+		u.healthWindow = d
+		return nil
+	}
+}
+
+// HealthGates option adds gates that are checked after an upgrade
+// in addition to the zinit services gate.
+func HealthGates(gates ...HealthGate) UpgraderOption {
+	return func(u *Upgrader) error {
+		u.gates = append(u.gates, gates...)
+		return nil
+	}
+}
+
 // NewUpgrader creates a new upgrader instance
 func NewUpgrader(root string, opts ...UpgraderOption) (*Upgrader, error) {
 	hubClient := hub.NewHubClient(defaultHubTimeout)
 	u := &Upgrader{
-		root: root,
-		hub:  hubClient,
+		root:         root,
+		hub:          hubClient,
+		healthWindow: defaultHealthWindow,
+		wake:         make(chan struct{}, 1),
 	}
 
 	for _, dir := range []string{u.fileCache(), u.flistCache()} {
@@ -204,6 +240,14 @@ func (u *Upgrader) Run(ctx context.Context) error {
 		return nil
 	}
 
+	// make sure the last upgrade is healthy before checking for new
+	// updates, otherwise roll back to the previous release
+	if err := u.checkUpgrade(ctx); errors.Is(err, ErrRestartNeeded) {
+		return err
+	} else if err != nil {
+		log.Error().Err(err).Msg("failed to check the last upgrade health")
+	}
+
 	// if the booting method is bootstrap then we run update periodically
 	// after u.nextUpdate to make sure all the modules are always up to date
 	for {
@@ -220,6 +264,7 @@ func (u *Upgrader) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-time.After(u.nextUpdate()):
+		case <-u.wake:
 		}
 
 	}
@@ -231,8 +276,14 @@ func (u *Upgrader) Version() semver.Version {
 
 // nextUpdate returns the interval until the next update
 // which is approximately 60 minutes + jitter interval(0-10 minutes)
-// to make sure not all nodes run upgrader at the same time
+// to make sure not all nodes run upgrader at the same time.
+// If an upgrade is pending, it's checked more often to not miss
+// the maintenance window
 func (u *Upgrader) nextUpdate() time.Duration {
+	if u.hasPending() {
+		return checkPendingEvery
+	}
+
 	jitter := rand.Intn(checkJitter)
 	next := checkForUpdateEvery + (time.Duration(jitter) * time.Minute)
 	log.Info().Str("after", next.String()).Msg("checking for update")
@@ -259,7 +310,40 @@ func (u *Upgrader) remote() (remote hub.TagLink, err error) {
 	return hub.NewTagLink(matches[0]), nil
 }
 
+// rolledOut checks that the target release is the version set on the chain,
+// and that it's safe to upgrade (or the node is part of the A/B testing farms)
+func (u *Upgrader) rolledOut(ctx context.Context, target hub.TagLink) (bool, error) {
+	env := environment.MustGet()
+	gw := stubs.NewSubstrateGatewayStub(u.zcl)
+	chainVer, testFarms, err := getRolloutConfig(ctx, gw)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get rollout config and version")
+	}
+
+	targetVer := target.Target[strings.LastIndex(target.Target, "/")+1:]
+
+	if env.RunningMode != environment.RunningDev && targetVer != chainVer.Version {
+		// nothing to do! target version is not the same as the chain
+		return false, nil
+	}
+
+	if !chainVer.SafeToUpgrade {
+		if !slices.Contains(testFarms, uint32(env.FarmID)) {
+			// nothing to do! waiting for the flag `safe to upgrade to be enabled after A/B testing`
+			// node is not a part of A/B testing
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 func (u *Upgrader) update(ctx context.Context) error {
+	if env := environment.MustGet(); len(env.UpgradeBundle) != 0 {
+		// offline upgrade, the hub is not used at all
+		return u.updateFromBundle(ctx, env.UpgradeBundle, env.UpgradeKeys)
+	}
+
 	// here we need to do a normal full update cycle
 	current, err := u.boot.Current()
 	if err != nil {
@@ -275,41 +359,33 @@ func (u *Upgrader) update(ctx context.Context) error {
 	// if the remote is different, we actually run the update and exit.
 	if remote.Target == current.Target {
 		// nothing to do!
+		u.clearPending()
 		return nil
 	}
 
-	env := environment.MustGet()
-	gw := stubs.NewSubstrateGatewayStub(u.zcl)
-	chainVer, testFarms, err := getRolloutConfig(ctx, gw)
-	if err != nil {
-		return errors.Wrap(err, "failed to get rollout config and version")
-	}
-
-	remoteVer := remote.Target[strings.LastIndex(remote.Target, "/")+1:]
-
-	if env.RunningMode != environment.RunningDev && remoteVer != chainVer.Version {
-		// nothing to do! hub version is not the same as the chain
+	if u.isRolledBack(remote) {
+		// this release failed the health gates before, wait for a new one
+		log.Debug().Str("release", remote.Target).Msg("release was rolled back, skipping")
 		return nil
 	}
 
-	if !chainVer.SafeToUpgrade {
-		if !slices.Contains(testFarms, uint32(env.FarmID)) {
-			// nothing to do! waiting for the flag `safe to upgrade to be enabled after A/B testing`
-			// node is not a part of A/B testing
-			return nil
-		}
+	if ok, err := u.rolledOut(ctx, remote); err != nil || !ok {
+		return err
 	}
 
-	log.Info().Str("running version", u.Version().String()).Str("updating to version", filepath.Base(remote.Target)).Msg("updating system...")
-	if err := u.updateTo(remote, &current); err != nil {
-		return errors.Wrapf(err, "failed to update to new tag '%s'", remote.Target)
+	if !u.canApply(ctx, remote) {
+		// wait for the maintenance window
+		return nil
 	}
 
-	if err := u.boot.Set(remote); err != nil {
-		return err
-	}
+	log.Info().Str("running version", u.Version().String()).Str("updating to version", filepath.Base(remote.Target)).Msg("updating system...")
+	return u.apply(current, remote, func() error {
+		if err := u.updateTo(remote, &current); err != nil {
+			return errors.Wrapf(err, "failed to update to new tag '%s'", remote.Target)
+		}
 
-	return ErrRestartNeeded
+		return nil
+	})
 }
 
 // updateTo updates flist packages to match "link"
@@ -477,12 +553,23 @@ func (u *Upgrader) install(repo, name string) error {
 	}
 	defer store.Close()
 
+	if err := u.installStore(store, u.storage, cache); err != nil {
+		return errors.Wrapf(err, "failed to install flist: %s/%s", repo, name)
+	}
+
+	return nil
+}
+
+// installStore copies the content of the flist store to the root filesystem
+// (file blocks are fetched from the given storage) and restarts the services
+// defined in the flist
+func (u *Upgrader) installStore(store meta.Walker, blocks storage.Storage, cache cache) error {
 	if err := safe(func() error {
 		// copy is done in a safe closer to avoid interrupting
 		// the installation
-		return u.copyRecursive(store, "/", cache)
+		return u.copyRecursiveFrom(store, blocks, "/", cache)
 	}); err != nil {
-		return errors.Wrapf(err, "failed to install flist: %s/%s", repo, name)
+		return err
 	}
 
 	services, err := u.servicesFromStore(store)
@@ -490,6 +577,12 @@ func (u *Upgrader) install(repo, name string) error {
 		return errors.Wrap(err, "failed to list services from flist")
 	}
 
+	if u.snapshot != nil {
+		if err := u.snapshot.restarted(services...); err != nil {
+			return errors.Wrap(err, "failed to backup restarted services")
+		}
+	}
+
 	return u.ensureRestarted(services...)
 }
 
@@ -562,6 +655,10 @@ func (u *Upgrader) ensureRestarted(service ...string) error {
 }
 
 func (u *Upgrader) copyRecursive(store meta.Walker, destination string, cache cache, skip ...string) error {
+	return u.copyRecursiveFrom(store, u.storage, destination, cache, skip...)
+}
+
+func (u *Upgrader) copyRecursiveFrom(store meta.Walker, blocks storage.Storage, destination string, cache cache, skip ...string) error {
 	return store.Walk("", func(path string, info meta.Meta) error {
 		dest := filepath.Join(destination, path)
 		if isIn(dest, skip) {
@@ -584,7 +681,7 @@ func (u *Upgrader) copyRecursive(store meta.Walker, destination string, cache ca
 		switch stat.Type {
 		case meta.RegularType:
 			// regular file (or other types that we don't handle)
-			return u.copyFile(dest, info, cache)
+			return u.copyFile(dest, info, blocks, cache)
 		case meta.LinkType:
 			// fmt.Println("link target", stat.LinkTarget)
 			target := stat.LinkTarget
@@ -594,6 +691,12 @@ func (u *Upgrader) copyRecursive(store meta.Walker, destination string, cache ca
 				target = filepath.Join(destination, stat.LinkTarget)
 			}
 
+			if u.snapshot != nil {
+				if err := u.snapshot.save(dest); err != nil {
+					return errors.Wrapf(err, "failed to backup '%s'", dest)
+				}
+			}
+
 			if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
 				return err
 			}
@@ -616,9 +719,16 @@ func isIn(target string, list []string) bool {
 	return false
 }
 
-func (u *Upgrader) copyFile(dst string, src meta.Meta, cache cache) error {
+func (u *Upgrader) copyFile(dst string, src meta.Meta, blocks storage.Storage, cache cache) error {
 	log.Info().Str("source", src.Name()).Str("destination", dst).Msg("copy file")
 
+	if u.snapshot != nil {
+		// keep the file of the current release for roll back
+		if err := u.snapshot.save(dst); err != nil {
+			return errors.Wrapf(err, "failed to backup '%s'", dst)
+		}
+	}
+
 	var (
 		isNew  = false
 		dstOld string
@@ -657,13 +767,13 @@ func (u *Upgrader) copyFile(dst string, src meta.Meta, cache cache) error {
 	}
 	defer fDst.Close()
 
-	fsCache := rofs.NewCache(cache.fileCache(), u.storage)
+	fsCache := rofs.NewCache(cache.fileCache(), blocks)
 	fSrc, err := fsCache.CheckAndGet(src)
 	if err != nil {
 		return err
 	}
 
-	if _, err := io.Copy(fDst, fSrc); err != nil {
+	if _, err = io.Copy(fDst, fSrc); err != nil {
 		return err
 	}
 
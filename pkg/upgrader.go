@@ -0,0 +1,55 @@
+package pkg
+
+//go:generate mkdir -p stubs
+//go:generate zbusc -module identityd -version 0.0.1 -name upgrader -package stubs github.com/threefoldtech/test/pkg+Upgrader stubs/upgrader_stub.go
+
+import "time"
+
+// MaintenanceWindow is a time range (in UTC) where the node is allowed to
+// apply upgrades.
+type MaintenanceWindow struct {
+	// Days of the week where the window is open, empty means every day
+	Days []time.Weekday `json:"days"`
+	// Start hour of the window [0-23]
+	Start uint8 `json:"start"`
+	// End hour of the window [1-24], the window is open until the end hour
+	// exclusive. If End is smaller than Start the window spans midnight
+	End uint8 `json:"end"`
+}
+
+// MaintenanceConfig configures when the node is allowed to apply upgrades.
+// If no windows are configured, and Idle is not set, upgrades are applied
+// as soon as they are available.
+type MaintenanceConfig struct {
+	// Windows where upgrades are allowed
+	Windows []MaintenanceWindow `json:"windows"`
+	// Idle allows upgrades when the node has no active workloads. If set
+	// with no windows, upgrades are only applied when the node is idle.
+	Idle bool `json:"idle"`
+}
+
+// PendingUpgrade is a release that is available but not applied yet
+// because the node is outside of its maintenance windows.
+type PendingUpgrade struct {
+	// Release is the pending release, empty if no upgrade is pending
+	Release string `json:"release"`
+	// Since is when the release was first found
+	Since uint64 `json:"since"`
+	// Reason why the release is not applied yet
+	Reason string `json:"reason"`
+}
+
+// Upgrader interface (provided by identityd)
+type Upgrader interface {
+	// LastUpgrade returns the report of the last upgrade
+	LastUpgrade() (UpgradeReport, error)
+	// MaintenanceGet returns the current maintenance configuration
+	MaintenanceGet() (MaintenanceConfig, error)
+	// MaintenanceSet sets the maintenance configuration
+	MaintenanceSet(cfg MaintenanceConfig) error
+	// Pending returns the pending upgrade if any
+	Pending() (PendingUpgrade, error)
+	// ApplyNow applies the pending upgrade right away regardless of the
+	// maintenance windows, this is meant for urgent (security) releases
+	ApplyNow() error
+}
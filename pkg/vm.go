@@ -218,6 +218,10 @@ type NetMetric struct {
 	NetRxBytes   uint64 `json:"net_rx_bytes"`
 	NetTxPackets uint64 `json:"net_tx_packets"`
 	NetTxBytes   uint64 `json:"net_tx_bytes"`
+
+	// Shaping holds the traffic shaping statistics if rate
+	// limits are applied on this network
+	Shaping *ShapingStats `json:"shaping,omitempty"`
 }
 
 // Nu calculate network units
@@ -19,7 +19,6 @@ import (
 const (
 	failuresBeforeDestroy = 4
 	monitorEvery          = 10 * time.Second
-	logrotateEvery        = 10 * time.Minute
 	cleanupEvery          = 10 * time.Minute
 )
 
@@ -28,18 +27,14 @@ var (
 	// the monitoring will not try to restart this machine
 	// when it detects that it is down.
 	permanent = struct{}{}
-
-	rotator = rotate.NewRotator(
-		rotate.MaxSize(8*rotate.Megabytes),
-		rotate.TailSize(4*rotate.Megabytes),
-	)
 )
 
-func (m *Module) logrotate(ctx context.Context) error {
-	log.Debug().Msg("running log rotations for vms")
+// logFiles returns the console logs of the running vms, logs of the
+// machines that are not running anymore are deleted by the rotation
+func (m *Module) logFiles() (map[string][]string, error) {
 	running, err := FindAll()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	names := make([]string, 0, len(running))
@@ -47,17 +42,21 @@ func (m *Module) logrotate(ctx context.Context) error {
 		names = append(names, name)
 	}
 
-	return rotator.RotateAll(filepath.Join(m.root, logsDir), names...)
+	return map[string][]string{filepath.Join(m.root, logsDir): names}, nil
 }
 
 // Monitor start vms  monitoring
 func (m *Module) Monitor(ctx context.Context) {
 
+	go rotate.NewLogsService(rotate.Job{
+		Name:  "vms",
+		Files: m.logFiles,
+		Prune: true,
+	}).Run(ctx)
+
 	go func() {
 		monTicker := time.NewTicker(monitorEvery)
 		defer monTicker.Stop()
-		logTicker := time.NewTicker(logrotateEvery)
-		defer logTicker.Stop()
 		cleanupTicker := time.NewTicker(cleanupEvery)
 		defer cleanupTicker.Stop()
 
@@ -67,10 +66,6 @@ func (m *Module) Monitor(ctx context.Context) {
 				if err := m.monitor(ctx); err != nil {
 					log.Error().Err(err).Msg("failed to run monitoring")
 				}
-			case <-logTicker.C:
-				if err := m.logrotate(ctx); err != nil {
-					log.Error().Err(err).Msg("failed to run log rotation")
-				}
 			case <-cleanupTicker.C:
 				if err := m.cleanupCidata(); err != nil {
 					log.Error().Err(err).Msg("failed to run cleanup")
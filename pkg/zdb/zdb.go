@@ -11,6 +11,7 @@ import (
 type Namespace struct {
 	Name              string         `yaml:"name"`
 	DataLimit         gridtypes.Unit `yaml:"data_limits_bytes"`
+	DataSize          gridtypes.Unit `yaml:"data_size_bytes"`
 	DataDiskFreespace gridtypes.Unit `yaml:"data_disk_freespace_bytes"`
 	Mode              string         `yaml:"mode"`
 	PasswordProtected bool           `yaml:"password"`